@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"forum/internal/database"
+)
+
+// runBackupCommand handles `forum backup [-keep N] <dest>`.
+// If dest is a directory (or ends in /), a timestamped backup is written into
+// it and only the newest N are kept; otherwise dest is the backup file itself.
+func runBackupCommand(db *database.DB, args []string) error {
+	flags := flag.NewFlagSet("backup", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	keep := flags.Int("keep", 0, "timestamped backups to keep in <dest> (0 = all)")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 || *keep < 0 {
+		return fmt.Errorf("usage: forum backup [-keep N] <file|directory>")
+	}
+	dest := flags.Arg(0)
+
+	info, err := os.Stat(dest)
+	isDir := strings.HasSuffix(dest, "/") || (err == nil && info.IsDir())
+
+	if isDir {
+		path, err := database.BackupToDir(db, dest, *keep)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Backup written: %s\n", path)
+		return nil
+	}
+
+	if *keep > 0 {
+		return fmt.Errorf("-keep needs a directory as the backup destination")
+	}
+	if err := database.Backup(db, dest); err != nil {
+		return err
+	}
+	fmt.Printf("Backup written: %s\n", dest)
+	return nil
+}
+
+// runRestoreCommand handles `forum restore <src>`
+func runRestoreCommand(db *database.DB, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: forum restore <backup file>")
+	}
+
+	if err := database.Restore(db, args[0]); err != nil {
+		return err
+	}
+
+	// Backups taken by an older release are brought up to the current schema
+	if err := database.RunMigrations(db); err != nil {
+		return err
+	}
+	current, err := database.CurrentVersion(db)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Restored %s (schema version: %d)\n", args[0], current)
+	return nil
+}
+
+// runScheduledBackups writes a timestamped backup into dir every interval,
+// keeping the newest keep. It runs for the lifetime of the server.
+func runScheduledBackups(db *database.DB, dir string, interval time.Duration, keep int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		path, err := database.BackupToDir(db, dir, keep)
+		if err != nil {
+			log.Printf("Scheduled backup failed: %v", err)
+			continue
+		}
+		log.Printf("Scheduled backup written: %s", path)
+	}
+}
@@ -9,11 +9,14 @@ import (
 	"strings"
 	"time"
 
+	"forum/internal/avatar"
 	"forum/internal/config"
 	"forum/internal/database"
 	"forum/internal/handlers"
+	"forum/internal/mail"
 	"forum/internal/middleware"
 	"forum/internal/services"
+	"forum/internal/store/sqlstore"
 )
 
 // findProjectRoot walks up the directory tree to find the project root
@@ -43,6 +46,29 @@ func findProjectRoot() (string, error) {
 	}
 }
 
+// newMailer picks where emails go: SMTP when SMTP_HOST is set, else
+// MAIL_FILE, else stdout (the server log)
+func newMailer(cfg *config.Config) mail.Mailer {
+	switch {
+	case cfg.SMTPHost != "":
+		log.Printf("Mail: SMTP via %s:%d", cfg.SMTPHost, cfg.SMTPPort)
+		return mail.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom)
+	case cfg.MailFile != "":
+		log.Printf("Mail: appending to %s", cfg.MailFile)
+		return mail.NewFileMailer(cfg.MailFile, cfg.MailFrom)
+	default:
+		log.Printf("Mail: writing to stdout (set SMTP_HOST or MAIL_FILE to change)")
+		return mail.NewWriterMailer(os.Stdout, cfg.MailFrom)
+	}
+}
+
+// minEmailVerifySecretLen is the shortest EMAIL_VERIFY_SECRET the server accepts
+const minEmailVerifySecretLen = 32
+
+// avatarDir holds uploaded avatar thumbnails, served by staticFileServer
+// under /static/avatars/
+const avatarDir = "web/static/avatars"
+
 // staticFileServer creates a secure static file server WITHOUT directory listing
 func staticFileServer() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -148,20 +174,74 @@ func main() {
 		log.Fatal("Failed to initialize database:", err)
 	}
 	defer db.Close()
+	log.Printf("Database backend: %s", db.Dialect)
+
+	// Subcommands (e.g. `forum migrate status`) run instead of the server
+	if len(os.Args) > 1 {
+		if err := runCommand(db, cfg, os.Args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// Anyone who knows the key can forge verification links, so there is
+	// no built-in one to fall back to
+	if len(cfg.EmailVerifySecret) < minEmailVerifySecretLen {
+		log.Fatalf("EMAIL_VERIFY_SECRET must be set to at least %d random characters (e.g. openssl rand -hex 32)",
+			minEmailVerifySecretLen)
+	}
 
 	if err := database.RunMigrations(db); err != nil {
 		log.Fatal("Failed to run migrations:", err)
 	}
 
+	schemaVersion, err := database.CurrentVersion(db)
+	if err != nil {
+		log.Fatal("Failed to read schema version:", err)
+	}
+	log.Printf("Database schema version: %d (latest: %d)", schemaVersion, database.LatestVersion())
+
+	// Full-text search index (optional: search falls back to LIKE matching without it)
+	if err := database.EnsureSearchIndex(db); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+
+	// Scheduled backups (optional: enabled by BACKUP_DIR)
+	if cfg.BackupDir != "" {
+		if db.Dialect == database.SQLite {
+			log.Printf("Backups: every %s to %s (keeping %d)", cfg.BackupInterval, cfg.BackupDir, cfg.BackupKeep)
+			go runScheduledBackups(db, cfg.BackupDir, cfg.BackupInterval, cfg.BackupKeep)
+		} else {
+			log.Printf("Warning: BACKUP_DIR is ignored: %v", database.ErrBackupUnsupported)
+		}
+	}
+
+	// Initialize storage (SQL implementation of the store interfaces)
+	st := sqlstore.New(db)
+
 	// Initialize services
-	userService := services.NewUserService(db)
-	sessionService := services.NewSessionService(db)
-	likesService := services.NewLikesService(db)
+	auditService := services.NewAuditService(st)
+	avatars := avatar.NewStore(avatarDir, "/static/avatars/", cfg.AvatarMaxBytes)
+	userService := services.NewUserService(st, st, auditService, avatars,
+		cfg.UsernameChangeInterval, cfg.UsernameReservePeriod)
+	sessionService := services.NewSessionService(st, auditService)
+	likesService := services.NewLikesService(st, st, st, st, auditService, cfg.Reactions, cfg.Karma)
+	postService := services.NewPostService(st, st, st, auditService, cfg.MaxReplyDepth, cfg.UnverifiedPostLimit)
+	moderationService := services.NewModerationService(st, st, auditService, cfg.Karma)
+	mailer := newMailer(cfg)
+	resetService := services.NewPasswordResetService(st, st, mailer, auditService,
+		cfg.BaseURL, cfg.PasswordResetTTL)
+	verifyService := services.NewEmailVerificationService(st, mailer, auditService,
+		cfg.EmailVerifySecret, cfg.BaseURL, cfg.EmailVerifyTTL)
 
 	// Initialize handlers
-	forumHandler := handlers.NewForumHandler(db)
-	authHandler := handlers.NewAuthHandler(userService, sessionService)
+	forumHandler := handlers.NewForumHandler(postService, likesService, st, st, st, st, cfg.PageSize)
+	authHandler := handlers.NewAuthHandler(userService, sessionService, resetService, verifyService)
 	likesHandler := handlers.NewLikesHandler(likesService)
+	moderationHandler := handlers.NewModerationHandler(forumHandler, moderationService)
+	auditHandler := handlers.NewAuditHandler(forumHandler, auditService)
+	profileHandler := handlers.NewProfileHandler(forumHandler, userService)
+	accountHandler := handlers.NewAccountHandler(forumHandler, userService, sessionService, verifyService)
 
 	// Initialize middleware
 	authMiddleware := middleware.NewAuthMiddleware(sessionService)
@@ -199,16 +279,31 @@ func main() {
 
 	// Public routes with optional auth (shows user info if logged in)
 	mux.HandleFunc("/category/", wrapOptionalAuth(authMiddleware, forumHandler.CategoryView))
-	mux.HandleFunc("/post/", handlePostRoutes(authMiddleware, forumHandler, likesHandler))
+	mux.HandleFunc("/post/", handlePostRoutes(authMiddleware, forumHandler, likesHandler, moderationHandler))
+	mux.HandleFunc("/search", wrapOptionalAuth(authMiddleware, forumHandler.Search))
+	mux.HandleFunc("/user/", wrapOptionalAuth(authMiddleware, profileHandler.Profile))
 
 	// Auth routes
 	mux.HandleFunc("/register", authHandler.Register)
 	mux.HandleFunc("/login", authHandler.Login)
 	mux.HandleFunc("/logout", authHandler.Logout)
+	mux.HandleFunc("/forgot-password", authHandler.ForgotPassword)
+	mux.HandleFunc("/reset-password", authHandler.ResetPassword)
+	mux.HandleFunc("/verify-email", authHandler.VerifyEmail)
 
 	// Protected routes (require login)
 	mux.Handle("/post/create", authMiddleware.RequireAuth(http.HandlerFunc(forumHandler.CreatePost)))
-	mux.HandleFunc("/comment/", handleCommentRoutes(authMiddleware, forumHandler, likesHandler))
+	mux.HandleFunc("/comment/", handleCommentRoutes(authMiddleware, forumHandler, likesHandler, moderationHandler))
+	mux.Handle("/account", authMiddleware.RequireAuth(http.HandlerFunc(accountHandler.Account)))
+	mux.Handle("/account/avatar", authMiddleware.RequireAuth(http.HandlerFunc(accountHandler.Avatar)))
+	mux.Handle("/account/password", authMiddleware.RequireAuth(http.HandlerFunc(accountHandler.Password)))
+	mux.Handle("/account/email", authMiddleware.RequireAuth(http.HandlerFunc(accountHandler.Email)))
+	mux.Handle("/account/username", authMiddleware.RequireAuth(http.HandlerFunc(accountHandler.Username)))
+	mux.Handle("/account/verify-email", authMiddleware.RequireAuth(http.HandlerFunc(accountHandler.ResendVerification)))
+
+	// Admin routes (the handlers check users.is_admin)
+	mux.Handle("/admin/deleted", authMiddleware.RequireAuth(http.HandlerFunc(moderationHandler.DeletedContent)))
+	mux.Handle("/admin/audit", authMiddleware.RequireAuth(http.HandlerFunc(auditHandler.AuditLog)))
 
 	// Home and 404 handler
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
@@ -239,13 +334,29 @@ func main() {
 	log.Fatal(server.ListenAndServe())
 }
 
+// runCommand dispatches command-line subcommands
+func runCommand(db *database.DB, cfg *config.Config, args []string) error {
+	switch args[0] {
+	case "migrate":
+		return runMigrateCommand(db, args[1:])
+	case "repair":
+		return runRepairCommand(db, cfg, args[1:])
+	case "backup":
+		return runBackupCommand(db, args[1:])
+	case "restore":
+		return runRestoreCommand(db, args[1:])
+	default:
+		return fmt.Errorf("unknown command: %s (available: migrate, repair, backup, restore)", args[0])
+	}
+}
+
 // Helper to wrap handlers with optional auth
 func wrapOptionalAuth(authMiddleware *middleware.AuthMiddleware, handler func(http.ResponseWriter, *http.Request)) http.HandlerFunc {
 	return authMiddleware.OptionalAuth(http.HandlerFunc(handler)).ServeHTTP
 }
 
-// Handle post routes - differentiates between viewing posts and like/dislike actions
-func handlePostRoutes(authMiddleware *middleware.AuthMiddleware, forumHandler *handlers.ForumHandler, likesHandler *handlers.LikesHandler) http.HandlerFunc {
+// Handle post routes - differentiates between viewing posts and like/dislike/react/delete/edit/pin/lock actions
+func handlePostRoutes(authMiddleware *middleware.AuthMiddleware, forumHandler *handlers.ForumHandler, likesHandler *handlers.LikesHandler, moderationHandler *handlers.ModerationHandler) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		path := r.URL.Path
 
@@ -260,14 +371,50 @@ func handlePostRoutes(authMiddleware *middleware.AuthMiddleware, forumHandler *h
 			authMiddleware.RequireAuth(http.HandlerFunc(likesHandler.DislikePost)).ServeHTTP(w, r)
 			return
 		}
+		if strings.HasSuffix(path, "/react") {
+			authMiddleware.RequireAuth(http.HandlerFunc(likesHandler.ReactPost)).ServeHTTP(w, r)
+			return
+		}
+		if strings.HasSuffix(path, "/delete") {
+			authMiddleware.RequireAuth(http.HandlerFunc(moderationHandler.DeletePost)).ServeHTTP(w, r)
+			return
+		}
+		if strings.HasSuffix(path, "/restore") {
+			authMiddleware.RequireAuth(http.HandlerFunc(moderationHandler.RestorePost)).ServeHTTP(w, r)
+			return
+		}
+		if strings.HasSuffix(path, "/edit") {
+			authMiddleware.RequireAuth(http.HandlerFunc(forumHandler.EditPost)).ServeHTTP(w, r)
+			return
+		}
+		if strings.HasSuffix(path, "/pin") {
+			authMiddleware.RequireAuth(http.HandlerFunc(moderationHandler.PinPost)).ServeHTTP(w, r)
+			return
+		}
+		if strings.HasSuffix(path, "/unpin") {
+			authMiddleware.RequireAuth(http.HandlerFunc(moderationHandler.UnpinPost)).ServeHTTP(w, r)
+			return
+		}
+		if strings.HasSuffix(path, "/lock") {
+			authMiddleware.RequireAuth(http.HandlerFunc(moderationHandler.LockPost)).ServeHTTP(w, r)
+			return
+		}
+		if strings.HasSuffix(path, "/unlock") {
+			authMiddleware.RequireAuth(http.HandlerFunc(moderationHandler.UnlockPost)).ServeHTTP(w, r)
+			return
+		}
+		if strings.HasSuffix(path, "/history") {
+			authMiddleware.OptionalAuth(http.HandlerFunc(forumHandler.PostHistory)).ServeHTTP(w, r)
+			return
+		}
 
 		// Otherwise it's a post view - optional auth
 		authMiddleware.OptionalAuth(http.HandlerFunc(forumHandler.PostView)).ServeHTTP(w, r)
 	}
 }
 
-// handleCommentRoutes handles comment creation and like/dislike actions
-func handleCommentRoutes(authMiddleware *middleware.AuthMiddleware, forumHandler *handlers.ForumHandler, likesHandler *handlers.LikesHandler) http.HandlerFunc {
+// handleCommentRoutes handles comment creation and like/dislike/react/edit/delete actions
+func handleCommentRoutes(authMiddleware *middleware.AuthMiddleware, forumHandler *handlers.ForumHandler, likesHandler *handlers.LikesHandler, moderationHandler *handlers.ModerationHandler) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		path := r.URL.Path
 
@@ -288,8 +435,16 @@ func handleCommentRoutes(authMiddleware *middleware.AuthMiddleware, forumHandler
 		if pathAfterComment == "" ||
 			pathAfterComment == "like" ||
 			pathAfterComment == "dislike" ||
+			pathAfterComment == "react" ||
+			pathAfterComment == "delete" ||
+			pathAfterComment == "restore" ||
+			pathAfterComment == "edit" ||
 			strings.HasPrefix(pathAfterComment, "like/") ||
-			strings.HasPrefix(pathAfterComment, "dislike/") {
+			strings.HasPrefix(pathAfterComment, "dislike/") ||
+			strings.HasPrefix(pathAfterComment, "react/") ||
+			strings.HasPrefix(pathAfterComment, "delete/") ||
+			strings.HasPrefix(pathAfterComment, "restore/") ||
+			strings.HasPrefix(pathAfterComment, "edit/") {
 			handlers.RenderError(w, 400, "Bad Request", "Comment ID is required")
 			log.Printf("Security: Missing comment ID in route: %s", path)
 			return
@@ -306,6 +461,22 @@ func handleCommentRoutes(authMiddleware *middleware.AuthMiddleware, forumHandler
 			authMiddleware.RequireAuth(http.HandlerFunc(likesHandler.DislikeComment)).ServeHTTP(w, r)
 			return
 		}
+		if strings.HasSuffix(path, "/react") {
+			authMiddleware.RequireAuth(http.HandlerFunc(likesHandler.ReactComment)).ServeHTTP(w, r)
+			return
+		}
+		if strings.HasSuffix(path, "/delete") {
+			authMiddleware.RequireAuth(http.HandlerFunc(moderationHandler.DeleteComment)).ServeHTTP(w, r)
+			return
+		}
+		if strings.HasSuffix(path, "/restore") {
+			authMiddleware.RequireAuth(http.HandlerFunc(moderationHandler.RestoreComment)).ServeHTTP(w, r)
+			return
+		}
+		if strings.HasSuffix(path, "/edit") {
+			authMiddleware.RequireAuth(http.HandlerFunc(forumHandler.EditComment)).ServeHTTP(w, r)
+			return
+		}
 
 		// Otherwise it's a comment creation - requires auth
 		authMiddleware.RequireAuth(http.HandlerFunc(forumHandler.CreateComment)).ServeHTTP(w, r)
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"forum/internal/database"
+)
+
+// runMigrateCommand handles `forum migrate <status|up|down [steps]|to <version>>`
+func runMigrateCommand(db *database.DB, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: forum migrate <status|up|down [steps]|to <version>>")
+	}
+
+	current, err := database.CurrentVersion(db)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "status":
+		fmt.Printf("Schema version: %d (latest: %d)\n", current, database.LatestVersion())
+		if current < database.LatestVersion() {
+			fmt.Printf("%d pending migration(s)\n", database.LatestVersion()-current)
+		}
+		return nil
+
+	case "up":
+		if err := database.RunMigrations(db); err != nil {
+			return err
+		}
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps <= 0 {
+				return fmt.Errorf("invalid step count: %s", args[1])
+			}
+		}
+		target := current - steps
+		if target < 0 {
+			target = 0
+		}
+		if err := database.MigrateTo(db, target); err != nil {
+			return err
+		}
+
+	case "to":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: forum migrate to <version>")
+		}
+		target, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid version: %s", args[1])
+		}
+		if err := database.MigrateTo(db, target); err != nil {
+			return err
+		}
+
+	default:
+		return fmt.Errorf("unknown migrate command: %s", args[0])
+	}
+
+	current, err = database.CurrentVersion(db)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Schema version: %d (latest: %d)\n", current, database.LatestVersion())
+	return nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+
+	"forum/internal/config"
+	"forum/internal/database"
+)
+
+// runRepairCommand handles `forum repair <counters|karma>`
+func runRepairCommand(db *database.DB, cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: forum repair <counters|karma>")
+	}
+
+	if err := database.RunMigrations(db); err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "counters":
+		posts, comments, err := database.RepairCounters(db)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Counters repaired: %d post(s), %d comment(s) were out of sync\n", posts, comments)
+		return nil
+
+	case "karma":
+		users, err := database.RebuildKarma(db, cfg.Karma.Post, cfg.Karma.Comment)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Karma rebuilt (post weight %d, comment weight %d): %d user(s) changed\n",
+			cfg.Karma.Post, cfg.Karma.Comment, users)
+		return nil
+
+	default:
+		return fmt.Errorf("unknown repair command: %s", args[0])
+	}
+}
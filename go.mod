@@ -4,6 +4,8 @@ go 1.24.6
 
 require (
 	github.com/google/uuid v1.6.0
+	github.com/lib/pq v1.10.9
 	github.com/mattn/go-sqlite3 v1.14.32
 	golang.org/x/crypto v0.42.0
+	golang.org/x/net v0.44.0
 )
@@ -0,0 +1,158 @@
+// Package avatar turns uploaded images into square PNG thumbnails and stores
+// them under a content-addressed name (the SHA-256 of the thumbnail), so the
+// same picture is stored once and a file never changes after it is written.
+package avatar
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	// Decoders for the accepted upload formats
+	_ "image/gif"
+	_ "image/jpeg"
+)
+
+// Size is the width and height of a thumbnail in pixels
+const Size = 128
+
+// maxPixels bounds the decoded size of an upload, so a small file that
+// claims huge dimensions cannot exhaust memory
+const maxPixels = 4096 * 4096
+
+var (
+	ErrTooLarge    = errors.New("image file is too large")
+	ErrUnsupported = errors.New("image must be a PNG, JPEG or GIF")
+	ErrInvalid     = errors.New("image cannot be read")
+	ErrDimensions  = errors.New("image dimensions are too large")
+)
+
+// Store writes thumbnails to a directory served at urlPrefix
+type Store struct {
+	dir       string
+	urlPrefix string
+	maxBytes  int
+}
+
+// NewStore returns a store for thumbnails in dir, linked as urlPrefix + name.
+// Uploads larger than maxBytes are rejected.
+func NewStore(dir, urlPrefix string, maxBytes int) *Store {
+	return &Store{dir: dir, urlPrefix: urlPrefix, maxBytes: maxBytes}
+}
+
+// MaxBytes is the largest upload Save accepts
+func (s *Store) MaxBytes() int {
+	return s.maxBytes
+}
+
+// Save makes a thumbnail of an uploaded image, writes it unless a file with
+// the same content exists, and returns its URL
+func (s *Store) Save(data []byte) (string, error) {
+	if len(data) > s.maxBytes {
+		return "", ErrTooLarge
+	}
+	thumb, err := Thumbnail(data)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(thumb)
+	name := hex.EncodeToString(sum[:]) + ".png"
+	path := filepath.Join(s.dir, name)
+	if _, err := os.Stat(path); err == nil {
+		return s.urlPrefix + name, nil
+	}
+
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return "", err
+	}
+	// Write under a temporary name first so a file is never seen half-written
+	tmp, err := os.CreateTemp(s.dir, ".upload-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(thumb); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+	return s.urlPrefix + name, nil
+}
+
+// Thumbnail checks that data is a PNG, JPEG or GIF by its content (not its
+// name), crops the middle square and scales it to Size×Size, re-encoded as PNG
+func Thumbnail(data []byte) ([]byte, error) {
+	switch http.DetectContentType(data) {
+	case "image/png", "image/jpeg", "image/gif":
+	default:
+		return nil, ErrUnsupported
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, ErrInvalid
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return nil, ErrDimensions
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalid
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, scaleSquare(src, Size)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// scaleSquare crops the centered square of src and scales it to size×size,
+// averaging a grid of up to 4×4 samples per output pixel
+func scaleSquare(src image.Image, size int) *image.RGBA {
+	b := src.Bounds()
+	side := min(b.Dx(), b.Dy())
+	x0 := b.Min.X + (b.Dx()-side)/2
+	y0 := b.Min.Y + (b.Dy()-side)/2
+
+	samples := min(max(side/size, 1), 4)
+	n := uint32(samples * samples)
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			var r, g, bl, a uint32
+			for sy := 0; sy < samples; sy++ {
+				for sx := 0; sx < samples; sx++ {
+					// Sample at the centers of a samples×samples grid over the output pixel
+					px := x0 + ((2*x*samples+2*sx+1)*side)/(2*size*samples)
+					py := y0 + ((2*y*samples+2*sy+1)*side)/(2*size*samples)
+					cr, cg, cb, ca := src.At(px, py).RGBA()
+					r, g, bl, a = r+cr, g+cg, bl+cb, a+ca
+				}
+			}
+			dst.SetRGBA64(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(bl / n), A: uint16(a / n)})
+		}
+	}
+	return dst
+}
@@ -1,20 +1,110 @@
 package config
 
 import (
+	"log"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"forum/internal/models"
 )
 
+// defaultReactions are offered next to the like (👍) and dislike (👎) buttons
+const defaultReactions = "love:❤️,laugh:😂,party:🎉,thinking:🤔"
+
 type Config struct {
 	Port        string
 	DatabaseURL string
 	JWTSecret   string
+	PageSize    int // Posts per page in listings
+
+	// Levels of replies allowed below a top-level comment; 0 disables replies
+	MaxReplyDepth int
+
+	// Reactions offered on posts and comments besides like and dislike
+	Reactions []models.ReactionKind
+
+	// Karma a like (or minus a dislike) earns the author of a post or comment
+	Karma models.KarmaWeights
+
+	// Largest avatar upload accepted, in bytes
+	AvatarMaxBytes int
+
+	// How often a user may change their username, and how long an old
+	// username stays reserved for its previous owner
+	UsernameChangeInterval time.Duration
+	UsernameReservePeriod  time.Duration
+
+	// Public address of the forum, used in links sent by email
+	BaseURL string
+
+	// Outgoing mail: SMTP when SMTPHost is set, otherwise appended to
+	// MailFile, or written to stdout when that is empty too
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	MailFrom     string
+	MailFile     string
+
+	// How long a password reset link can be used
+	PasswordResetTTL time.Duration
+
+	// Key that signs email verification links; there is no default, the
+	// server refuses to start without one
+	EmailVerifySecret string
+
+	// How long an email verification link can be used, and how many posts
+	// and comments an unverified account may create per day (0 = none)
+	EmailVerifyTTL      time.Duration
+	UnverifiedPostLimit int
+
+	// Scheduled backups (SQLite only); disabled unless BackupDir is set
+	BackupDir      string
+	BackupInterval time.Duration
+	BackupKeep     int // Timestamped backups to keep, 0 = all
 }
 
 func Load() *Config {
+	port := getEnv("PORT", "8080")
 	return &Config{
-		Port:        getEnv("PORT", "8080"),
+		Port:        port,
 		DatabaseURL: getEnv("DATABASE_URL", "forum.db"),
 		JWTSecret:   getEnv("JWT_SECRET", "your-secret-key-change-in-production"),
+		PageSize:    getEnvInt("PAGE_SIZE", 20, 1, 100),
+
+		MaxReplyDepth: getEnvInt("MAX_REPLY_DEPTH", 5, 0, 20),
+		Reactions:     getEnvReactions("REACTIONS", defaultReactions),
+		Karma: models.KarmaWeights{
+			Post:    getEnvInt("KARMA_POST_WEIGHT", 2, 0, 100),
+			Comment: getEnvInt("KARMA_COMMENT_WEIGHT", 1, 0, 100),
+		},
+
+		AvatarMaxBytes: getEnvInt("AVATAR_MAX_BYTES", 2<<20, 1<<10, 20<<20),
+
+		UsernameChangeInterval: getEnvDuration("USERNAME_CHANGE_INTERVAL", 30*24*time.Hour),
+		UsernameReservePeriod:  getEnvDuration("USERNAME_RESERVE_PERIOD", 90*24*time.Hour),
+
+		BaseURL: strings.TrimRight(getEnv("BASE_URL", "http://localhost:"+port), "/"),
+
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     getEnvInt("SMTP_PORT", 587, 1, 65535),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		MailFrom:     getEnv("MAIL_FROM", "forum@localhost"),
+		MailFile:     getEnv("MAIL_FILE", ""),
+
+		PasswordResetTTL: getEnvDuration("PASSWORD_RESET_TTL", time.Hour),
+
+		EmailVerifySecret:   getEnv("EMAIL_VERIFY_SECRET", ""),
+		EmailVerifyTTL:      getEnvDuration("EMAIL_VERIFY_TTL", 48*time.Hour),
+		UnverifiedPostLimit: getEnvInt("UNVERIFIED_POST_LIMIT", 20, 0, 1000),
+
+		BackupDir:      getEnv("BACKUP_DIR", ""),
+		BackupInterval: getEnvDuration("BACKUP_INTERVAL", 24*time.Hour),
+		BackupKeep:     getEnvInt("BACKUP_KEEP", 7, 0, 1000),
 	}
 }
 
@@ -24,3 +114,65 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvInt reads an integer setting, falling back to defaultValue when it is
+// missing or outside [min, max]
+func getEnvInt(key string, defaultValue, min, max int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < min || n > max {
+		log.Printf("Warning: invalid %s=%q (must be %d-%d), using %d", key, value, min, max, defaultValue)
+		return defaultValue
+	}
+	return n
+}
+
+// getEnvDuration reads a duration setting such as "6h" or "30m", falling back
+// to defaultValue when it is missing, invalid or not positive
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Printf("Warning: invalid %s=%q (e.g. 24h or 30m), using %s", key, value, defaultValue)
+		return defaultValue
+	}
+	return d
+}
+
+// reactionNameRe matches a reaction name as stored in reactions.reaction
+var reactionNameRe = regexp.MustCompile(`^[a-z][a-z0-9_]{0,19}$`)
+
+// getEnvReactions reads a reaction set such as "love:❤️,laugh:😂", falling
+// back to defaultValue when it is missing or has an invalid or repeated
+// entry. "like" and "dislike" are the votes and can't be listed.
+func getEnvReactions(key, defaultValue string) []models.ReactionKind {
+	value := getEnv(key, defaultValue)
+	kinds, ok := parseReactions(value)
+	if !ok {
+		log.Printf("Warning: invalid %s=%q (e.g. %q), using the default", key, value, defaultValue)
+		kinds, _ = parseReactions(defaultValue)
+	}
+	return kinds
+}
+
+func parseReactions(value string) ([]models.ReactionKind, bool) {
+	var kinds []models.ReactionKind
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(value, ",") {
+		name, emoji, found := strings.Cut(strings.TrimSpace(entry), ":")
+		name, emoji = strings.TrimSpace(name), strings.TrimSpace(emoji)
+		if !found || !reactionNameRe.MatchString(name) || emoji == "" || len(emoji) > 32 ||
+			name == models.ReactionLike || name == models.ReactionDislike || seen[name] {
+			return nil, false
+		}
+		seen[name] = true
+		kinds = append(kinds, models.ReactionKind{Name: name, Emoji: emoji})
+	}
+	return kinds, true
+}
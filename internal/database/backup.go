@@ -0,0 +1,243 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// ErrBackupUnsupported is returned by Backup and Restore on PostgreSQL
+var ErrBackupUnsupported = errors.New("backup and restore need a SQLite database (use pg_dump and pg_restore for PostgreSQL)")
+
+const (
+	// Pages copied per backup step. Between steps the source is unlocked,
+	// so the running server can keep reading and writing during a backup.
+	backupStepPages = 256
+	backupStepPause = 10 * time.Millisecond
+
+	// Timestamped backups written by BackupToDir are named forum-<timestamp>.db
+	backupFilePrefix = "forum-"
+	backupFileSuffix = ".db"
+	backupTimeFormat = "20060102-150405"
+)
+
+// Backup copies the database to dest with SQLite's online backup API, which is
+// safe while the server is running (unlike copying forum.db, which misses
+// anything still in forum.db-wal). The copy is written next to dest, checked
+// with PRAGMA integrity_check and only then renamed to dest.
+func Backup(db *DB, dest string) error {
+	if db.Dialect != SQLite {
+		return ErrBackupUnsupported
+	}
+
+	tmp := dest + ".tmp"
+	os.Remove(tmp)
+	if err := backupToFile(db.DB, tmp); err != nil {
+		removeDatabaseFiles(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, dest); err != nil {
+		removeDatabaseFiles(tmp)
+		return err
+	}
+	return nil
+}
+
+// BackupToDir writes a timestamped backup into dir and then deletes the oldest
+// ones so that at most keep remain (keep <= 0 keeps them all).
+// It returns the path of the new backup.
+func BackupToDir(db *DB, dir string, keep int) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	dest := filepath.Join(dir, backupFilePrefix+time.Now().Format(backupTimeFormat)+backupFileSuffix)
+	if err := Backup(db, dest); err != nil {
+		return "", err
+	}
+
+	if keep > 0 {
+		if _, err := RotateBackups(dir, keep); err != nil {
+			return dest, fmt.Errorf("backup written to %s, but rotation failed: %w", dest, err)
+		}
+	}
+	return dest, nil
+}
+
+// RotateBackups deletes all but the newest keep timestamped backups in dir
+// and returns the deleted paths. Other files in dir are left alone.
+func RotateBackups(dir string, keep int) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.Type().IsRegular() && strings.HasPrefix(name, backupFilePrefix) && strings.HasSuffix(name, backupFileSuffix) {
+			stamp := strings.TrimSuffix(strings.TrimPrefix(name, backupFilePrefix), backupFileSuffix)
+			if _, err := time.Parse(backupTimeFormat, stamp); err == nil {
+				backups = append(backups, name)
+			}
+		}
+	}
+	if len(backups) <= keep {
+		return nil, nil
+	}
+
+	// The timestamp format sorts chronologically, newest last
+	sort.Strings(backups)
+	var removed []string
+	for _, name := range backups[:len(backups)-keep] {
+		path := filepath.Join(dir, name)
+		if err := os.Remove(path); err != nil {
+			return removed, err
+		}
+		removed = append(removed, path)
+	}
+	return removed, nil
+}
+
+// Restore replaces the contents of the database with the backup at src.
+// The backup is checked first (integrity and schema version), and is copied
+// in with the online backup API so open connections see the restored data.
+func Restore(db *DB, src string) error {
+	if db.Dialect != SQLite {
+		return ErrBackupUnsupported
+	}
+	if _, err := os.Stat(src); err != nil {
+		return err
+	}
+
+	// mode=ro keeps a mistyped path from creating an empty database
+	backup, err := sql.Open("sqlite3", "file:"+src+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer backup.Close()
+
+	if err := CheckIntegrity(backup); err != nil {
+		return fmt.Errorf("backup %s is damaged: %w", src, err)
+	}
+
+	var version sql.NullInt64
+	if err := backup.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&version); err != nil {
+		return fmt.Errorf("%s is not a forum database: %w", src, err)
+	}
+	if int(version.Int64) > LatestVersion() {
+		return fmt.Errorf("backup %s has schema version %d, newer than this binary supports (%d)",
+			src, version.Int64, LatestVersion())
+	}
+
+	if err := copyDatabase(db.DB, backup); err != nil {
+		return err
+	}
+	return CheckIntegrity(db.DB)
+}
+
+// CheckIntegrity runs PRAGMA integrity_check and returns an error listing the
+// problems it reports
+func CheckIntegrity(db *sql.DB) error {
+	rows, err := db.Query(`PRAGMA integrity_check`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var msg string
+		if err := rows.Scan(&msg); err != nil {
+			return err
+		}
+		if msg != "ok" {
+			problems = append(problems, msg)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("integrity check failed: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// backupToFile copies src into a new database file at path, switches the copy
+// out of WAL mode so it is a single self-contained file, and checks it
+func backupToFile(src *sql.DB, path string) error {
+	dest, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return err
+	}
+	defer dest.Close()
+
+	if err := copyDatabase(dest, src); err != nil {
+		return err
+	}
+	if _, err := dest.Exec(`PRAGMA journal_mode=DELETE`); err != nil {
+		return err
+	}
+	return CheckIntegrity(dest)
+}
+
+// copyDatabase overwrites the main database of dest with src, page by page
+func copyDatabase(dest, src *sql.DB) error {
+	ctx := context.Background()
+
+	destConn, err := dest.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer destConn.Close()
+
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+
+	return destConn.Raw(func(destRaw any) error {
+		return srcConn.Raw(func(srcRaw any) error {
+			destSQLite, ok1 := destRaw.(*sqlite3.SQLiteConn)
+			srcSQLite, ok2 := srcRaw.(*sqlite3.SQLiteConn)
+			if !ok1 || !ok2 {
+				return ErrBackupUnsupported
+			}
+
+			b, err := destSQLite.Backup("main", srcSQLite, "main")
+			if err != nil {
+				return err
+			}
+			for {
+				done, err := b.Step(backupStepPages)
+				if err != nil {
+					b.Finish()
+					return err
+				}
+				if done {
+					break
+				}
+				time.Sleep(backupStepPause)
+			}
+			return b.Finish()
+		})
+	})
+}
+
+// removeDatabaseFiles deletes a database file and its WAL side files
+func removeDatabaseFiles(path string) {
+	for _, suffix := range []string{"", "-wal", "-shm", "-journal"} {
+		os.Remove(path + suffix)
+	}
+}
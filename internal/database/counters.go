@@ -0,0 +1,54 @@
+package database
+
+// Recount queries for the denormalized counters added in migration 0002
+// (votes are counted from reactions since migration 0008).
+// They only touch rows whose stored value differs from the source tables.
+const (
+	postCountsQuery = `
+		(SELECT COUNT(*) FROM reactions r WHERE r.target_type = 'post' AND r.target_id = posts.id AND r.reaction = 'like'),
+		(SELECT COUNT(*) FROM reactions r WHERE r.target_type = 'post' AND r.target_id = posts.id AND r.reaction = 'dislike'),
+		(SELECT COUNT(*) FROM comments c WHERE c.post_id = posts.id)`
+
+	commentCountsQuery = `
+		(SELECT COUNT(*) FROM reactions r WHERE r.target_type = 'comment' AND r.target_id = comments.id AND r.reaction = 'like'),
+		(SELECT COUNT(*) FROM reactions r WHERE r.target_type = 'comment' AND r.target_id = comments.id AND r.reaction = 'dislike'),
+		(SELECT COUNT(*) FROM comments c WHERE c.parent_id = comments.id)`
+
+	repairPostCounters = `
+		UPDATE posts SET (like_count, dislike_count, reply_count) = (SELECT ` + postCountsQuery + `)
+		WHERE (like_count, dislike_count, reply_count) <> (SELECT ` + postCountsQuery + `)`
+
+	repairCommentCounters = `
+		UPDATE comments SET (like_count, dislike_count, reply_count) = (SELECT ` + commentCountsQuery + `)
+		WHERE (like_count, dislike_count, reply_count) <> (SELECT ` + commentCountsQuery + `)`
+)
+
+// RepairCounters recomputes like_count, dislike_count and reply_count on posts
+// and comments from reactions and comments. The triggers keep them in sync,
+// so this is only needed after manual edits or a bug.
+// It returns how many posts and comments had drifted.
+func RepairCounters(db *DB) (posts int64, comments int64, err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(repairPostCounters)
+	if err != nil {
+		return 0, 0, err
+	}
+	if posts, err = result.RowsAffected(); err != nil {
+		return 0, 0, err
+	}
+
+	result, err = tx.Exec(repairCommentCounters)
+	if err != nil {
+		return 0, 0, err
+	}
+	if comments, err = result.RowsAffected(); err != nil {
+		return 0, 0, err
+	}
+
+	return posts, comments, tx.Commit()
+}
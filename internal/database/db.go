@@ -2,138 +2,86 @@ package database
 
 import (
 	"database/sql"
+	"strconv"
+	"strings"
 
+	_ "github.com/lib/pq"
 	_ "github.com/mattn/go-sqlite3"
 )
 
-func InitDB(dbPath string) (*sql.DB, error) {
-	db, err := sql.Open("sqlite3", dbPath+"?_foreign_keys=on&_journal_mode=WAL")
-	if err != nil {
-		return nil, err
-	}
-
-	if err = db.Ping(); err != nil {
-		return nil, err
-	}
-
-	return db, nil
-}
+// Dialect identifies the SQL flavour of the underlying database
+type Dialect string
 
-func RunMigrations(db *sql.DB) error {
-	schema := `
-	CREATE TABLE IF NOT EXISTS users (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		uuid TEXT UNIQUE NOT NULL,
-		username VARCHAR(50) UNIQUE NOT NULL,
-		email VARCHAR(100) UNIQUE NOT NULL,
-		password_hash VARCHAR(255) NOT NULL,
-		avatar_url VARCHAR(255),
-		is_admin BOOLEAN DEFAULT FALSE,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-	);
-
-	CREATE TABLE IF NOT EXISTS sessions (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		token TEXT UNIQUE NOT NULL,
-		user_id INTEGER NOT NULL,
-		expires_at DATETIME NOT NULL,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
-	);
+const (
+	SQLite   Dialect = "sqlite3"
+	Postgres Dialect = "postgres"
+)
 
-	CREATE TABLE IF NOT EXISTS categories (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		name VARCHAR(100) NOT NULL,
-		description TEXT,
-		slug VARCHAR(100) UNIQUE NOT NULL,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
-	);
+// DB is a database handle that knows which dialect it speaks
+type DB struct {
+	*sql.DB
+	Dialect Dialect
 
-	CREATE TABLE IF NOT EXISTS posts (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		title VARCHAR(255) NOT NULL,
-		content TEXT NOT NULL,
-		user_id INTEGER NOT NULL,
-		is_pinned BOOLEAN DEFAULT FALSE,
-		is_locked BOOLEAN DEFAULT FALSE,
-		view_count INTEGER DEFAULT 0,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (user_id) REFERENCES users(id)
-	);
+	// FullTextSearch is set by EnsureSearchIndex when the search index is available
+	FullTextSearch bool
+}
 
-	CREATE TABLE IF NOT EXISTS post_categories (
-		post_id INTEGER NOT NULL,
-		category_id INTEGER NOT NULL,
-		PRIMARY KEY (post_id, category_id),
-		FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
-		FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE CASCADE
-	);
+// DialectFromURL picks the backend from DATABASE_URL:
+// postgres:// and postgresql:// URLs use PostgreSQL, anything else is a SQLite file path
+func DialectFromURL(databaseURL string) Dialect {
+	if strings.HasPrefix(databaseURL, "postgres://") || strings.HasPrefix(databaseURL, "postgresql://") {
+		return Postgres
+	}
+	return SQLite
+}
 
-	CREATE TABLE IF NOT EXISTS comments (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		content TEXT NOT NULL,
-		user_id INTEGER NOT NULL,
-		post_id INTEGER NOT NULL,
-		parent_id INTEGER,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (user_id) REFERENCES users(id),
-		FOREIGN KEY (post_id) REFERENCES posts(id),
-		FOREIGN KEY (parent_id) REFERENCES comments(id)
-	);
+func InitDB(databaseURL string) (*DB, error) {
+	dialect := DialectFromURL(databaseURL)
 
-	CREATE TABLE IF NOT EXISTS post_likes (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		user_id INTEGER NOT NULL,
-		post_id INTEGER NOT NULL,
-		is_like BOOLEAN NOT NULL,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
-		FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
-		UNIQUE(user_id, post_id)
-	);
+	dsn := databaseURL
+	if dialect == SQLite {
+		// _txlock=immediate makes transactions take the write lock when they
+		// begin, so concurrent ones wait their turn instead of failing with
+		// "database is locked" when they first write
+		dsn = databaseURL + "?_foreign_keys=on&_journal_mode=WAL&_txlock=immediate"
+	}
 
-	CREATE TABLE IF NOT EXISTS comment_likes (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		user_id INTEGER NOT NULL,
-		comment_id INTEGER NOT NULL,
-		is_like BOOLEAN NOT NULL,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
-		FOREIGN KEY (comment_id) REFERENCES comments(id) ON DELETE CASCADE,
-		UNIQUE(user_id, comment_id)
-	);
+	db, err := sql.Open(string(dialect), dsn)
+	if err != nil {
+		return nil, err
+	}
 
-	-- Create indexes for better performance
-	CREATE INDEX IF NOT EXISTS idx_posts_user_id ON posts(user_id);
-	CREATE INDEX IF NOT EXISTS idx_posts_created_at ON posts(created_at);
-	CREATE INDEX IF NOT EXISTS idx_post_categories_post_id ON post_categories(post_id);
-	CREATE INDEX IF NOT EXISTS idx_post_categories_category_id ON post_categories(category_id);
-	CREATE INDEX IF NOT EXISTS idx_comments_post_id ON comments(post_id);
-	CREATE INDEX IF NOT EXISTS idx_comments_user_id ON comments(user_id);
-	CREATE INDEX IF NOT EXISTS idx_sessions_token ON sessions(token);
-	CREATE INDEX IF NOT EXISTS idx_sessions_expires_at ON sessions(expires_at);
-	CREATE INDEX IF NOT EXISTS idx_post_likes_post_id ON post_likes(post_id);
-	CREATE INDEX IF NOT EXISTS idx_post_likes_user_id ON post_likes(user_id);
-	CREATE INDEX IF NOT EXISTS idx_comment_likes_comment_id ON comment_likes(comment_id);
-	CREATE INDEX IF NOT EXISTS idx_comment_likes_user_id ON comment_likes(user_id);
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
 
-	-- Insert default categories (5 categories now)
-	INSERT OR IGNORE INTO categories (id, name, description, slug) VALUES 
-		(1, 'General Discussion', 'General topics and discussions', 'general'),
-		(2, 'Tech Talk', 'Technology and programming discussions', 'tech'),
-		(3, 'Announcements', 'Important announcements', 'announcements'),
-		(4, 'Help & Support', 'Get help and support from the community', 'help-support'),
-		(5, 'Off-Topic', 'Casual discussions and off-topic conversations', 'off-topic');
+	return &DB{DB: db, Dialect: dialect}, nil
+}
 
-	-- Create a default admin user (username: admin, password: admin123)
-	INSERT OR IGNORE INTO users (id, uuid, username, email, password_hash, is_admin) VALUES 
-		(1, '550e8400-e29b-41d4-a716-446655440000', 'admin', 'admin@forum.local', 
-		 '$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy', TRUE);
-	`
+// Rebind converts ? placeholders to the dialect's bind syntax ($1, $2, ... for PostgreSQL).
+// Question marks inside single-quoted string literals are left alone.
+func (db *DB) Rebind(query string) string {
+	if db.Dialect != Postgres || !strings.Contains(query, "?") {
+		return query
+	}
 
-	_, err := db.Exec(schema)
-	return err
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+	n := 0
+	inString := false
+	for _, r := range query {
+		switch {
+		case r == '\'':
+			inString = !inString
+			b.WriteRune(r)
+		case r == '?' && !inString:
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
 }
@@ -0,0 +1,29 @@
+package database
+
+// karmaQuery computes a user's karma from the votes on their posts and
+// comments, weighted by the two placeholders (post weight, comment weight).
+// Votes on one's own posts and comments, and on deleted ones, don't count.
+const karmaQuery = `
+		? * (SELECT COALESCE(SUM(CASE r.reaction WHEN 'like' THEN 1 ELSE -1 END), 0)
+		     FROM reactions r JOIN posts p ON r.target_type = 'post' AND r.target_id = p.id
+		     WHERE p.user_id = users.id AND p.deleted_at IS NULL AND r.user_id <> users.id AND r.reaction IN ('like', 'dislike'))
+		+ ? * (SELECT COALESCE(SUM(CASE r.reaction WHEN 'like' THEN 1 ELSE -1 END), 0)
+		     FROM reactions r JOIN comments c ON r.target_type = 'comment' AND r.target_id = c.id
+		     WHERE c.user_id = users.id AND c.deleted_at IS NULL AND r.user_id <> users.id AND r.reaction IN ('like', 'dislike'))`
+
+const rebuildKarma = `
+	UPDATE users SET karma = (SELECT ` + karmaQuery + `)
+	WHERE karma <> (SELECT ` + karmaQuery + `)`
+
+// RebuildKarma recomputes users.karma from the votes in reactions with the
+// given weights. LikesService keeps karma up to date as votes change, so
+// this is needed for votes from before migration 0009, after changing the
+// weights, or after votes disappeared with a deleted user or post.
+// It returns how many users' karma changed.
+func RebuildKarma(db *DB, postWeight, commentWeight int) (int64, error) {
+	result, err := db.Exec(db.Rebind(rebuildKarma), postWeight, commentWeight, postWeight, commentWeight)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,162 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+)
+
+// Migration is a single numbered schema change, written once per dialect
+type Migration struct {
+	Version  int
+	Name     string
+	SQLite   Script
+	Postgres Script
+}
+
+// Script holds the SQL for one dialect.
+// Up moves the schema from Version-1 to Version, Down reverses it.
+type Script struct {
+	Up   string
+	Down string
+}
+
+// script returns the SQL for the given dialect
+func (m Migration) script(dialect Dialect) Script {
+	if dialect == Postgres {
+		return m.Postgres
+	}
+	return m.SQLite
+}
+
+// createMigrationsTable is the bookkeeping table that records applied versions
+var createMigrationsTable = map[Dialect]string{
+	SQLite: `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`,
+	Postgres: `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	)`,
+}
+
+// sortedMigrations returns the registered migrations ordered by version
+// and checks that versions are unique and contiguous starting from 1
+func sortedMigrations() ([]Migration, error) {
+	list := make([]Migration, len(migrations))
+	copy(list, migrations)
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+
+	for i, m := range list {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("migration %04d_%s: expected version %d (versions must be contiguous)", m.Version, m.Name, i+1)
+		}
+	}
+	return list, nil
+}
+
+// LatestVersion returns the highest migration version known to this binary
+func LatestVersion() int {
+	latest := 0
+	for _, m := range migrations {
+		if m.Version > latest {
+			latest = m.Version
+		}
+	}
+	return latest
+}
+
+// CurrentVersion returns the schema version recorded in the database (0 = empty)
+func CurrentVersion(db *DB) (int, error) {
+	if _, err := db.Exec(createMigrationsTable[db.Dialect]); err != nil {
+		return 0, err
+	}
+
+	var version sql.NullInt64
+	err := db.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&version)
+	if err != nil {
+		return 0, err
+	}
+	return int(version.Int64), nil
+}
+
+// RunMigrations applies every pending migration in order
+func RunMigrations(db *DB) error {
+	return MigrateTo(db, LatestVersion())
+}
+
+// MigrateTo moves the schema up or down until it reaches the target version.
+// Each step runs in its own transaction together with its schema_migrations row,
+// so a failed step leaves the database at the previous version.
+func MigrateTo(db *DB, target int) error {
+	list, err := sortedMigrations()
+	if err != nil {
+		return err
+	}
+
+	if target < 0 || target > len(list) {
+		return fmt.Errorf("unknown schema version %d (latest is %d)", target, len(list))
+	}
+
+	current, err := CurrentVersion(db)
+	if err != nil {
+		return fmt.Errorf("reading schema version: %w", err)
+	}
+
+	if current > len(list) {
+		return fmt.Errorf("database schema version %d is newer than this binary supports (%d)", current, len(list))
+	}
+
+	// Apply pending migrations (up)
+	for v := current + 1; v <= target; v++ {
+		m := list[v-1]
+		log.Printf("Applying migration %04d_%s", m.Version, m.Name)
+		if err := applyStep(db, m.script(db.Dialect).Up, func(tx *sql.Tx) error {
+			_, err := tx.Exec(db.Rebind(`INSERT INTO schema_migrations (version, name) VALUES (?, ?)`), m.Version, m.Name)
+			return err
+		}); err != nil {
+			return fmt.Errorf("migration %04d_%s up: %w", m.Version, m.Name, err)
+		}
+	}
+
+	// Revert applied migrations (down)
+	for v := current; v > target; v-- {
+		m := list[v-1]
+		log.Printf("Reverting migration %04d_%s", m.Version, m.Name)
+		if err := applyStep(db, m.script(db.Dialect).Down, func(tx *sql.Tx) error {
+			_, err := tx.Exec(db.Rebind(`DELETE FROM schema_migrations WHERE version = ?`), m.Version)
+			return err
+		}); err != nil {
+			return fmt.Errorf("migration %04d_%s down: %w", m.Version, m.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// applyStep runs one migration script and its bookkeeping in a single transaction
+func applyStep(db *DB, script string, record func(tx *sql.Tx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if script != "" {
+		if _, err := tx.Exec(script); err != nil {
+			return err
+		}
+	}
+
+	if err := record(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,79 @@
+package database
+
+// migrations is the ordered list of schema changes.
+// Never edit a migration that has shipped - add a new one instead.
+// Every migration must provide SQL for both SQLite and PostgreSQL.
+var migrations = []Migration{
+	{
+		Version:  1,
+		Name:     "initial_schema",
+		SQLite:   Script{Up: sqliteInitialSchemaUp, Down: sqliteInitialSchemaDown},
+		Postgres: Script{Up: postgresInitialSchemaUp, Down: postgresInitialSchemaDown},
+	},
+	{
+		Version:  2,
+		Name:     "denormalized_counters",
+		SQLite:   Script{Up: sqliteCountersUp, Down: sqliteCountersDown},
+		Postgres: Script{Up: postgresCountersUp, Down: postgresCountersDown},
+	},
+	{
+		Version:  3,
+		Name:     "soft_delete",
+		SQLite:   Script{Up: sqliteSoftDeleteUp, Down: sqliteSoftDeleteDown},
+		Postgres: Script{Up: postgresSoftDeleteUp, Down: postgresSoftDeleteDown},
+	},
+	{
+		Version:  4,
+		Name:     "audit_events",
+		SQLite:   Script{Up: sqliteAuditEventsUp, Down: sqliteAuditEventsDown},
+		Postgres: Script{Up: postgresAuditEventsUp, Down: postgresAuditEventsDown},
+	},
+	{
+		Version:  5,
+		Name:     "post_revisions",
+		SQLite:   Script{Up: sqlitePostRevisionsUp, Down: sqlitePostRevisionsDown},
+		Postgres: Script{Up: postgresPostRevisionsUp, Down: postgresPostRevisionsDown},
+	},
+	{
+		Version:  6,
+		Name:     "global_pins",
+		SQLite:   Script{Up: sqliteGlobalPinsUp, Down: sqliteGlobalPinsDown},
+		Postgres: Script{Up: postgresGlobalPinsUp, Down: postgresGlobalPinsDown},
+	},
+	{
+		Version:  7,
+		Name:     "post_locks",
+		SQLite:   Script{Up: sqlitePostLocksUp, Down: sqlitePostLocksDown},
+		Postgres: Script{Up: postgresPostLocksUp, Down: postgresPostLocksDown},
+	},
+	{
+		Version:  8,
+		Name:     "reactions",
+		SQLite:   Script{Up: sqliteReactionsUp, Down: sqliteReactionsDown},
+		Postgres: Script{Up: postgresReactionsUp, Down: postgresReactionsDown},
+	},
+	{
+		Version:  9,
+		Name:     "user_karma",
+		SQLite:   Script{Up: sqliteUserKarmaUp, Down: sqliteUserKarmaDown},
+		Postgres: Script{Up: postgresUserKarmaUp, Down: postgresUserKarmaDown},
+	},
+	{
+		Version:  10,
+		Name:     "username_history",
+		SQLite:   Script{Up: sqliteUsernameHistoryUp, Down: sqliteUsernameHistoryDown},
+		Postgres: Script{Up: postgresUsernameHistoryUp, Down: postgresUsernameHistoryDown},
+	},
+	{
+		Version:  11,
+		Name:     "password_resets",
+		SQLite:   Script{Up: sqlitePasswordResetsUp, Down: sqlitePasswordResetsDown},
+		Postgres: Script{Up: postgresPasswordResetsUp, Down: postgresPasswordResetsDown},
+	},
+	{
+		Version:  12,
+		Name:     "email_verification",
+		SQLite:   Script{Up: sqliteEmailVerificationUp, Down: sqliteEmailVerificationDown},
+		Postgres: Script{Up: postgresEmailVerificationUp, Down: postgresEmailVerificationDown},
+	},
+}
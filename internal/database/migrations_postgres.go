@@ -0,0 +1,520 @@
+package database
+
+// PostgreSQL migration scripts, referenced from migrations.go.
+// They mirror the SQLite scripts: SERIAL instead of AUTOINCREMENT,
+// TIMESTAMPTZ instead of DATETIME and ON CONFLICT instead of OR IGNORE.
+
+// postgresInitialSchemaUp is the PostgreSQL version of the initial schema
+const postgresInitialSchemaUp = `
+	CREATE TABLE IF NOT EXISTS users (
+		id SERIAL PRIMARY KEY,
+		uuid TEXT UNIQUE NOT NULL,
+		username VARCHAR(50) UNIQUE NOT NULL,
+		email VARCHAR(100) UNIQUE NOT NULL,
+		password_hash VARCHAR(255) NOT NULL,
+		avatar_url VARCHAR(255),
+		is_admin BOOLEAN DEFAULT FALSE,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS sessions (
+		id SERIAL PRIMARY KEY,
+		token TEXT UNIQUE NOT NULL,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		expires_at TIMESTAMPTZ NOT NULL,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS categories (
+		id SERIAL PRIMARY KEY,
+		name VARCHAR(100) NOT NULL,
+		description TEXT,
+		slug VARCHAR(100) UNIQUE NOT NULL,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS posts (
+		id SERIAL PRIMARY KEY,
+		title VARCHAR(255) NOT NULL,
+		content TEXT NOT NULL,
+		user_id INTEGER NOT NULL REFERENCES users(id),
+		is_pinned BOOLEAN DEFAULT FALSE,
+		is_locked BOOLEAN DEFAULT FALSE,
+		view_count INTEGER DEFAULT 0,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS post_categories (
+		post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+		category_id INTEGER NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+		PRIMARY KEY (post_id, category_id)
+	);
+
+	CREATE TABLE IF NOT EXISTS comments (
+		id SERIAL PRIMARY KEY,
+		content TEXT NOT NULL,
+		user_id INTEGER NOT NULL REFERENCES users(id),
+		post_id INTEGER NOT NULL REFERENCES posts(id),
+		parent_id INTEGER REFERENCES comments(id),
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS post_likes (
+		id SERIAL PRIMARY KEY,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+		is_like BOOLEAN NOT NULL,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(user_id, post_id)
+	);
+
+	CREATE TABLE IF NOT EXISTS comment_likes (
+		id SERIAL PRIMARY KEY,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		comment_id INTEGER NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+		is_like BOOLEAN NOT NULL,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(user_id, comment_id)
+	);
+
+	-- Create indexes for better performance
+	CREATE INDEX IF NOT EXISTS idx_posts_user_id ON posts(user_id);
+	CREATE INDEX IF NOT EXISTS idx_posts_created_at ON posts(created_at);
+	CREATE INDEX IF NOT EXISTS idx_post_categories_post_id ON post_categories(post_id);
+	CREATE INDEX IF NOT EXISTS idx_post_categories_category_id ON post_categories(category_id);
+	CREATE INDEX IF NOT EXISTS idx_comments_post_id ON comments(post_id);
+	CREATE INDEX IF NOT EXISTS idx_comments_user_id ON comments(user_id);
+	CREATE INDEX IF NOT EXISTS idx_sessions_token ON sessions(token);
+	CREATE INDEX IF NOT EXISTS idx_sessions_expires_at ON sessions(expires_at);
+	CREATE INDEX IF NOT EXISTS idx_post_likes_post_id ON post_likes(post_id);
+	CREATE INDEX IF NOT EXISTS idx_post_likes_user_id ON post_likes(user_id);
+	CREATE INDEX IF NOT EXISTS idx_comment_likes_comment_id ON comment_likes(comment_id);
+	CREATE INDEX IF NOT EXISTS idx_comment_likes_user_id ON comment_likes(user_id);
+
+	-- Insert default categories (5 categories now)
+	INSERT INTO categories (id, name, description, slug) VALUES 
+		(1, 'General Discussion', 'General topics and discussions', 'general'),
+		(2, 'Tech Talk', 'Technology and programming discussions', 'tech'),
+		(3, 'Announcements', 'Important announcements', 'announcements'),
+		(4, 'Help & Support', 'Get help and support from the community', 'help-support'),
+		(5, 'Off-Topic', 'Casual discussions and off-topic conversations', 'off-topic')
+	ON CONFLICT DO NOTHING;
+
+	-- Create a default admin user (username: admin, password: admin123)
+	INSERT INTO users (id, uuid, username, email, password_hash, is_admin) VALUES 
+		(1, '550e8400-e29b-41d4-a716-446655440000', 'admin', 'admin@forum.local', 
+		 '$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy', TRUE)
+	ON CONFLICT DO NOTHING;
+
+	-- Explicit IDs don't advance SERIAL sequences, so move them past the seed rows
+	SELECT setval(pg_get_serial_sequence('categories', 'id'), (SELECT MAX(id) FROM categories));
+	SELECT setval(pg_get_serial_sequence('users', 'id'), (SELECT MAX(id) FROM users));
+	`
+
+// postgresInitialSchemaDown drops everything in reverse dependency order
+const postgresInitialSchemaDown = `
+	DROP TABLE IF EXISTS comment_likes;
+	DROP TABLE IF EXISTS post_likes;
+	DROP TABLE IF EXISTS comments;
+	DROP TABLE IF EXISTS post_categories;
+	DROP TABLE IF EXISTS posts;
+	DROP TABLE IF EXISTS categories;
+	DROP TABLE IF EXISTS sessions;
+	DROP TABLE IF EXISTS users;
+	`
+
+// postgresCountersUp adds denormalized vote/reply counters, fills them from the
+// source tables and installs triggers that keep them up to date
+const postgresCountersUp = `
+	ALTER TABLE posts
+		ADD COLUMN like_count INTEGER NOT NULL DEFAULT 0,
+		ADD COLUMN dislike_count INTEGER NOT NULL DEFAULT 0,
+		ADD COLUMN reply_count INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE comments
+		ADD COLUMN like_count INTEGER NOT NULL DEFAULT 0,
+		ADD COLUMN dislike_count INTEGER NOT NULL DEFAULT 0,
+		ADD COLUMN reply_count INTEGER NOT NULL DEFAULT 0;
+
+	UPDATE posts SET
+		like_count = (SELECT COUNT(*) FROM post_likes pl WHERE pl.post_id = posts.id AND pl.is_like = TRUE),
+		dislike_count = (SELECT COUNT(*) FROM post_likes pl WHERE pl.post_id = posts.id AND pl.is_like = FALSE),
+		reply_count = (SELECT COUNT(*) FROM comments c WHERE c.post_id = posts.id);
+
+	UPDATE comments SET
+		like_count = (SELECT COUNT(*) FROM comment_likes cl WHERE cl.comment_id = comments.id AND cl.is_like = TRUE),
+		dislike_count = (SELECT COUNT(*) FROM comment_likes cl WHERE cl.comment_id = comments.id AND cl.is_like = FALSE),
+		reply_count = (SELECT COUNT(*) FROM comments r WHERE r.parent_id = comments.id);
+
+	CREATE FUNCTION post_likes_count() RETURNS trigger AS $$
+	BEGIN
+		IF TG_OP IN ('UPDATE', 'DELETE') THEN
+			UPDATE posts SET
+				like_count = like_count - (CASE WHEN OLD.is_like THEN 1 ELSE 0 END),
+				dislike_count = dislike_count - (CASE WHEN OLD.is_like THEN 0 ELSE 1 END)
+			WHERE id = OLD.post_id;
+		END IF;
+		IF TG_OP IN ('INSERT', 'UPDATE') THEN
+			UPDATE posts SET
+				like_count = like_count + (CASE WHEN NEW.is_like THEN 1 ELSE 0 END),
+				dislike_count = dislike_count + (CASE WHEN NEW.is_like THEN 0 ELSE 1 END)
+			WHERE id = NEW.post_id;
+		END IF;
+		RETURN NULL;
+	END;
+	$$ LANGUAGE plpgsql;
+
+	CREATE TRIGGER post_likes_count AFTER INSERT OR DELETE OR UPDATE OF is_like, post_id ON post_likes
+		FOR EACH ROW EXECUTE FUNCTION post_likes_count();
+
+	CREATE FUNCTION comment_likes_count() RETURNS trigger AS $$
+	BEGIN
+		IF TG_OP IN ('UPDATE', 'DELETE') THEN
+			UPDATE comments SET
+				like_count = like_count - (CASE WHEN OLD.is_like THEN 1 ELSE 0 END),
+				dislike_count = dislike_count - (CASE WHEN OLD.is_like THEN 0 ELSE 1 END)
+			WHERE id = OLD.comment_id;
+		END IF;
+		IF TG_OP IN ('INSERT', 'UPDATE') THEN
+			UPDATE comments SET
+				like_count = like_count + (CASE WHEN NEW.is_like THEN 1 ELSE 0 END),
+				dislike_count = dislike_count + (CASE WHEN NEW.is_like THEN 0 ELSE 1 END)
+			WHERE id = NEW.comment_id;
+		END IF;
+		RETURN NULL;
+	END;
+	$$ LANGUAGE plpgsql;
+
+	CREATE TRIGGER comment_likes_count AFTER INSERT OR DELETE OR UPDATE OF is_like, comment_id ON comment_likes
+		FOR EACH ROW EXECUTE FUNCTION comment_likes_count();
+
+	-- Replies: every comment counts towards its post, and towards its parent comment
+	CREATE FUNCTION comments_reply_count() RETURNS trigger AS $$
+	BEGIN
+		IF TG_OP = 'INSERT' THEN
+			UPDATE posts SET reply_count = reply_count + 1 WHERE id = NEW.post_id;
+			UPDATE comments SET reply_count = reply_count + 1 WHERE id = NEW.parent_id;
+		ELSE
+			UPDATE posts SET reply_count = reply_count - 1 WHERE id = OLD.post_id;
+			UPDATE comments SET reply_count = reply_count - 1 WHERE id = OLD.parent_id;
+		END IF;
+		RETURN NULL;
+	END;
+	$$ LANGUAGE plpgsql;
+
+	CREATE TRIGGER comments_reply_count AFTER INSERT OR DELETE ON comments
+		FOR EACH ROW EXECUTE FUNCTION comments_reply_count();
+	`
+
+const postgresCountersDown = `
+	DROP TRIGGER IF EXISTS comments_reply_count ON comments;
+	DROP TRIGGER IF EXISTS comment_likes_count ON comment_likes;
+	DROP TRIGGER IF EXISTS post_likes_count ON post_likes;
+	DROP FUNCTION IF EXISTS comments_reply_count();
+	DROP FUNCTION IF EXISTS comment_likes_count();
+	DROP FUNCTION IF EXISTS post_likes_count();
+
+	ALTER TABLE comments DROP COLUMN reply_count, DROP COLUMN dislike_count, DROP COLUMN like_count;
+	ALTER TABLE posts DROP COLUMN reply_count, DROP COLUMN dislike_count, DROP COLUMN like_count;
+	`
+
+// postgresSoftDeleteUp adds soft-delete columns to posts and comments
+const postgresSoftDeleteUp = `
+	ALTER TABLE posts
+		ADD COLUMN deleted_at TIMESTAMPTZ,
+		ADD COLUMN deleted_by INTEGER REFERENCES users(id);
+	ALTER TABLE comments
+		ADD COLUMN deleted_at TIMESTAMPTZ,
+		ADD COLUMN deleted_by INTEGER REFERENCES users(id);
+	`
+
+const postgresSoftDeleteDown = `
+	ALTER TABLE comments DROP COLUMN deleted_by, DROP COLUMN deleted_at;
+	ALTER TABLE posts DROP COLUMN deleted_by, DROP COLUMN deleted_at;
+	`
+
+// postgresAuditEventsUp adds the audit log (see sqliteAuditEventsUp)
+const postgresAuditEventsUp = `
+	CREATE TABLE audit_events (
+		id SERIAL PRIMARY KEY,
+		actor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+		action VARCHAR(50) NOT NULL,
+		target_type VARCHAR(20) NOT NULL,
+		target_id INTEGER,
+		ip VARCHAR(45) NOT NULL DEFAULT '',
+		user_agent VARCHAR(255) NOT NULL DEFAULT '',
+		diff JSONB NOT NULL DEFAULT '{}',
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX idx_audit_events_actor ON audit_events(actor_id, id);
+	CREATE INDEX idx_audit_events_action ON audit_events(action, id);
+	CREATE INDEX idx_audit_events_target ON audit_events(target_type, target_id, id);
+	`
+
+const postgresAuditEventsDown = `
+	DROP TABLE IF EXISTS audit_events;
+	`
+
+// postgresPostRevisionsUp keeps every earlier version of an edited post
+// (see sqlitePostRevisionsUp)
+const postgresPostRevisionsUp = `
+	ALTER TABLE posts ADD COLUMN edited_at TIMESTAMPTZ;
+
+	CREATE TABLE post_revisions (
+		id SERIAL PRIMARY KEY,
+		post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+		title VARCHAR(255) NOT NULL,
+		content TEXT NOT NULL,
+		categories TEXT NOT NULL DEFAULT '',
+		edited_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX idx_post_revisions_post ON post_revisions(post_id, id);
+	`
+
+const postgresPostRevisionsDown = `
+	DROP TABLE IF EXISTS post_revisions;
+	ALTER TABLE posts DROP COLUMN edited_at;
+	`
+
+// postgresGlobalPinsUp: see sqliteGlobalPinsUp
+const postgresGlobalPinsUp = `
+	ALTER TABLE posts ADD COLUMN pinned_globally BOOLEAN NOT NULL DEFAULT FALSE;
+	`
+
+const postgresGlobalPinsDown = `
+	ALTER TABLE posts DROP COLUMN pinned_globally;
+	`
+
+// postgresPostLocksUp: see sqlitePostLocksUp
+const postgresPostLocksUp = `
+	ALTER TABLE posts
+		ADD COLUMN locked_at TIMESTAMPTZ,
+		ADD COLUMN locked_by INTEGER REFERENCES users(id),
+		ADD COLUMN lock_reason TEXT NOT NULL DEFAULT '';
+	`
+
+const postgresPostLocksDown = `
+	ALTER TABLE posts DROP COLUMN lock_reason, DROP COLUMN locked_by, DROP COLUMN locked_at;
+	`
+
+// postgresReactionsUp: see sqliteReactionsUp
+const postgresReactionsUp = `
+	CREATE TABLE reactions (
+		id SERIAL PRIMARY KEY,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		target_type VARCHAR(20) NOT NULL CHECK (target_type IN ('post', 'comment')),
+		target_id INTEGER NOT NULL,
+		reaction VARCHAR(20) NOT NULL,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(user_id, target_type, target_id, reaction)
+	);
+
+	CREATE INDEX idx_reactions_target ON reactions(target_type, target_id, reaction);
+	CREATE UNIQUE INDEX idx_reactions_vote ON reactions(user_id, target_type, target_id)
+		WHERE reaction IN ('like', 'dislike');
+
+	INSERT INTO reactions (user_id, target_type, target_id, reaction, created_at)
+		SELECT user_id, 'post', post_id, CASE WHEN is_like THEN 'like' ELSE 'dislike' END, created_at
+		FROM post_likes ORDER BY id;
+	INSERT INTO reactions (user_id, target_type, target_id, reaction, created_at)
+		SELECT user_id, 'comment', comment_id, CASE WHEN is_like THEN 'like' ELSE 'dislike' END, created_at
+		FROM comment_likes ORDER BY id;
+
+	DROP TABLE post_likes;
+	DROP TABLE comment_likes;
+	DROP FUNCTION post_likes_count();
+	DROP FUNCTION comment_likes_count();
+
+	CREATE FUNCTION reactions_count() RETURNS trigger AS $$
+	BEGIN
+		IF TG_OP IN ('UPDATE', 'DELETE') AND OLD.reaction IN ('like', 'dislike') THEN
+			IF OLD.target_type = 'post' THEN
+				UPDATE posts SET
+					like_count = like_count - (CASE WHEN OLD.reaction = 'like' THEN 1 ELSE 0 END),
+					dislike_count = dislike_count - (CASE WHEN OLD.reaction = 'like' THEN 0 ELSE 1 END)
+				WHERE id = OLD.target_id;
+			ELSE
+				UPDATE comments SET
+					like_count = like_count - (CASE WHEN OLD.reaction = 'like' THEN 1 ELSE 0 END),
+					dislike_count = dislike_count - (CASE WHEN OLD.reaction = 'like' THEN 0 ELSE 1 END)
+				WHERE id = OLD.target_id;
+			END IF;
+		END IF;
+		IF TG_OP IN ('INSERT', 'UPDATE') AND NEW.reaction IN ('like', 'dislike') THEN
+			IF NEW.target_type = 'post' THEN
+				UPDATE posts SET
+					like_count = like_count + (CASE WHEN NEW.reaction = 'like' THEN 1 ELSE 0 END),
+					dislike_count = dislike_count + (CASE WHEN NEW.reaction = 'like' THEN 0 ELSE 1 END)
+				WHERE id = NEW.target_id;
+			ELSE
+				UPDATE comments SET
+					like_count = like_count + (CASE WHEN NEW.reaction = 'like' THEN 1 ELSE 0 END),
+					dislike_count = dislike_count + (CASE WHEN NEW.reaction = 'like' THEN 0 ELSE 1 END)
+				WHERE id = NEW.target_id;
+			END IF;
+		END IF;
+		RETURN NULL;
+	END;
+	$$ LANGUAGE plpgsql;
+
+	CREATE TRIGGER reactions_count AFTER INSERT OR DELETE OR UPDATE OF reaction, target_type, target_id ON reactions
+		FOR EACH ROW EXECUTE FUNCTION reactions_count();
+
+	-- target_id can't have a foreign key (see sqliteReactionsUp)
+	CREATE FUNCTION delete_target_reactions() RETURNS trigger AS $$
+	BEGIN
+		DELETE FROM reactions WHERE target_type = TG_ARGV[0] AND target_id = OLD.id;
+		RETURN NULL;
+	END;
+	$$ LANGUAGE plpgsql;
+
+	CREATE TRIGGER posts_delete_reactions AFTER DELETE ON posts
+		FOR EACH ROW EXECUTE FUNCTION delete_target_reactions('post');
+	CREATE TRIGGER comments_delete_reactions AFTER DELETE ON comments
+		FOR EACH ROW EXECUTE FUNCTION delete_target_reactions('comment');
+	`
+
+// postgresReactionsDown: see sqliteReactionsDown
+const postgresReactionsDown = `
+	DROP TRIGGER IF EXISTS comments_delete_reactions ON comments;
+	DROP TRIGGER IF EXISTS posts_delete_reactions ON posts;
+	DROP FUNCTION IF EXISTS delete_target_reactions();
+
+	CREATE TABLE post_likes (
+		id SERIAL PRIMARY KEY,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+		is_like BOOLEAN NOT NULL,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(user_id, post_id)
+	);
+
+	CREATE TABLE comment_likes (
+		id SERIAL PRIMARY KEY,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		comment_id INTEGER NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+		is_like BOOLEAN NOT NULL,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(user_id, comment_id)
+	);
+
+	CREATE INDEX idx_post_likes_post_id ON post_likes(post_id);
+	CREATE INDEX idx_post_likes_user_id ON post_likes(user_id);
+	CREATE INDEX idx_comment_likes_comment_id ON comment_likes(comment_id);
+	CREATE INDEX idx_comment_likes_user_id ON comment_likes(user_id);
+
+	-- Copied before the triggers exist, so the counts stay as they are
+	INSERT INTO post_likes (user_id, post_id, is_like, created_at)
+		SELECT user_id, target_id, reaction = 'like', created_at FROM reactions
+		WHERE target_type = 'post' AND reaction IN ('like', 'dislike') ORDER BY id;
+	INSERT INTO comment_likes (user_id, comment_id, is_like, created_at)
+		SELECT user_id, target_id, reaction = 'like', created_at FROM reactions
+		WHERE target_type = 'comment' AND reaction IN ('like', 'dislike') ORDER BY id;
+
+	DROP TABLE reactions;
+	DROP FUNCTION IF EXISTS reactions_count();
+
+	CREATE FUNCTION post_likes_count() RETURNS trigger AS $$
+	BEGIN
+		IF TG_OP IN ('UPDATE', 'DELETE') THEN
+			UPDATE posts SET
+				like_count = like_count - (CASE WHEN OLD.is_like THEN 1 ELSE 0 END),
+				dislike_count = dislike_count - (CASE WHEN OLD.is_like THEN 0 ELSE 1 END)
+			WHERE id = OLD.post_id;
+		END IF;
+		IF TG_OP IN ('INSERT', 'UPDATE') THEN
+			UPDATE posts SET
+				like_count = like_count + (CASE WHEN NEW.is_like THEN 1 ELSE 0 END),
+				dislike_count = dislike_count + (CASE WHEN NEW.is_like THEN 0 ELSE 1 END)
+			WHERE id = NEW.post_id;
+		END IF;
+		RETURN NULL;
+	END;
+	$$ LANGUAGE plpgsql;
+
+	CREATE TRIGGER post_likes_count AFTER INSERT OR DELETE OR UPDATE OF is_like, post_id ON post_likes
+		FOR EACH ROW EXECUTE FUNCTION post_likes_count();
+
+	CREATE FUNCTION comment_likes_count() RETURNS trigger AS $$
+	BEGIN
+		IF TG_OP IN ('UPDATE', 'DELETE') THEN
+			UPDATE comments SET
+				like_count = like_count - (CASE WHEN OLD.is_like THEN 1 ELSE 0 END),
+				dislike_count = dislike_count - (CASE WHEN OLD.is_like THEN 0 ELSE 1 END)
+			WHERE id = OLD.comment_id;
+		END IF;
+		IF TG_OP IN ('INSERT', 'UPDATE') THEN
+			UPDATE comments SET
+				like_count = like_count + (CASE WHEN NEW.is_like THEN 1 ELSE 0 END),
+				dislike_count = dislike_count + (CASE WHEN NEW.is_like THEN 0 ELSE 1 END)
+			WHERE id = NEW.comment_id;
+		END IF;
+		RETURN NULL;
+	END;
+	$$ LANGUAGE plpgsql;
+
+	CREATE TRIGGER comment_likes_count AFTER INSERT OR DELETE OR UPDATE OF is_like, comment_id ON comment_likes
+		FOR EACH ROW EXECUTE FUNCTION comment_likes_count();
+	`
+
+// postgresUserKarmaUp: see sqliteUserKarmaUp
+const postgresUserKarmaUp = `
+	ALTER TABLE users ADD COLUMN karma INTEGER NOT NULL DEFAULT 0;
+	`
+
+const postgresUserKarmaDown = `
+	ALTER TABLE users DROP COLUMN karma;
+	`
+
+// postgresUsernameHistoryUp: see sqliteUsernameHistoryUp
+const postgresUsernameHistoryUp = `
+	CREATE TABLE username_history (
+		id SERIAL PRIMARY KEY,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		username VARCHAR(50) NOT NULL,
+		changed_at TIMESTAMPTZ NOT NULL
+	);
+
+	CREATE INDEX idx_username_history_user ON username_history(user_id, changed_at);
+	CREATE INDEX idx_username_history_username ON username_history(username, changed_at);
+	`
+
+const postgresUsernameHistoryDown = `
+	DROP TABLE IF EXISTS username_history;
+	`
+
+// postgresPasswordResetsUp: see sqlitePasswordResetsUp
+const postgresPasswordResetsUp = `
+	CREATE TABLE password_resets (
+		id SERIAL PRIMARY KEY,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		token_hash VARCHAR(64) UNIQUE NOT NULL,
+		expires_at TIMESTAMPTZ NOT NULL,
+		used_at TIMESTAMPTZ,
+		created_at TIMESTAMPTZ NOT NULL
+	);
+
+	CREATE INDEX idx_password_resets_user ON password_resets(user_id, created_at);
+	`
+
+const postgresPasswordResetsDown = `
+	DROP TABLE IF EXISTS password_resets;
+	`
+
+// postgresEmailVerificationUp: see sqliteEmailVerificationUp
+const postgresEmailVerificationUp = `
+	ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMPTZ;
+	ALTER TABLE users ADD COLUMN verification_sent_at TIMESTAMPTZ;
+
+	UPDATE users SET email_verified_at = created_at;
+	`
+
+const postgresEmailVerificationDown = `
+	ALTER TABLE users DROP COLUMN verification_sent_at;
+	ALTER TABLE users DROP COLUMN email_verified_at;
+	`
@@ -0,0 +1,561 @@
+package database
+
+// SQLite migration scripts, referenced from migrations.go
+
+// sqliteInitialSchemaUp is the schema that used to be created on every boot.
+// It keeps IF NOT EXISTS / OR IGNORE so existing forum.db files are adopted as version 1.
+const sqliteInitialSchemaUp = `
+	CREATE TABLE IF NOT EXISTS users (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		uuid TEXT UNIQUE NOT NULL,
+		username VARCHAR(50) UNIQUE NOT NULL,
+		email VARCHAR(100) UNIQUE NOT NULL,
+		password_hash VARCHAR(255) NOT NULL,
+		avatar_url VARCHAR(255),
+		is_admin BOOLEAN DEFAULT FALSE,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS sessions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		token TEXT UNIQUE NOT NULL,
+		user_id INTEGER NOT NULL,
+		expires_at DATETIME NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS categories (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name VARCHAR(100) NOT NULL,
+		description TEXT,
+		slug VARCHAR(100) UNIQUE NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS posts (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		title VARCHAR(255) NOT NULL,
+		content TEXT NOT NULL,
+		user_id INTEGER NOT NULL,
+		is_pinned BOOLEAN DEFAULT FALSE,
+		is_locked BOOLEAN DEFAULT FALSE,
+		view_count INTEGER DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id)
+	);
+
+	CREATE TABLE IF NOT EXISTS post_categories (
+		post_id INTEGER NOT NULL,
+		category_id INTEGER NOT NULL,
+		PRIMARY KEY (post_id, category_id),
+		FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+		FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS comments (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		content TEXT NOT NULL,
+		user_id INTEGER NOT NULL,
+		post_id INTEGER NOT NULL,
+		parent_id INTEGER,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id),
+		FOREIGN KEY (post_id) REFERENCES posts(id),
+		FOREIGN KEY (parent_id) REFERENCES comments(id)
+	);
+
+	CREATE TABLE IF NOT EXISTS post_likes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		post_id INTEGER NOT NULL,
+		is_like BOOLEAN NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+		FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+		UNIQUE(user_id, post_id)
+	);
+
+	CREATE TABLE IF NOT EXISTS comment_likes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		comment_id INTEGER NOT NULL,
+		is_like BOOLEAN NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+		FOREIGN KEY (comment_id) REFERENCES comments(id) ON DELETE CASCADE,
+		UNIQUE(user_id, comment_id)
+	);
+
+	-- Create indexes for better performance
+	CREATE INDEX IF NOT EXISTS idx_posts_user_id ON posts(user_id);
+	CREATE INDEX IF NOT EXISTS idx_posts_created_at ON posts(created_at);
+	CREATE INDEX IF NOT EXISTS idx_post_categories_post_id ON post_categories(post_id);
+	CREATE INDEX IF NOT EXISTS idx_post_categories_category_id ON post_categories(category_id);
+	CREATE INDEX IF NOT EXISTS idx_comments_post_id ON comments(post_id);
+	CREATE INDEX IF NOT EXISTS idx_comments_user_id ON comments(user_id);
+	CREATE INDEX IF NOT EXISTS idx_sessions_token ON sessions(token);
+	CREATE INDEX IF NOT EXISTS idx_sessions_expires_at ON sessions(expires_at);
+	CREATE INDEX IF NOT EXISTS idx_post_likes_post_id ON post_likes(post_id);
+	CREATE INDEX IF NOT EXISTS idx_post_likes_user_id ON post_likes(user_id);
+	CREATE INDEX IF NOT EXISTS idx_comment_likes_comment_id ON comment_likes(comment_id);
+	CREATE INDEX IF NOT EXISTS idx_comment_likes_user_id ON comment_likes(user_id);
+
+	-- Insert default categories (5 categories now)
+	INSERT OR IGNORE INTO categories (id, name, description, slug) VALUES 
+		(1, 'General Discussion', 'General topics and discussions', 'general'),
+		(2, 'Tech Talk', 'Technology and programming discussions', 'tech'),
+		(3, 'Announcements', 'Important announcements', 'announcements'),
+		(4, 'Help & Support', 'Get help and support from the community', 'help-support'),
+		(5, 'Off-Topic', 'Casual discussions and off-topic conversations', 'off-topic');
+
+	-- Create a default admin user (username: admin, password: admin123)
+	INSERT OR IGNORE INTO users (id, uuid, username, email, password_hash, is_admin) VALUES 
+		(1, '550e8400-e29b-41d4-a716-446655440000', 'admin', 'admin@forum.local', 
+		 '$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy', TRUE);
+	`
+
+// sqliteInitialSchemaDown drops everything in reverse dependency order
+const sqliteInitialSchemaDown = `
+	DROP TABLE IF EXISTS comment_likes;
+	DROP TABLE IF EXISTS post_likes;
+	DROP TABLE IF EXISTS comments;
+	DROP TABLE IF EXISTS post_categories;
+	DROP TABLE IF EXISTS posts;
+	DROP TABLE IF EXISTS categories;
+	DROP TABLE IF EXISTS sessions;
+	DROP TABLE IF EXISTS users;
+	`
+
+// sqliteCountersUp adds denormalized vote/reply counters, fills them from the
+// source tables and installs triggers that keep them up to date
+const sqliteCountersUp = `
+	ALTER TABLE posts ADD COLUMN like_count INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE posts ADD COLUMN dislike_count INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE posts ADD COLUMN reply_count INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE comments ADD COLUMN like_count INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE comments ADD COLUMN dislike_count INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE comments ADD COLUMN reply_count INTEGER NOT NULL DEFAULT 0;
+
+	UPDATE posts SET
+		like_count = (SELECT COUNT(*) FROM post_likes pl WHERE pl.post_id = posts.id AND pl.is_like = TRUE),
+		dislike_count = (SELECT COUNT(*) FROM post_likes pl WHERE pl.post_id = posts.id AND pl.is_like = FALSE),
+		reply_count = (SELECT COUNT(*) FROM comments c WHERE c.post_id = posts.id);
+
+	UPDATE comments SET
+		like_count = (SELECT COUNT(*) FROM comment_likes cl WHERE cl.comment_id = comments.id AND cl.is_like = TRUE),
+		dislike_count = (SELECT COUNT(*) FROM comment_likes cl WHERE cl.comment_id = comments.id AND cl.is_like = FALSE),
+		reply_count = (SELECT COUNT(*) FROM comments r WHERE r.parent_id = comments.id);
+
+	-- Post votes
+	CREATE TRIGGER post_likes_count_insert AFTER INSERT ON post_likes BEGIN
+		UPDATE posts SET
+			like_count = like_count + (CASE WHEN new.is_like THEN 1 ELSE 0 END),
+			dislike_count = dislike_count + (CASE WHEN new.is_like THEN 0 ELSE 1 END)
+		WHERE id = new.post_id;
+	END;
+
+	CREATE TRIGGER post_likes_count_delete AFTER DELETE ON post_likes BEGIN
+		UPDATE posts SET
+			like_count = like_count - (CASE WHEN old.is_like THEN 1 ELSE 0 END),
+			dislike_count = dislike_count - (CASE WHEN old.is_like THEN 0 ELSE 1 END)
+		WHERE id = old.post_id;
+	END;
+
+	CREATE TRIGGER post_likes_count_update AFTER UPDATE OF is_like, post_id ON post_likes BEGIN
+		UPDATE posts SET
+			like_count = like_count - (CASE WHEN old.is_like THEN 1 ELSE 0 END),
+			dislike_count = dislike_count - (CASE WHEN old.is_like THEN 0 ELSE 1 END)
+		WHERE id = old.post_id;
+		UPDATE posts SET
+			like_count = like_count + (CASE WHEN new.is_like THEN 1 ELSE 0 END),
+			dislike_count = dislike_count + (CASE WHEN new.is_like THEN 0 ELSE 1 END)
+		WHERE id = new.post_id;
+	END;
+
+	-- Comment votes
+	CREATE TRIGGER comment_likes_count_insert AFTER INSERT ON comment_likes BEGIN
+		UPDATE comments SET
+			like_count = like_count + (CASE WHEN new.is_like THEN 1 ELSE 0 END),
+			dislike_count = dislike_count + (CASE WHEN new.is_like THEN 0 ELSE 1 END)
+		WHERE id = new.comment_id;
+	END;
+
+	CREATE TRIGGER comment_likes_count_delete AFTER DELETE ON comment_likes BEGIN
+		UPDATE comments SET
+			like_count = like_count - (CASE WHEN old.is_like THEN 1 ELSE 0 END),
+			dislike_count = dislike_count - (CASE WHEN old.is_like THEN 0 ELSE 1 END)
+		WHERE id = old.comment_id;
+	END;
+
+	CREATE TRIGGER comment_likes_count_update AFTER UPDATE OF is_like, comment_id ON comment_likes BEGIN
+		UPDATE comments SET
+			like_count = like_count - (CASE WHEN old.is_like THEN 1 ELSE 0 END),
+			dislike_count = dislike_count - (CASE WHEN old.is_like THEN 0 ELSE 1 END)
+		WHERE id = old.comment_id;
+		UPDATE comments SET
+			like_count = like_count + (CASE WHEN new.is_like THEN 1 ELSE 0 END),
+			dislike_count = dislike_count + (CASE WHEN new.is_like THEN 0 ELSE 1 END)
+		WHERE id = new.comment_id;
+	END;
+
+	-- Replies: every comment counts towards its post, and towards its parent comment
+	CREATE TRIGGER comments_reply_count_insert AFTER INSERT ON comments BEGIN
+		UPDATE posts SET reply_count = reply_count + 1 WHERE id = new.post_id;
+		UPDATE comments SET reply_count = reply_count + 1 WHERE id = new.parent_id;
+	END;
+
+	CREATE TRIGGER comments_reply_count_delete AFTER DELETE ON comments BEGIN
+		UPDATE posts SET reply_count = reply_count - 1 WHERE id = old.post_id;
+		UPDATE comments SET reply_count = reply_count - 1 WHERE id = old.parent_id;
+	END;
+	`
+
+const sqliteCountersDown = `
+	DROP TRIGGER IF EXISTS comments_reply_count_delete;
+	DROP TRIGGER IF EXISTS comments_reply_count_insert;
+	DROP TRIGGER IF EXISTS comment_likes_count_update;
+	DROP TRIGGER IF EXISTS comment_likes_count_delete;
+	DROP TRIGGER IF EXISTS comment_likes_count_insert;
+	DROP TRIGGER IF EXISTS post_likes_count_update;
+	DROP TRIGGER IF EXISTS post_likes_count_delete;
+	DROP TRIGGER IF EXISTS post_likes_count_insert;
+
+	ALTER TABLE comments DROP COLUMN reply_count;
+	ALTER TABLE comments DROP COLUMN dislike_count;
+	ALTER TABLE comments DROP COLUMN like_count;
+	ALTER TABLE posts DROP COLUMN reply_count;
+	ALTER TABLE posts DROP COLUMN dislike_count;
+	ALTER TABLE posts DROP COLUMN like_count;
+	`
+
+// sqliteSoftDeleteUp adds soft-delete columns: deleted rows stay in the table
+// so threads keep their shape and moderators can restore them
+const sqliteSoftDeleteUp = `
+	ALTER TABLE posts ADD COLUMN deleted_at DATETIME;
+	ALTER TABLE posts ADD COLUMN deleted_by INTEGER REFERENCES users(id);
+	ALTER TABLE comments ADD COLUMN deleted_at DATETIME;
+	ALTER TABLE comments ADD COLUMN deleted_by INTEGER REFERENCES users(id);
+	`
+
+const sqliteSoftDeleteDown = `
+	ALTER TABLE comments DROP COLUMN deleted_by;
+	ALTER TABLE comments DROP COLUMN deleted_at;
+	ALTER TABLE posts DROP COLUMN deleted_by;
+	ALTER TABLE posts DROP COLUMN deleted_at;
+	`
+
+// sqliteAuditEventsUp adds the audit log. actor_id is NULL for anonymous
+// actions; diff holds a JSON object of {"field": [old, new]} pairs.
+const sqliteAuditEventsUp = `
+	CREATE TABLE audit_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		actor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+		action VARCHAR(50) NOT NULL,
+		target_type VARCHAR(20) NOT NULL,
+		target_id INTEGER,
+		ip VARCHAR(45) NOT NULL DEFAULT '',
+		user_agent VARCHAR(255) NOT NULL DEFAULT '',
+		diff TEXT NOT NULL DEFAULT '{}',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX idx_audit_events_actor ON audit_events(actor_id, id);
+	CREATE INDEX idx_audit_events_action ON audit_events(action, id);
+	CREATE INDEX idx_audit_events_target ON audit_events(target_type, target_id, id);
+	`
+
+const sqliteAuditEventsDown = `
+	DROP TABLE IF EXISTS audit_events;
+	`
+
+// sqlitePostRevisionsUp keeps every earlier version of an edited post.
+// A revision row is written by the edit that replaced it: edited_by and
+// created_at say who made that edit and when.
+const sqlitePostRevisionsUp = `
+	ALTER TABLE posts ADD COLUMN edited_at DATETIME;
+
+	CREATE TABLE post_revisions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+		title VARCHAR(255) NOT NULL,
+		content TEXT NOT NULL,
+		categories TEXT NOT NULL DEFAULT '',
+		edited_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX idx_post_revisions_post ON post_revisions(post_id, id);
+	`
+
+const sqlitePostRevisionsDown = `
+	DROP TABLE IF EXISTS post_revisions;
+	ALTER TABLE posts DROP COLUMN edited_at;
+	`
+
+// sqliteGlobalPinsUp lets admins pin a post to the home page as well as to
+// its categories. pinned_globally implies is_pinned.
+const sqliteGlobalPinsUp = `
+	ALTER TABLE posts ADD COLUMN pinned_globally BOOLEAN NOT NULL DEFAULT FALSE;
+	`
+
+const sqliteGlobalPinsDown = `
+	ALTER TABLE posts DROP COLUMN pinned_globally;
+	`
+
+// sqlitePostLocksUp records who locked a thread (posts.is_locked), when and why
+const sqlitePostLocksUp = `
+	ALTER TABLE posts ADD COLUMN locked_at DATETIME;
+	ALTER TABLE posts ADD COLUMN locked_by INTEGER REFERENCES users(id);
+	ALTER TABLE posts ADD COLUMN lock_reason TEXT NOT NULL DEFAULT '';
+	`
+
+const sqlitePostLocksDown = `
+	ALTER TABLE posts DROP COLUMN lock_reason;
+	ALTER TABLE posts DROP COLUMN locked_by;
+	ALTER TABLE posts DROP COLUMN locked_at;
+	`
+
+// sqliteReactionsUp replaces post_likes and comment_likes with one reactions
+// table for posts and comments. Likes and dislikes become the reactions
+// "like" and "dislike"; a partial unique index keeps them one vote per user
+// and target, and the triggers of migration 0002 move over to count them.
+const sqliteReactionsUp = `
+	CREATE TABLE reactions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		target_type VARCHAR(20) NOT NULL CHECK (target_type IN ('post', 'comment')),
+		target_id INTEGER NOT NULL,
+		reaction VARCHAR(20) NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(user_id, target_type, target_id, reaction)
+	);
+
+	CREATE INDEX idx_reactions_target ON reactions(target_type, target_id, reaction);
+	CREATE UNIQUE INDEX idx_reactions_vote ON reactions(user_id, target_type, target_id)
+		WHERE reaction IN ('like', 'dislike');
+
+	INSERT INTO reactions (user_id, target_type, target_id, reaction, created_at)
+		SELECT user_id, 'post', post_id, CASE WHEN is_like THEN 'like' ELSE 'dislike' END, created_at
+		FROM post_likes ORDER BY id;
+	INSERT INTO reactions (user_id, target_type, target_id, reaction, created_at)
+		SELECT user_id, 'comment', comment_id, CASE WHEN is_like THEN 'like' ELSE 'dislike' END, created_at
+		FROM comment_likes ORDER BY id;
+
+	-- Dropping the tables drops their counter triggers too (without firing them)
+	DROP TABLE post_likes;
+	DROP TABLE comment_likes;
+
+	CREATE TRIGGER reactions_count_insert AFTER INSERT ON reactions
+	WHEN new.reaction IN ('like', 'dislike') BEGIN
+		UPDATE posts SET
+			like_count = like_count + (CASE WHEN new.reaction = 'like' THEN 1 ELSE 0 END),
+			dislike_count = dislike_count + (CASE WHEN new.reaction = 'like' THEN 0 ELSE 1 END)
+		WHERE new.target_type = 'post' AND id = new.target_id;
+		UPDATE comments SET
+			like_count = like_count + (CASE WHEN new.reaction = 'like' THEN 1 ELSE 0 END),
+			dislike_count = dislike_count + (CASE WHEN new.reaction = 'like' THEN 0 ELSE 1 END)
+		WHERE new.target_type = 'comment' AND id = new.target_id;
+	END;
+
+	CREATE TRIGGER reactions_count_delete AFTER DELETE ON reactions
+	WHEN old.reaction IN ('like', 'dislike') BEGIN
+		UPDATE posts SET
+			like_count = like_count - (CASE WHEN old.reaction = 'like' THEN 1 ELSE 0 END),
+			dislike_count = dislike_count - (CASE WHEN old.reaction = 'like' THEN 0 ELSE 1 END)
+		WHERE old.target_type = 'post' AND id = old.target_id;
+		UPDATE comments SET
+			like_count = like_count - (CASE WHEN old.reaction = 'like' THEN 1 ELSE 0 END),
+			dislike_count = dislike_count - (CASE WHEN old.reaction = 'like' THEN 0 ELSE 1 END)
+		WHERE old.target_type = 'comment' AND id = old.target_id;
+	END;
+
+	-- Votes only ever switch between like and dislike
+	CREATE TRIGGER reactions_count_update AFTER UPDATE OF reaction ON reactions
+	WHEN old.reaction IN ('like', 'dislike') AND new.reaction IN ('like', 'dislike') BEGIN
+		UPDATE posts SET
+			like_count = like_count + (CASE WHEN new.reaction = 'like' THEN 1 ELSE 0 END)
+				- (CASE WHEN old.reaction = 'like' THEN 1 ELSE 0 END),
+			dislike_count = dislike_count + (CASE WHEN new.reaction = 'like' THEN 0 ELSE 1 END)
+				- (CASE WHEN old.reaction = 'like' THEN 0 ELSE 1 END)
+		WHERE new.target_type = 'post' AND id = new.target_id;
+		UPDATE comments SET
+			like_count = like_count + (CASE WHEN new.reaction = 'like' THEN 1 ELSE 0 END)
+				- (CASE WHEN old.reaction = 'like' THEN 1 ELSE 0 END),
+			dislike_count = dislike_count + (CASE WHEN new.reaction = 'like' THEN 0 ELSE 1 END)
+				- (CASE WHEN old.reaction = 'like' THEN 0 ELSE 1 END)
+		WHERE new.target_type = 'comment' AND id = new.target_id;
+	END;
+
+	-- target_id can't have a foreign key, so deleting a post or comment
+	-- removes its reactions here (post_likes/comment_likes cascaded)
+	CREATE TRIGGER posts_delete_reactions AFTER DELETE ON posts BEGIN
+		DELETE FROM reactions WHERE target_type = 'post' AND target_id = old.id;
+	END;
+
+	CREATE TRIGGER comments_delete_reactions AFTER DELETE ON comments BEGIN
+		DELETE FROM reactions WHERE target_type = 'comment' AND target_id = old.id;
+	END;
+	`
+
+// sqliteReactionsDown restores post_likes and comment_likes with the votes
+// and triggers they had; other reactions are dropped
+const sqliteReactionsDown = `
+	DROP TRIGGER IF EXISTS comments_delete_reactions;
+	DROP TRIGGER IF EXISTS posts_delete_reactions;
+
+	CREATE TABLE post_likes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		post_id INTEGER NOT NULL,
+		is_like BOOLEAN NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+		FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+		UNIQUE(user_id, post_id)
+	);
+
+	CREATE TABLE comment_likes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		comment_id INTEGER NOT NULL,
+		is_like BOOLEAN NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+		FOREIGN KEY (comment_id) REFERENCES comments(id) ON DELETE CASCADE,
+		UNIQUE(user_id, comment_id)
+	);
+
+	CREATE INDEX idx_post_likes_post_id ON post_likes(post_id);
+	CREATE INDEX idx_post_likes_user_id ON post_likes(user_id);
+	CREATE INDEX idx_comment_likes_comment_id ON comment_likes(comment_id);
+	CREATE INDEX idx_comment_likes_user_id ON comment_likes(user_id);
+
+	-- Copied before the triggers exist, so the counts stay as they are
+	INSERT INTO post_likes (user_id, post_id, is_like, created_at)
+		SELECT user_id, target_id, reaction = 'like', created_at FROM reactions
+		WHERE target_type = 'post' AND reaction IN ('like', 'dislike') ORDER BY id;
+	INSERT INTO comment_likes (user_id, comment_id, is_like, created_at)
+		SELECT user_id, target_id, reaction = 'like', created_at FROM reactions
+		WHERE target_type = 'comment' AND reaction IN ('like', 'dislike') ORDER BY id;
+
+	DROP TABLE reactions;
+
+	CREATE TRIGGER post_likes_count_insert AFTER INSERT ON post_likes BEGIN
+		UPDATE posts SET
+			like_count = like_count + (CASE WHEN new.is_like THEN 1 ELSE 0 END),
+			dislike_count = dislike_count + (CASE WHEN new.is_like THEN 0 ELSE 1 END)
+		WHERE id = new.post_id;
+	END;
+
+	CREATE TRIGGER post_likes_count_delete AFTER DELETE ON post_likes BEGIN
+		UPDATE posts SET
+			like_count = like_count - (CASE WHEN old.is_like THEN 1 ELSE 0 END),
+			dislike_count = dislike_count - (CASE WHEN old.is_like THEN 0 ELSE 1 END)
+		WHERE id = old.post_id;
+	END;
+
+	CREATE TRIGGER post_likes_count_update AFTER UPDATE OF is_like, post_id ON post_likes BEGIN
+		UPDATE posts SET
+			like_count = like_count - (CASE WHEN old.is_like THEN 1 ELSE 0 END),
+			dislike_count = dislike_count - (CASE WHEN old.is_like THEN 0 ELSE 1 END)
+		WHERE id = old.post_id;
+		UPDATE posts SET
+			like_count = like_count + (CASE WHEN new.is_like THEN 1 ELSE 0 END),
+			dislike_count = dislike_count + (CASE WHEN new.is_like THEN 0 ELSE 1 END)
+		WHERE id = new.post_id;
+	END;
+
+	CREATE TRIGGER comment_likes_count_insert AFTER INSERT ON comment_likes BEGIN
+		UPDATE comments SET
+			like_count = like_count + (CASE WHEN new.is_like THEN 1 ELSE 0 END),
+			dislike_count = dislike_count + (CASE WHEN new.is_like THEN 0 ELSE 1 END)
+		WHERE id = new.comment_id;
+	END;
+
+	CREATE TRIGGER comment_likes_count_delete AFTER DELETE ON comment_likes BEGIN
+		UPDATE comments SET
+			like_count = like_count - (CASE WHEN old.is_like THEN 1 ELSE 0 END),
+			dislike_count = dislike_count - (CASE WHEN old.is_like THEN 0 ELSE 1 END)
+		WHERE id = old.comment_id;
+	END;
+
+	CREATE TRIGGER comment_likes_count_update AFTER UPDATE OF is_like, comment_id ON comment_likes BEGIN
+		UPDATE comments SET
+			like_count = like_count - (CASE WHEN old.is_like THEN 1 ELSE 0 END),
+			dislike_count = dislike_count - (CASE WHEN old.is_like THEN 0 ELSE 1 END)
+		WHERE id = old.comment_id;
+		UPDATE comments SET
+			like_count = like_count + (CASE WHEN new.is_like THEN 1 ELSE 0 END),
+			dislike_count = dislike_count + (CASE WHEN new.is_like THEN 0 ELSE 1 END)
+		WHERE id = new.comment_id;
+	END;
+	`
+
+// sqliteUserKarmaUp adds the reputation score that LikesService keeps up to
+// date as votes change. It starts at 0: "forum repair karma" computes it for
+// existing votes with the configured weights.
+const sqliteUserKarmaUp = `
+	ALTER TABLE users ADD COLUMN karma INTEGER NOT NULL DEFAULT 0;
+	`
+
+const sqliteUserKarmaDown = `
+	ALTER TABLE users DROP COLUMN karma;
+	`
+
+// sqliteUsernameHistoryUp records the names users had before renaming
+// themselves: the latest change limits how often a user may rename, and a
+// recently released name stays reserved for its previous owner.
+const sqliteUsernameHistoryUp = `
+	CREATE TABLE username_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		username VARCHAR(50) NOT NULL,
+		changed_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX idx_username_history_user ON username_history(user_id, changed_at);
+	CREATE INDEX idx_username_history_username ON username_history(username, changed_at);
+	`
+
+const sqliteUsernameHistoryDown = `
+	DROP TABLE IF EXISTS username_history;
+	`
+
+// sqlitePasswordResetsUp holds password reset tokens. Only the SHA-256 of a
+// token is stored, so the table can't be used to reset passwords; a token
+// works until expires_at, and only once (used_at).
+const sqlitePasswordResetsUp = `
+	CREATE TABLE password_resets (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		token_hash VARCHAR(64) UNIQUE NOT NULL,
+		expires_at DATETIME NOT NULL,
+		used_at DATETIME,
+		created_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX idx_password_resets_user ON password_resets(user_id, created_at);
+	`
+
+const sqlitePasswordResetsDown = `
+	DROP TABLE IF EXISTS password_resets;
+	`
+
+// sqliteEmailVerificationUp adds when a user confirmed their email address
+// (NULL until they do) and when a verification email was last sent, for
+// throttling. Accounts that existed before count as verified.
+const sqliteEmailVerificationUp = `
+	ALTER TABLE users ADD COLUMN email_verified_at DATETIME;
+	ALTER TABLE users ADD COLUMN verification_sent_at DATETIME;
+
+	UPDATE users SET email_verified_at = created_at;
+	`
+
+const sqliteEmailVerificationDown = `
+	ALTER TABLE users DROP COLUMN verification_sent_at;
+	ALTER TABLE users DROP COLUMN email_verified_at;
+	`
@@ -0,0 +1,137 @@
+package database
+
+import (
+	"errors"
+	"log"
+)
+
+// ErrSearchUnavailable is returned by EnsureSearchIndex when the SQLite driver
+// was built without FTS5 (build with -tags sqlite_fts5 to enable it)
+var ErrSearchUnavailable = errors.New("full-text search unavailable: SQLite was built without FTS5 (use -tags sqlite_fts5)")
+
+// sqliteSearchIndex creates the FTS5 tables and the triggers that keep them in sync.
+// posts_fts and comments_fts are external-content tables: they index posts/comments
+// without storing a second copy of the text.
+const sqliteSearchIndex = `
+	CREATE VIRTUAL TABLE IF NOT EXISTS posts_fts USING fts5(
+		title, content,
+		content='posts', content_rowid='id',
+		tokenize='unicode61 remove_diacritics 2'
+	);
+
+	CREATE VIRTUAL TABLE IF NOT EXISTS comments_fts USING fts5(
+		content,
+		content='comments', content_rowid='id',
+		tokenize='unicode61 remove_diacritics 2'
+	);
+
+	CREATE TRIGGER IF NOT EXISTS posts_fts_insert AFTER INSERT ON posts BEGIN
+		INSERT INTO posts_fts (rowid, title, content) VALUES (new.id, new.title, new.content);
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS posts_fts_delete AFTER DELETE ON posts BEGIN
+		INSERT INTO posts_fts (posts_fts, rowid, title, content) VALUES ('delete', old.id, old.title, old.content);
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS posts_fts_update AFTER UPDATE OF title, content ON posts BEGIN
+		INSERT INTO posts_fts (posts_fts, rowid, title, content) VALUES ('delete', old.id, old.title, old.content);
+		INSERT INTO posts_fts (rowid, title, content) VALUES (new.id, new.title, new.content);
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS comments_fts_insert AFTER INSERT ON comments BEGIN
+		INSERT INTO comments_fts (rowid, content) VALUES (new.id, new.content);
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS comments_fts_delete AFTER DELETE ON comments BEGIN
+		INSERT INTO comments_fts (comments_fts, rowid, content) VALUES ('delete', old.id, old.content);
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS comments_fts_update AFTER UPDATE OF content ON comments BEGIN
+		INSERT INTO comments_fts (comments_fts, rowid, content) VALUES ('delete', old.id, old.content);
+		INSERT INTO comments_fts (rowid, content) VALUES (new.id, new.content);
+	END;
+	`
+
+// sqliteDropSearchTriggers detaches the FTS tables from posts/comments. A binary
+// without FTS5 cannot fire the triggers, so it removes them to keep writes working;
+// the index is rebuilt the next time an FTS5-enabled binary starts.
+const sqliteDropSearchTriggers = `
+	DROP TRIGGER IF EXISTS posts_fts_insert;
+	DROP TRIGGER IF EXISTS posts_fts_delete;
+	DROP TRIGGER IF EXISTS posts_fts_update;
+	DROP TRIGGER IF EXISTS comments_fts_insert;
+	DROP TRIGGER IF EXISTS comments_fts_delete;
+	DROP TRIGGER IF EXISTS comments_fts_update;
+	`
+
+// sqliteSearchRebuild fills the FTS tables from existing rows
+const sqliteSearchRebuild = `
+	INSERT INTO posts_fts (posts_fts) VALUES ('rebuild');
+	INSERT INTO comments_fts (comments_fts) VALUES ('rebuild');
+	`
+
+// postgresSearchIndex uses expression GIN indexes, which PostgreSQL keeps in sync itself
+const postgresSearchIndex = `
+	CREATE INDEX IF NOT EXISTS idx_posts_search ON posts
+		USING GIN (to_tsvector('simple', title || ' ' || content));
+	CREATE INDEX IF NOT EXISTS idx_comments_search ON comments
+		USING GIN (to_tsvector('simple', content));
+	`
+
+// EnsureSearchIndex creates the full-text search index if the backend supports it
+// and sets db.FullTextSearch. It is safe to call on every start.
+//
+// The index lives outside the numbered migrations because FTS5 is a compile-time
+// option of the SQLite driver: a binary built without it must still be able to
+// migrate and serve the forum (search then falls back to LIKE matching).
+func EnsureSearchIndex(db *DB) error {
+	db.FullTextSearch = false
+
+	if db.Dialect == Postgres {
+		if _, err := db.Exec(postgresSearchIndex); err != nil {
+			return err
+		}
+		db.FullTextSearch = true
+		return nil
+	}
+
+	var enabled bool
+	if err := db.QueryRow(`SELECT sqlite_compileoption_used('ENABLE_FTS5')`).Scan(&enabled); err != nil {
+		return err
+	}
+	if !enabled {
+		if _, err := db.Exec(sqliteDropSearchTriggers); err != nil {
+			return err
+		}
+		return ErrSearchUnavailable
+	}
+
+	// Missing triggers mean the index is new or went stale while they were dropped
+	var triggers int
+	err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name = 'posts_fts_insert'`).Scan(&triggers)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(sqliteSearchIndex); err != nil {
+		return err
+	}
+	if triggers == 0 {
+		log.Printf("Building full-text search index")
+		if _, err := tx.Exec(sqliteSearchRebuild); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	db.FullTextSearch = true
+	return nil
+}
@@ -0,0 +1,348 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"forum/internal/avatar"
+	"forum/internal/models"
+	"forum/internal/services"
+	"forum/internal/store"
+	"forum/internal/validation"
+)
+
+// multipartOverhead is room for the form fields and part headers around an
+// uploaded file
+const multipartOverhead = 64 << 10
+
+// accountNotices are the messages shown after a redirect to /account?saved=...
+var accountNotices = map[string]string{
+	"avatar":        "Your avatar has been updated.",
+	"avatar-remove": "Your avatar has been removed.",
+	"password":      "Your password has been changed. You have been logged out everywhere else.",
+	"email":         "Your email address has been changed. Please confirm it with the link we sent to it.",
+	"verify-sent":   "A new verification link has been sent to your email address.",
+	"username":      "Your username has been changed.",
+}
+
+// accountForm is what the account page shows besides the user: a notice
+// after a change, or an error with the values that were submitted
+type accountForm struct {
+	Notice   string
+	Error    string
+	Email    string // Defaults to the user's
+	Username string // Defaults to the user's
+}
+
+type AccountHandler struct {
+	forum    *ForumHandler // Template helpers
+	users    *services.UserService
+	sessions *services.SessionService
+	verify   *services.EmailVerificationService
+}
+
+func NewAccountHandler(forum *ForumHandler, users *services.UserService, sessions *services.SessionService,
+	verify *services.EmailVerificationService) *AccountHandler {
+	return &AccountHandler{
+		forum:    forum,
+		users:    users,
+		sessions: sessions,
+		verify:   verify,
+	}
+}
+
+// Account handles GET /account: the logged-in user's settings
+func (h *AccountHandler) Account(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		RenderError(w, 405, "Method Not Allowed", "This endpoint only accepts GET requests.")
+		return
+	}
+
+	user := h.forum.getUserFromContext(r)
+	if user == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+	h.renderAccount(w, r, user, accountForm{Notice: accountNotices[r.URL.Query().Get("saved")]})
+}
+
+// Avatar handles POST /account/avatar: a multipart upload in the "avatar"
+// field sets the avatar, a "remove" field clears it
+func (h *AccountHandler) Avatar(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		RenderError(w, 405, "Method Not Allowed", "This endpoint only accepts POST requests.")
+		return
+	}
+
+	user := h.forum.getUserFromContext(r)
+	if user == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	maxBytes := h.users.AvatarMaxBytes()
+	tooLarge := fmt.Sprintf("Image file is too large (the limit is %s).", formatBytes(maxBytes))
+	r.Body = http.MaxBytesReader(w, r.Body, int64(maxBytes)+multipartOverhead)
+	if err := r.ParseMultipartForm(int64(maxBytes) + multipartOverhead); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			h.renderAccount(w, r, user, accountForm{Error: tooLarge})
+			return
+		}
+		// A plain form post can still ask to remove the avatar
+		if !errors.Is(err, http.ErrNotMultipart) {
+			RenderError(w, 400, "Bad Request", "Invalid form data.")
+			return
+		}
+	}
+
+	if r.FormValue("remove") != "" {
+		if err := h.users.RemoveAvatar(user.ID, requestInfo(r)); err != nil {
+			log.Printf("Error removing avatar of user %d: %v", user.ID, err)
+			RenderError(w, 500, "Internal Server Error", "Error removing avatar. Please try again later.")
+			return
+		}
+		http.Redirect(w, r, "/account?saved=avatar-remove", http.StatusSeeOther)
+		return
+	}
+
+	file, _, err := r.FormFile("avatar")
+	if err != nil {
+		h.renderAccount(w, r, user, accountForm{Error: "Please choose an image to upload."})
+		return
+	}
+	defer file.Close()
+
+	// One byte past the limit is enough to reject the file as too large
+	data, err := io.ReadAll(io.LimitReader(file, int64(maxBytes)+1))
+	if err != nil {
+		RenderError(w, 400, "Bad Request", "Error reading the uploaded file.")
+		return
+	}
+
+	if _, err := h.users.SetAvatar(user.ID, data, requestInfo(r)); err != nil {
+		switch {
+		case errors.Is(err, avatar.ErrTooLarge):
+			h.renderAccount(w, r, user, accountForm{Error: tooLarge})
+		case errors.Is(err, avatar.ErrUnsupported):
+			h.renderAccount(w, r, user, accountForm{Error: "Avatar must be a PNG, JPEG or GIF image."})
+		case errors.Is(err, avatar.ErrInvalid):
+			h.renderAccount(w, r, user, accountForm{Error: "The image could not be read. Please upload a valid PNG, JPEG or GIF."})
+		case errors.Is(err, avatar.ErrDimensions):
+			h.renderAccount(w, r, user, accountForm{Error: "Image dimensions are too large."})
+		default:
+			log.Printf("Error saving avatar of user %d: %v", user.ID, err)
+			RenderError(w, 500, "Internal Server Error", "Error saving avatar. Please try again later.")
+		}
+		return
+	}
+	http.Redirect(w, r, "/account?saved=avatar", http.StatusSeeOther)
+}
+
+// Password handles POST /account/password. The current password is
+// required; afterwards every other session is ended and this browser gets
+// a new one.
+func (h *AccountHandler) Password(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.accountPost(w, r)
+	if !ok {
+		return
+	}
+
+	current := r.FormValue("current_password")
+	password := r.FormValue("new_password")
+	if valid, errMsg := validation.ValidatePassword(password); !valid {
+		h.renderAccount(w, r, user, accountForm{Error: errMsg})
+		return
+	}
+	if password != r.FormValue("confirm_password") {
+		h.renderAccount(w, r, user, accountForm{Error: "Passwords do not match"})
+		return
+	}
+	if password == current {
+		h.renderAccount(w, r, user, accountForm{Error: "The new password must be different from the current one"})
+		return
+	}
+
+	if err := h.users.ChangePassword(user.ID, current, password, requestInfo(r)); err != nil {
+		if errors.Is(err, services.ErrWrongPassword) {
+			h.renderAccount(w, r, user, accountForm{Error: "Current password is incorrect"})
+			return
+		}
+		log.Printf("Error changing password of user %d: %v", user.ID, err)
+		RenderError(w, 500, "Internal Server Error", "Error changing password. Please try again later.")
+		return
+	}
+
+	token, err := h.sessions.CreateSession(user.ID, requestInfo(r))
+	if err != nil {
+		// The password is changed; the user just has to log in again
+		log.Printf("Error creating session after password change of user %d: %v", user.ID, err)
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+	setSessionCookie(w, token)
+	http.Redirect(w, r, "/account?saved=password", http.StatusSeeOther)
+}
+
+// Email handles POST /account/email; the current password is required
+func (h *AccountHandler) Email(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.accountPost(w, r)
+	if !ok {
+		return
+	}
+
+	email := r.FormValue("email")
+	form := accountForm{Email: email}
+	if valid, errMsg := validation.ValidateEmail(email); !valid {
+		form.Error = errMsg
+		h.renderAccount(w, r, user, form)
+		return
+	}
+
+	err := h.users.ChangeEmail(user.ID, r.FormValue("password"), email, requestInfo(r))
+	switch {
+	case err == nil:
+		changed := *user
+		changed.Email = email
+		changed.EmailVerifiedAt = nil
+		if err := h.verify.Send(&changed, requestInfo(r)); err != nil {
+			log.Printf("Error sending verification email to user %d: %v", user.ID, err)
+		}
+		http.Redirect(w, r, "/account?saved=email", http.StatusSeeOther)
+	case errors.Is(err, services.ErrWrongPassword):
+		form.Error = "Current password is incorrect"
+		h.renderAccount(w, r, user, form)
+	case errors.Is(err, store.ErrEmailTaken):
+		form.Error = "That email address is already used by another account"
+		h.renderAccount(w, r, user, form)
+	default:
+		log.Printf("Error changing email of user %d: %v", user.ID, err)
+		RenderError(w, 500, "Internal Server Error", "Error changing email. Please try again later.")
+	}
+}
+
+// Username handles POST /account/username. Renames are limited to one per
+// USERNAME_CHANGE_INTERVAL, and names given up recently stay reserved.
+func (h *AccountHandler) Username(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.accountPost(w, r)
+	if !ok {
+		return
+	}
+
+	username := validation.CleanText(r.FormValue("username"))
+	form := accountForm{Username: username}
+	if valid, errMsg := validation.ValidateUsername(username); !valid {
+		form.Error = errMsg
+		h.renderAccount(w, r, user, form)
+		return
+	}
+
+	err := h.users.ChangeUsername(user.ID, username, requestInfo(r))
+	switch {
+	case err == nil:
+		http.Redirect(w, r, "/account?saved=username", http.StatusSeeOther)
+	case errors.Is(err, services.ErrRenameTooSoon):
+		next, _ := h.users.NextUsernameChange(user.ID)
+		form.Error = "You can change your username again on " + toLocalTime(next).Format("Jan 2, 2006 3:04 PM")
+		h.renderAccount(w, r, user, form)
+	case errors.Is(err, store.ErrUsernameTaken):
+		form.Error = "That username is already taken"
+		h.renderAccount(w, r, user, form)
+	case errors.Is(err, services.ErrUsernameReserved):
+		form.Error = "That username was recently used by another account and is still reserved"
+		h.renderAccount(w, r, user, form)
+	default:
+		log.Printf("Error changing username of user %d: %v", user.ID, err)
+		RenderError(w, 500, "Internal Server Error", "Error changing username. Please try again later.")
+	}
+}
+
+// ResendVerification handles POST /account/verify-email: another link for
+// an unverified address, at most one every few minutes
+func (h *AccountHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.accountPost(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.verify.Resend(user, requestInfo(r))
+	switch {
+	case err == nil:
+		http.Redirect(w, r, "/account?saved=verify-sent", http.StatusSeeOther)
+	case errors.Is(err, services.ErrAlreadyVerified):
+		h.renderAccount(w, r, user, accountForm{Error: "Your email address is already verified."})
+	case errors.Is(err, services.ErrVerifyThrottled):
+		next, _ := h.verify.NextResend(user.ID)
+		h.renderAccount(w, r, user, accountForm{
+			Error: "A verification email was sent recently. You can ask for another at " +
+				toLocalTime(next).Format("3:04 PM") + ".",
+		})
+	default:
+		log.Printf("Error resending verification email to user %d: %v", user.ID, err)
+		RenderError(w, 500, "Internal Server Error", "Error sending verification email. Please try again later.")
+	}
+}
+
+// accountPost checks the method and login of a settings form and parses it
+func (h *AccountHandler) accountPost(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	if r.Method != http.MethodPost {
+		RenderError(w, 405, "Method Not Allowed", "This endpoint only accepts POST requests.")
+		return nil, false
+	}
+	user := h.forum.getUserFromContext(r)
+	if user == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return nil, false
+	}
+	if err := r.ParseForm(); err != nil {
+		RenderError(w, 400, "Bad Request", "Invalid form data.")
+		return nil, false
+	}
+	return user, true
+}
+
+// renderAccount shows the account page with an optional notice or error
+func (h *AccountHandler) renderAccount(w http.ResponseWriter, r *http.Request, user *models.User, form accountForm) {
+	if form.Email == "" {
+		form.Email = user.Email
+	}
+	if form.Username == "" {
+		form.Username = user.Username
+	}
+
+	data := h.forum.templateData(r, "Account")
+	data["Account"] = user
+	data["Form"] = form
+	data["AvatarMaxBytes"] = formatBytes(h.users.AvatarMaxBytes())
+	data["RenameDays"] = days(h.users.UsernameChangeInterval())
+	data["ReserveDays"] = days(h.users.UsernameReservePeriod())
+	data["UnverifiedLimit"] = h.forum.postService.UnverifiedLimit()
+	if next, err := h.users.NextUsernameChange(user.ID); err != nil {
+		log.Printf("Error loading last username change of user %d: %v", user.ID, err)
+	} else if next.After(time.Now()) {
+		data["NextRename"] = toLocalTime(next)
+	}
+	h.forum.renderTemplate(w, "account", data)
+}
+
+// days rounds a duration up to whole days
+func days(d time.Duration) int {
+	return int((d + 24*time.Hour - 1) / (24 * time.Hour))
+}
+
+// formatBytes writes a size limit for people, e.g. "2 MB" or "500 KB"
+func formatBytes(n int) string {
+	switch {
+	case n >= 1<<20 && n%(1<<20) == 0:
+		return fmt.Sprintf("%d MB", n>>20)
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%d KB", n>>10)
+	}
+	return fmt.Sprintf("%d bytes", n)
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"forum/internal/services"
+	"forum/internal/store"
+)
+
+// auditPageSize is the number of events shown per page of /admin/audit
+const auditPageSize = 50
+
+// requestInfo extracts the client address and user agent recorded in the audit log
+func requestInfo(r *http.Request) services.RequestInfo {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return services.RequestInfo{
+		IP:        ip,
+		UserAgent: r.UserAgent(),
+	}
+}
+
+type AuditHandler struct {
+	forum *ForumHandler // Template helpers
+	audit *services.AuditService
+}
+
+func NewAuditHandler(forum *ForumHandler, audit *services.AuditService) *AuditHandler {
+	return &AuditHandler{
+		forum: forum,
+		audit: audit,
+	}
+}
+
+// AuditLog handles GET /admin/audit?actor=&action=&target=&id=&before=
+func (h *AuditHandler) AuditLog(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		RenderError(w, 405, "Method Not Allowed", "This endpoint only accepts GET requests.")
+		return
+	}
+
+	user := h.forum.getUserFromContext(r)
+	if user == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+	if !user.IsAdmin {
+		RenderError(w, 403, "Forbidden", "Only administrators can view the audit log.")
+		return
+	}
+
+	query := r.URL.Query()
+	filter := store.AuditFilter{
+		ActorName:  strings.TrimSpace(query.Get("actor")),
+		Action:     query.Get("action"),
+		TargetType: query.Get("target"),
+		Limit:      auditPageSize + 1, // one extra row tells us whether an older page exists
+	}
+
+	if filter.Action != "" && !slices.Contains(services.AuditActions, filter.Action) {
+		RenderError(w, 400, "Bad Request", "Unknown audit action.")
+		return
+	}
+	if filter.TargetType != "" && !slices.Contains(services.AuditTargetTypes, filter.TargetType) {
+		RenderError(w, 400, "Bad Request", "Unknown target type.")
+		return
+	}
+
+	var ok bool
+	if filter.TargetID, ok = optionalID(w, query.Get("id"), "Target ID"); !ok {
+		return
+	}
+	if filter.BeforeID, ok = optionalID(w, query.Get("before"), "Before"); !ok {
+		return
+	}
+
+	events, err := h.audit.ListEvents(filter)
+	if err != nil {
+		log.Printf("Error loading audit events: %v", err)
+		RenderError(w, 500, "Internal Server Error", "Error loading the audit log. Please try again later.")
+		return
+	}
+
+	var olderURL string
+	if len(events) > auditPageSize {
+		events = events[:auditPageSize]
+		older := url.Values{}
+		for _, name := range []string{"actor", "action", "target", "id"} {
+			if v := query.Get(name); v != "" {
+				older.Set(name, v)
+			}
+		}
+		older.Set("before", strconv.Itoa(events[len(events)-1].ID))
+		olderURL = "/admin/audit?" + older.Encode()
+	}
+
+	for i := range events {
+		events[i].CreatedAt = toLocalTime(events[i].CreatedAt)
+	}
+
+	data := h.forum.templateData(r, "Audit Log")
+	data["Events"] = events
+	data["Actions"] = services.AuditActions
+	data["TargetTypes"] = services.AuditTargetTypes
+	data["Filter"] = filter
+	data["TargetIDValue"] = query.Get("id")
+	data["OlderURL"] = olderURL
+	data["Paged"] = filter.BeforeID > 0
+
+	h.forum.renderTemplate(w, "admin_audit", data)
+}
+
+// optionalID parses an optional positive ID query parameter,
+// rendering a 400 page and returning false if it is malformed
+func optionalID(w http.ResponseWriter, value, name string) (int, bool) {
+	if value == "" {
+		return 0, true
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil || id <= 0 {
+		RenderError(w, 400, "Bad Request", fmt.Sprintf("%s must be a positive number.", name))
+		return 0, false
+	}
+	return id, true
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"html/template"
 	"log"
 	"net/http"
@@ -14,12 +15,17 @@ import (
 type AuthHandler struct {
 	userService    *services.UserService
 	sessionService *services.SessionService
+	resetService   *services.PasswordResetService
+	verifyService  *services.EmailVerificationService
 }
 
-func NewAuthHandler(userService *services.UserService, sessionService *services.SessionService) *AuthHandler {
+func NewAuthHandler(userService *services.UserService, sessionService *services.SessionService,
+	resetService *services.PasswordResetService, verifyService *services.EmailVerificationService) *AuthHandler {
 	return &AuthHandler{
 		userService:    userService,
 		sessionService: sessionService,
+		resetService:   resetService,
+		verifyService:  verifyService,
 	}
 }
 
@@ -115,7 +121,7 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		email = strings.TrimSpace(email)
 		// Password: No trim (already validated no spaces, preserve exact chars)
 
-		_, err := h.userService.CreateUser(username, email, password)
+		user, err := h.userService.CreateUser(username, email, password, requestInfo(r))
 		if err != nil {
 			data := map[string]interface{}{
 				"Title":    "Register",
@@ -127,6 +133,11 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		// The account exists either way; a lost email can be sent again from /account
+		if err := h.verifyService.Send(user, requestInfo(r)); err != nil {
+			log.Printf("Error sending verification email to user %d: %v", user.ID, err)
+		}
+
 		http.Redirect(w, r, "/login?registered=1", http.StatusSeeOther)
 		return
 	}
@@ -141,7 +152,10 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 			"Title": "Login",
 		}
 		if r.URL.Query().Get("registered") == "1" {
-			data["Success"] = "Registration successful! Please log in."
+			data["Success"] = "Registration successful! Please log in, and confirm your email address with the link we sent you."
+		}
+		if r.URL.Query().Get("reset") == "1" {
+			data["Success"] = "Your password has been reset. Please log in with the new password."
 		}
 		h.renderAuthTemplate(w, "login", data)
 		return
@@ -167,7 +181,7 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		log.Printf("Authentication successful for user: %s (ID: %d)", user.Username, user.ID)
 
 		// Create session
-		token, err := h.sessionService.CreateSession(user.ID)
+		token, err := h.sessionService.CreateSession(user.ID, requestInfo(r))
 		if err != nil {
 			log.Printf("Session creation failed: %v", err)
 			RenderError(w, 500, "Internal Server Error", "Error creating session. Please try again.")
@@ -176,16 +190,7 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 
 		log.Printf("Session created with token: %s", token[:20]+"...")
 
-		// Set session cookie
-		cookie := &http.Cookie{
-			Name:     "session_token",
-			Value:    token,
-			Path:     "/",
-			Expires:  time.Now().Add(24 * time.Hour),
-			HttpOnly: true,
-			SameSite: http.SameSiteLaxMode,
-		}
-		http.SetCookie(w, cookie)
+		setSessionCookie(w, token)
 
 		log.Printf("Cookie set, redirecting to /")
 
@@ -206,7 +211,7 @@ func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 	cookie, err := r.Cookie("session_token")
 	if err == nil {
 		// Delete session from database
-		h.sessionService.DeleteSession(cookie.Value)
+		h.sessionService.DeleteSession(cookie.Value, requestInfo(r))
 	}
 
 	// Clear session cookie
@@ -221,3 +226,128 @@ func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 
 	http.Redirect(w, r, "/", http.StatusSeeOther)
 }
+
+// ForgotPassword handles /forgot-password: GET shows the form, POST emails a
+// reset link. The answer is the same whether or not the account exists.
+func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		h.renderAuthTemplate(w, "forgot_password", map[string]interface{}{
+			"Title": "Forgot Password",
+		})
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		login := strings.TrimSpace(r.FormValue("login"))
+		if login == "" {
+			h.renderAuthTemplate(w, "forgot_password", map[string]interface{}{
+				"Title": "Forgot Password",
+				"Error": "Please enter your username or email",
+			})
+			return
+		}
+
+		if err := h.resetService.RequestReset(login, requestInfo(r)); err != nil {
+			// Logged only: an error page would tell that the account exists
+			log.Printf("Password reset request failed: %v", err)
+		}
+		h.renderAuthTemplate(w, "forgot_password", map[string]interface{}{
+			"Title":   "Forgot Password",
+			"Success": "If an account matches, a password reset link has been sent to its email address.",
+		})
+		return
+	}
+
+	RenderError(w, 405, "Method Not Allowed", "This endpoint only accepts GET and POST requests.")
+}
+
+// ResetPassword handles /reset-password?token=...: GET shows the new
+// password form for a valid token, POST sets the password and ends every
+// session of the user
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		RenderError(w, 405, "Method Not Allowed", "This endpoint only accepts GET and POST requests.")
+		return
+	}
+
+	token := r.FormValue("token")
+	data := map[string]interface{}{
+		"Title": "Reset Password",
+		"Token": token,
+	}
+
+	if r.Method == http.MethodGet {
+		if err := h.resetService.CheckToken(token); err != nil {
+			if !errors.Is(err, services.ErrInvalidResetToken) {
+				log.Printf("Password reset token check failed: %v", err)
+				RenderError(w, 500, "Internal Server Error", "Error checking the reset link. Please try again later.")
+				return
+			}
+			data["Invalid"] = true
+		}
+		h.renderAuthTemplate(w, "reset_password", data)
+		return
+	}
+
+	password := r.FormValue("password")
+	if valid, errMsg := validation.ValidatePassword(password); !valid {
+		data["Error"] = errMsg
+		h.renderAuthTemplate(w, "reset_password", data)
+		return
+	}
+	if password != r.FormValue("confirm_password") {
+		data["Error"] = "Passwords do not match"
+		h.renderAuthTemplate(w, "reset_password", data)
+		return
+	}
+
+	if err := h.resetService.ResetPassword(token, password, requestInfo(r)); err != nil {
+		if errors.Is(err, services.ErrInvalidResetToken) {
+			data["Invalid"] = true
+			h.renderAuthTemplate(w, "reset_password", data)
+			return
+		}
+		log.Printf("Password reset failed: %v", err)
+		RenderError(w, 500, "Internal Server Error", "Error resetting password. Please try again later.")
+		return
+	}
+
+	http.Redirect(w, r, "/login?reset=1", http.StatusSeeOther)
+}
+
+// VerifyEmail handles GET /verify-email?token=...: the link emailed to
+// confirm an address. It works without logging in.
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		RenderError(w, 405, "Method Not Allowed", "This endpoint only accepts GET requests.")
+		return
+	}
+
+	data := map[string]interface{}{
+		"Title": "Verify Email",
+	}
+	user, err := h.verifyService.Verify(r.URL.Query().Get("token"), requestInfo(r))
+	switch {
+	case err == nil:
+		data["Success"] = "Thank you! The email address " + user.Email + " is verified."
+	case errors.Is(err, services.ErrInvalidVerifyToken):
+		data["Error"] = "This verification link is invalid or has expired."
+	default:
+		log.Printf("Email verification failed: %v", err)
+		RenderError(w, 500, "Internal Server Error", "Error verifying email. Please try again later.")
+		return
+	}
+	h.renderAuthTemplate(w, "verify_email", data)
+}
+
+// setSessionCookie gives the browser a session created by SessionService.CreateSession
+func setSessionCookie(w http.ResponseWriter, token string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     "session_token",
+		Value:    token,
+		Path:     "/",
+		Expires:  time.Now().Add(24 * time.Hour),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
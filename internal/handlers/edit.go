@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"forum/internal/models"
+	"forum/internal/services"
+	"forum/internal/store"
+	"forum/internal/validation"
+)
+
+// postForm is the title, content and categories submitted by the
+// create and edit post forms
+type postForm struct {
+	Title       string
+	Content     string
+	CategoryIDs []int
+}
+
+// readPostForm reads and validates a post form. errMsg is the message to
+// show on the form when the input is invalid; err is set only when the
+// request body cannot be parsed at all.
+func readPostForm(r *http.Request) (form postForm, errMsg string, err error) {
+	// ✅ STEP 1: Get raw input (NO TRIM YET!)
+	// ✅ STEP 2: Clean dangerous Unicode only (preserves spaces for validation)
+	form.Title = validation.CleanText(r.FormValue("title"))
+	form.Content = validation.CleanText(r.FormValue("content"))
+
+	if err := r.ParseForm(); err != nil {
+		return form, "", err
+	}
+
+	// ✅ FIX: Validate category ID format EXPLICITLY before processing
+	for _, idStr := range r.Form["category_id[]"] {
+		if strings.TrimSpace(idStr) == "" {
+			return form, "Invalid category selection: empty category ID", nil
+		}
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			return form, fmt.Sprintf("Invalid category ID format: '%s' must be a number", idStr), nil
+		}
+		if id <= 0 {
+			return form, fmt.Sprintf("Invalid category ID: %d (must be positive)", id), nil
+		}
+		form.CategoryIDs = append(form.CategoryIDs, id)
+	}
+
+	if valid, msg := validation.ValidatePostTitle(form.Title); !valid {
+		return form, msg, nil
+	}
+	if valid, msg := validation.ValidatePostContent(form.Content); !valid {
+		return form, msg, nil
+	}
+	if valid, msg := validation.ValidateCategories(form.CategoryIDs); !valid {
+		return form, msg, nil
+	}
+
+	// ✅ STEP 3: NOW TRIM - After validation passed
+	// At this point, validation already rejected any leading/trailing spaces
+	// This trim is just for safety (should be a no-op)
+	form.Title = strings.TrimSpace(form.Title)
+	form.Content = strings.TrimSpace(form.Content)
+	return form, "", nil
+}
+
+// renderPostForm shows the create post form (editPostID 0) or the edit form
+// for a post, filled with the submitted values and an optional error
+func (h *ForumHandler) renderPostForm(w http.ResponseWriter, r *http.Request, editPostID int, form postForm, errMsg string) {
+	categories, err := h.categories.ListCategories()
+	if err != nil {
+		RenderError(w, 500, "Internal Server Error", "Error loading categories. Please try again later.")
+		return
+	}
+
+	pageTitle := "Create New Post"
+	if editPostID > 0 {
+		pageTitle = "Edit Post"
+	}
+	data := h.templateData(r, pageTitle)
+	data["Categories"] = categories
+	data["EditPostID"] = editPostID
+	data["PostTitle"] = form.Title // "Title" is the page title
+	data["Content"] = form.Content
+	data["SelectedCategoryIDs"] = form.CategoryIDs
+	if errMsg != "" {
+		data["Error"] = errMsg
+	}
+
+	h.renderTemplate(w, "create_post", data)
+}
+
+// EditPost handles GET/POST /post/{id}/edit (author or admin)
+func (h *ForumHandler) EditPost(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		RenderError(w, 405, "Method Not Allowed", "This endpoint only accepts GET and POST requests.")
+		return
+	}
+
+	user := h.getUserFromContext(r)
+	if user == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	postID, ok := actionID(w, r, "/post/", "Post")
+	if !ok {
+		return
+	}
+
+	post, err := h.postService.PostForEdit(user, postID)
+	if err != nil {
+		renderModerationError(w, err, "Post", "edit")
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		h.renderPostForm(w, r, postID, postForm{
+			Title:       post.Title,
+			Content:     post.Content,
+			CategoryIDs: post.CategoryIDs,
+		}, "")
+		return
+	}
+
+	form, errMsg, err := readPostForm(r)
+	if err != nil {
+		RenderError(w, 400, "Bad Request", "Error parsing form data.")
+		return
+	}
+	if errMsg != "" {
+		h.renderPostForm(w, r, postID, form, errMsg)
+		return
+	}
+
+	err = h.postService.EditPost(user, postID, form.Title, form.Content, form.CategoryIDs, requestInfo(r))
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) || errors.Is(err, services.ErrForbidden) {
+			renderModerationError(w, err, "Post", "edit")
+			return
+		}
+		log.Printf("Error editing post: %v", err)
+		h.renderPostForm(w, r, postID, form, "Error saving post: "+err.Error())
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/post/%d", postID), http.StatusSeeOther)
+}
+
+// renderCommentForm shows the edit form for a comment, filled with the
+// submitted content and an optional error
+func (h *ForumHandler) renderCommentForm(w http.ResponseWriter, r *http.Request, comment *models.Comment, content, errMsg string) {
+	data := h.templateData(r, "Edit Comment")
+	data["Comment"] = comment
+	data["Content"] = content
+	if errMsg != "" {
+		data["Error"] = errMsg
+	}
+
+	h.renderTemplate(w, "edit_comment", data)
+}
+
+// renderCommentEditError is renderModerationError plus locked threads
+func renderCommentEditError(w http.ResponseWriter, err error) {
+	if errors.Is(err, services.ErrLocked) {
+		RenderError(w, 403, "Thread Locked", "This thread is locked and its comments can no longer be edited.")
+		return
+	}
+	renderModerationError(w, err, "Comment", "edit")
+}
+
+// EditComment handles GET/POST /comment/{id}/edit (author or admin)
+func (h *ForumHandler) EditComment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		RenderError(w, 405, "Method Not Allowed", "This endpoint only accepts GET and POST requests.")
+		return
+	}
+
+	user := h.getUserFromContext(r)
+	if user == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	commentID, ok := actionID(w, r, "/comment/", "Comment")
+	if !ok {
+		return
+	}
+
+	comment, err := h.postService.CommentForEdit(user, commentID)
+	if err != nil {
+		renderCommentEditError(w, err)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		h.renderCommentForm(w, r, comment, comment.Content, "")
+		return
+	}
+
+	// Same steps as CreateComment: clean, validate, then trim
+	content := validation.CleanText(r.FormValue("content"))
+	if valid, errMsg := validation.ValidateCommentContent(content); !valid {
+		h.renderCommentForm(w, r, comment, content, errMsg)
+		return
+	}
+	content = strings.TrimSpace(content)
+
+	err = h.postService.EditComment(user, commentID, content, requestInfo(r))
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) || errors.Is(err, services.ErrForbidden) ||
+			errors.Is(err, services.ErrLocked) {
+			renderCommentEditError(w, err)
+			return
+		}
+		log.Printf("Error editing comment: %v", err)
+		h.renderCommentForm(w, r, comment, content, "Error saving comment. Please try again later.")
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/post/%d#comment-%d", comment.PostID, commentID), http.StatusSeeOther)
+}
+
+// PostHistory handles GET /post/{id}/history: every version of a post with diffs
+func (h *ForumHandler) PostHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		RenderError(w, 405, "Method Not Allowed", "This endpoint only accepts GET requests.")
+		return
+	}
+
+	postID, ok := actionID(w, r, "/post/", "Post")
+	if !ok {
+		return
+	}
+
+	post, versions, err := h.postService.History(postID)
+	user := h.getUserFromContext(r)
+	if err == nil && post.IsDeleted() && (user == nil || !user.IsAdmin) {
+		err = store.ErrNotFound // deleted posts keep their history from everyone but admins
+	}
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			RenderError(w, 404, "Not Found", "The post you're looking for doesn't exist.")
+			return
+		}
+		log.Printf("Error loading post history: %v", err)
+		RenderError(w, 500, "Internal Server Error", "Error loading post history. Please try again later.")
+		return
+	}
+
+	for i := range versions {
+		versions[i].CreatedAt = toLocalTime(versions[i].CreatedAt)
+	}
+
+	data := h.templateData(r, "History: "+post.Title)
+	data["Post"] = post
+	data["Versions"] = versions
+
+	h.renderTemplate(w, "post_history", data)
+}
@@ -1,7 +1,7 @@
 package handlers
 
 import (
-	"database/sql"
+	"errors"
 	"fmt"
 	"html/template"
 	"log"
@@ -12,6 +12,8 @@ import (
 
 	"forum/internal/middleware"
 	"forum/internal/models"
+	"forum/internal/services"
+	"forum/internal/store"
 	"forum/internal/validation"
 )
 
@@ -36,12 +38,24 @@ func toLocalTime(t time.Time) time.Time {
 }
 
 type ForumHandler struct {
-	db *sql.DB
+	postService  *services.PostService  // Creating posts and comments
+	likesService *services.LikesService // Reactions shown on a post page
+	posts        store.PostStore
+	comments     store.CommentStore
+	categories   store.CategoryStore
+	search       store.SearchStore
+	pageSize     int
 }
 
-func NewForumHandler(db *sql.DB) *ForumHandler {
+func NewForumHandler(postService *services.PostService, likesService *services.LikesService, posts store.PostStore, comments store.CommentStore, categories store.CategoryStore, search store.SearchStore, pageSize int) *ForumHandler {
 	return &ForumHandler{
-		db: db,
+		postService:  postService,
+		likesService: likesService,
+		posts:        posts,
+		comments:     comments,
+		categories:   categories,
+		search:       search,
+		pageSize:     pageSize,
 	}
 }
 
@@ -80,6 +94,46 @@ func (h *ForumHandler) templateData(r *http.Request, title string) map[string]in
 	}
 }
 
+// unverifiedLimitMessage explains services.ErrUnverifiedLimit
+func (h *ForumHandler) unverifiedLimitMessage() string {
+	if limit := h.postService.UnverifiedLimit(); limit > 0 {
+		return fmt.Sprintf("Accounts with an unverified email address can create %d posts and comments a day. "+
+			"Please verify your email address to post more; you can request a new link on your account page.", limit)
+	}
+	return "Please verify your email address before posting; you can request a new link on your account page."
+}
+
+// pageRequest reads the ?before= / ?after= cursors of a listing page
+func (h *ForumHandler) pageRequest(r *http.Request) (store.PageRequest, error) {
+	page := store.PageRequest{Limit: h.pageSize}
+	before := r.URL.Query().Get("before")
+	after := r.URL.Query().Get("after")
+
+	if before != "" && after != "" {
+		return page, store.ErrInvalidCursor
+	}
+	var err error
+	if before != "" {
+		page.Before, err = store.ParseCursor(before)
+	}
+	if after != "" {
+		page.After, err = store.ParseCursor(after)
+	}
+	return page, err
+}
+
+// pageURL links to a neighbouring page, keeping the other query parameters (e.g. filter)
+func pageURL(r *http.Request, param string, cursor *store.Cursor) string {
+	if cursor == nil {
+		return ""
+	}
+	query := r.URL.Query()
+	query.Del("before")
+	query.Del("after")
+	query.Set(param, cursor.Encode())
+	return r.URL.Path + "?" + query.Encode()
+}
+
 func (h *ForumHandler) Home(w http.ResponseWriter, r *http.Request) {
 	// ✅ NEW: Validate method
 	if r.Method != http.MethodGet {
@@ -87,7 +141,7 @@ func (h *ForumHandler) Home(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	categories, err := h.getCategories()
+	categories, err := h.categories.ListCategories()
 	if err != nil {
 		RenderError(w, 500, "Internal Server Error", "Error loading categories. Please try again later.")
 		log.Printf("Error loading categories: %v", err)
@@ -102,7 +156,13 @@ func (h *ForumHandler) Home(w http.ResponseWriter, r *http.Request) {
 
 	filter := r.URL.Query().Get("filter")
 
-	var recentPosts []models.Post
+	page, err := h.pageRequest(r)
+	if err != nil {
+		RenderError(w, 400, "Bad Request", "Invalid page cursor.")
+		return
+	}
+
+	var postPage *store.PostPage
 	var filterTitle string
 
 	switch filter {
@@ -111,17 +171,17 @@ func (h *ForumHandler) Home(w http.ResponseWriter, r *http.Request) {
 			http.Redirect(w, r, "/login", http.StatusSeeOther)
 			return
 		}
-		recentPosts, err = h.getMyPosts(userID)
+		postPage, err = h.posts.ListPostsPage(store.PostFilter{AuthorID: userID, ViewerID: userID}, page)
 		filterTitle = "My Posts"
 	case "liked-posts":
 		if user == nil {
 			http.Redirect(w, r, "/login", http.StatusSeeOther)
 			return
 		}
-		recentPosts, err = h.getLikedPosts(userID)
+		postPage, err = h.posts.ListPostsPage(store.PostFilter{LikedBy: userID, ViewerID: userID}, page)
 		filterTitle = "Liked Posts"
 	default:
-		recentPosts, err = h.getRecentPosts(userID)
+		postPage, err = h.posts.ListPostsPage(store.PostFilter{ViewerID: userID}, page)
 		filterTitle = "Recent Posts"
 	}
 
@@ -131,6 +191,8 @@ func (h *ForumHandler) Home(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	recentPosts := postPage.Posts
+
 	// Convert all post times to local timezone
 	for i := range recentPosts {
 		recentPosts[i].CreatedAt = toLocalTime(recentPosts[i].CreatedAt)
@@ -141,6 +203,8 @@ func (h *ForumHandler) Home(w http.ResponseWriter, r *http.Request) {
 	data["RecentPosts"] = recentPosts
 	data["FilterTitle"] = filterTitle
 	data["CurrentFilter"] = filter
+	data["NextURL"] = pageURL(r, "before", postPage.Next)
+	data["PrevURL"] = pageURL(r, "after", postPage.Prev)
 
 	h.renderTemplate(w, "home", data)
 }
@@ -159,9 +223,9 @@ func (h *ForumHandler) CategoryView(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	category, err := h.getCategoryBySlug(slug)
+	category, err := h.categories.GetCategoryBySlug(slug)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, store.ErrNotFound) {
 			RenderError(w, 404, "Category Not Found", "The category you're looking for doesn't exist.")
 			return
 		}
@@ -179,7 +243,13 @@ func (h *ForumHandler) CategoryView(w http.ResponseWriter, r *http.Request) {
 	// Get filter parameter (CONSISTENT with home page)
 	filter := r.URL.Query().Get("filter")
 
-	var posts []models.Post
+	page, err := h.pageRequest(r)
+	if err != nil {
+		RenderError(w, 400, "Bad Request", "Invalid page cursor.")
+		return
+	}
+
+	var postPage *store.PostPage
 	var filterTitle string
 
 	// Apply filter based on user selection
@@ -189,17 +259,17 @@ func (h *ForumHandler) CategoryView(w http.ResponseWriter, r *http.Request) {
 			http.Redirect(w, r, "/login", http.StatusSeeOther)
 			return
 		}
-		posts, err = h.getMyPostsByCategory(userID, category.ID)
+		postPage, err = h.posts.ListPostsPage(store.PostFilter{CategoryID: category.ID, AuthorID: userID, ViewerID: userID}, page)
 		filterTitle = "My Posts in " + category.Name
 	case "liked-posts":
 		if user == nil {
 			http.Redirect(w, r, "/login", http.StatusSeeOther)
 			return
 		}
-		posts, err = h.getLikedPostsByCategory(userID, category.ID)
+		postPage, err = h.posts.ListPostsPage(store.PostFilter{CategoryID: category.ID, LikedBy: userID, ViewerID: userID}, page)
 		filterTitle = "Liked Posts in " + category.Name
 	default:
-		posts, err = h.getPostsByCategory(category.ID, userID)
+		postPage, err = h.posts.ListPostsPage(store.PostFilter{CategoryID: category.ID, ViewerID: userID}, page)
 		filterTitle = "All Posts in " + category.Name
 	}
 
@@ -209,6 +279,8 @@ func (h *ForumHandler) CategoryView(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	posts := postPage.Posts
+
 	// Convert times to local timezone
 	for i := range posts {
 		posts[i].CreatedAt = toLocalTime(posts[i].CreatedAt)
@@ -219,6 +291,8 @@ func (h *ForumHandler) CategoryView(w http.ResponseWriter, r *http.Request) {
 	data["Posts"] = posts
 	data["FilterTitle"] = filterTitle
 	data["CurrentFilter"] = filter
+	data["NextURL"] = pageURL(r, "before", postPage.Next)
+	data["PrevURL"] = pageURL(r, "after", postPage.Prev)
 
 	h.renderTemplate(w, "category", data)
 }
@@ -255,32 +329,72 @@ func (h *ForumHandler) PostView(w http.ResponseWriter, r *http.Request) {
 		userID = user.ID
 	}
 
-	post, err := h.getPostByID(id, userID)
+	post, err := h.posts.GetPost(id, userID)
 	if err != nil {
-		// ✅ CORRECT: 404 for valid ID that doesn't exist
-		RenderError(w, 404, "Not Found", "The post you're looking for doesn't exist.")
+		if errors.Is(err, store.ErrNotFound) {
+			// ✅ CORRECT: 404 for valid ID that doesn't exist
+			RenderError(w, 404, "Not Found", "The post you're looking for doesn't exist.")
+			return
+		}
+		log.Printf("Error loading post: %v", err)
+		RenderError(w, 500, "Internal Server Error", "Error loading post. Please try again later.")
 		return
 	}
 
-	h.incrementViewCount(id)
+	if !post.IsDeleted() {
+		if err := h.posts.IncrementViewCount(id); err != nil {
+			log.Printf("Error incrementing view count: %v", err)
+		}
+	}
 
-	comments, err := h.getCommentsByPostID(id, userID)
+	comments, err := h.comments.ListComments(id, userID)
 	if err != nil {
 		RenderError(w, 500, "Internal Server Error", "Error loading comments. Please try again later.")
 		return
 	}
 
+	h.renderPost(w, r, post, comments, nil)
+}
+
+// renderPost renders the post page for post and its comments as loaded from
+// the store. Entries of extra are added to the template data, e.g. a comment
+// form error.
+func (h *ForumHandler) renderPost(w http.ResponseWriter, r *http.Request, post *models.Post, comments []models.Comment, extra map[string]interface{}) {
+	user := h.getUserFromContext(r)
+	var userID int
+	if user != nil {
+		userID = user.ID
+	}
+
+	// Deleted posts and comments keep their place but not their text
+	hideDeleted(post, comments, user)
+	h.postService.RenderContent(post, comments)
+	comments = h.postService.ThreadComments(comments)
+	if err := h.likesService.AttachReactions(userID, post, comments); err != nil {
+		log.Printf("Error loading reactions: %v", err)
+		RenderError(w, 500, "Internal Server Error", "Error loading reactions. Please try again later.")
+		return
+	}
+
 	// Convert post time to local timezone
 	post.CreatedAt = toLocalTime(post.CreatedAt)
+	post.EditedAt = localTimePtr(post.EditedAt)
+	post.LockedAt = localTimePtr(post.LockedAt)
+	post.DeletedAt = localTimePtr(post.DeletedAt)
 
 	// Convert all comment times to local timezone
 	for i := range comments {
 		comments[i].CreatedAt = toLocalTime(comments[i].CreatedAt)
+		comments[i].UpdatedAt = toLocalTime(comments[i].UpdatedAt)
+		comments[i].DeletedAt = localTimePtr(comments[i].DeletedAt)
 	}
 
 	data := h.templateData(r, post.Title)
 	data["Post"] = post
 	data["Comments"] = comments
+	for k, v := range extra {
+		data[k] = v
+	}
 
 	h.renderTemplate(w, "post", data)
 }
@@ -298,7 +412,7 @@ func (h *ForumHandler) CreatePost(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if r.Method == http.MethodGet {
-		categories, err := h.getCategories()
+		categories, err := h.categories.ListCategories()
 		if err != nil {
 			RenderError(w, 500, "Internal Server Error", "Error loading categories. Please try again later.")
 			return
@@ -312,127 +426,23 @@ func (h *ForumHandler) CreatePost(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if r.Method == http.MethodPost {
-		// ✅ STEP 1: Get raw input (NO TRIM YET!)
-		title := r.FormValue("title")
-		content := r.FormValue("content")
-
-		// ✅ STEP 2: Clean dangerous Unicode only (preserves spaces for validation)
-		title = validation.CleanText(title)
-		content = validation.CleanText(content)
-
-		err := r.ParseForm()
+		form, errMsg, err := readPostForm(r)
 		if err != nil {
 			RenderError(w, 400, "Bad Request", "Error parsing form data.")
 			return
 		}
-
-		categoryIDStrs := r.Form["category_id[]"]
-
-		// ✅ FIX: Validate category ID format EXPLICITLY before processing
-		var categoryIDs []int
-		for _, idStr := range categoryIDStrs {
-			// Check for empty strings
-			if strings.TrimSpace(idStr) == "" {
-				categories, _ := h.getCategories()
-				data := h.templateData(r, "Create New Post")
-				data["Categories"] = categories
-				data["Error"] = "Invalid category selection: empty category ID"
-				data["Title"] = title
-				data["Content"] = content
-
-				h.renderTemplate(w, "create_post", data)
-				return
-			}
-
-			// Try to parse as integer
-			id, err := strconv.Atoi(idStr)
-			if err != nil {
-				categories, _ := h.getCategories()
-				data := h.templateData(r, "Create New Post")
-				data["Categories"] = categories
-				data["Error"] = fmt.Sprintf("Invalid category ID format: '%s' must be a number", idStr)
-				data["Title"] = title
-				data["Content"] = content
-
-				h.renderTemplate(w, "create_post", data)
-				return
-			}
-
-			// Validate ID is positive
-			if id <= 0 {
-				categories, _ := h.getCategories()
-				data := h.templateData(r, "Create New Post")
-				data["Categories"] = categories
-				data["Error"] = fmt.Sprintf("Invalid category ID: %d (must be positive)", id)
-				data["Title"] = title
-				data["Content"] = content
-
-				h.renderTemplate(w, "create_post", data)
-				return
-			}
-
-			categoryIDs = append(categoryIDs, id)
-		}
-
-		// Validate title
-		if valid, errMsg := validation.ValidatePostTitle(title); !valid {
-			categories, _ := h.getCategories()
-			data := h.templateData(r, "Create New Post")
-			data["Categories"] = categories
-			data["Error"] = errMsg
-			data["Title"] = title
-			data["Content"] = content
-			data["SelectedCategoryIDs"] = categoryIDs
-
-			h.renderTemplate(w, "create_post", data)
-			return
-		}
-
-		// Validate content
-		if valid, errMsg := validation.ValidatePostContent(content); !valid {
-			categories, _ := h.getCategories()
-			data := h.templateData(r, "Create New Post")
-			data["Categories"] = categories
-			data["Error"] = errMsg
-			data["Title"] = title
-			data["Content"] = content
-			data["SelectedCategoryIDs"] = categoryIDs
-
-			h.renderTemplate(w, "create_post", data)
+		if errMsg != "" {
+			h.renderPostForm(w, r, 0, form, errMsg)
 			return
 		}
 
-		// Validate categories
-		if valid, errMsg := validation.ValidateCategories(categoryIDs); !valid {
-			categories, _ := h.getCategories()
-			data := h.templateData(r, "Create New Post")
-			data["Categories"] = categories
-			data["Error"] = errMsg
-			data["Title"] = title
-			data["Content"] = content
-			data["SelectedCategoryIDs"] = categoryIDs
-
-			h.renderTemplate(w, "create_post", data)
+		postID, err := h.postService.CreatePost(user, form.Title, form.Content, form.CategoryIDs, requestInfo(r))
+		if errors.Is(err, services.ErrUnverifiedLimit) {
+			h.renderPostForm(w, r, 0, form, h.unverifiedLimitMessage())
 			return
 		}
-
-		// ✅ STEP 3: NOW TRIM - After validation passed
-		// At this point, validation already rejected any leading/trailing spaces
-		// This trim is just for safety (should be a no-op)
-		title = strings.TrimSpace(title)
-		content = strings.TrimSpace(content)
-
-		postID, err := h.createPost(title, content, user.ID, categoryIDs)
 		if err != nil {
-			categories, _ := h.getCategories()
-			data := h.templateData(r, "Create New Post")
-			data["Categories"] = categories
-			data["Error"] = "Error creating post: " + err.Error()
-			data["Title"] = title
-			data["Content"] = content
-			data["SelectedCategoryIDs"] = categoryIDs
-
-			h.renderTemplate(w, "create_post", data)
+			h.renderPostForm(w, r, 0, form, "Error creating post: "+err.Error())
 			return
 		}
 
@@ -443,7 +453,8 @@ func (h *ForumHandler) CreatePost(w http.ResponseWriter, r *http.Request) {
 	RenderError(w, 405, "Method Not Allowed", "This endpoint only accepts GET and POST requests.")
 }
 
-// CreateComment handles POST /comment/{postID}
+// CreateComment handles POST /comment/{postID}. An optional parent_id field
+// makes the comment a reply to another comment on the same post.
 func (h *ForumHandler) CreateComment(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		RenderError(w, 405, "Method Not Allowed", "This endpoint only accepts POST requests.")
@@ -478,7 +489,7 @@ func (h *ForumHandler) CreateComment(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check if post exists
-	exists, err := h.postExists(postID)
+	exists, err := h.posts.PostExists(postID)
 	if err != nil {
 		log.Printf("Error checking post existence: %v", err)
 		RenderError(w, 500, "Internal Server Error", "Error processing comment. Please try again later.")
@@ -490,6 +501,17 @@ func (h *ForumHandler) CreateComment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Replies name the comment they answer; top-level comments leave it empty
+	var parentID *int
+	if parentStr := r.FormValue("parent_id"); parentStr != "" {
+		id, err := strconv.Atoi(parentStr)
+		if err != nil || id <= 0 {
+			RenderError(w, 400, "Bad Request", "Invalid parent comment ID. Must be a positive number.")
+			return
+		}
+		parentID = &id
+	}
+
 	// ✅ STEP 1: Get raw input (NO TRIM YET!)
 	content := r.FormValue("content")
 
@@ -499,7 +521,7 @@ func (h *ForumHandler) CreateComment(w http.ResponseWriter, r *http.Request) {
 	// ✅ NEW: VALIDATE COMMENT CONTENT - Stay on page on error
 	if valid, errMsg := validation.ValidateCommentContent(content); !valid {
 		// Get post data to re-render the page
-		post, err := h.getPostByID(postID, user.ID)
+		post, err := h.posts.GetPost(postID, user.ID)
 		if err != nil {
 			log.Printf("Error loading post: %v", err)
 			RenderError(w, 500, "Internal Server Error", "Error processing comment. Please try again later.")
@@ -507,27 +529,23 @@ func (h *ForumHandler) CreateComment(w http.ResponseWriter, r *http.Request) {
 		}
 
 		// Get existing comments
-		comments, err := h.getCommentsByPostID(postID, user.ID)
+		comments, err := h.comments.ListComments(postID, user.ID)
 		if err != nil {
 			log.Printf("Error loading comments: %v", err)
 			RenderError(w, 500, "Internal Server Error", "Error processing comment. Please try again later.")
 			return
 		}
 
-		// Convert times to local timezone
-		post.CreatedAt = toLocalTime(post.CreatedAt)
-		for i := range comments {
-			comments[i].CreatedAt = toLocalTime(comments[i].CreatedAt)
-		}
-
 		// Re-render post page with error message and preserve user's UNTRIMMED comment
-		data := h.templateData(r, post.Title)
-		data["Post"] = post
-		data["Comments"] = comments
-		data["CommentError"] = errMsg    // Error message to display
-		data["CommentContent"] = content // Preserve user's input with spaces
+		extra := map[string]interface{}{
+			"CommentError":   errMsg,  // Error message to display
+			"CommentContent": content, // Preserve user's input with spaces
+		}
+		if parentID != nil {
+			extra["ReplyTo"] = *parentID // Show the error on that comment's reply form
+		}
 
-		h.renderTemplate(w, "post", data)
+		h.renderPost(w, r, post, comments, extra)
 		return
 	}
 
@@ -535,558 +553,56 @@ func (h *ForumHandler) CreateComment(w http.ResponseWriter, r *http.Request) {
 	// At this point, validation already rejected any leading/trailing spaces
 	content = strings.TrimSpace(content)
 
-	err = h.insertComment(content, user.ID, postID)
-	if err != nil {
-		log.Printf("Error creating comment: %v", err)
-
-		// ✅ NEW: On database error, also stay on page with error message
-		post, postErr := h.getPostByID(postID, user.ID)
-		if postErr != nil {
-			// Fallback to error page if we can't load post
-			RenderError(w, 500, "Internal Server Error", "Error creating comment. Please try again later.")
-			return
-		}
-
-		comments, _ := h.getCommentsByPostID(postID, user.ID)
-
-		post.CreatedAt = toLocalTime(post.CreatedAt)
-		for i := range comments {
-			comments[i].CreatedAt = toLocalTime(comments[i].CreatedAt)
-		}
-
-		data := h.templateData(r, post.Title)
-		data["Post"] = post
-		data["Comments"] = comments
-		data["CommentError"] = "Error creating comment. Please try again later."
-		data["CommentContent"] = content // Already trimmed at this point
-
-		h.renderTemplate(w, "post", data)
+	_, err = h.postService.CreateComment(user, postID, parentID, content, requestInfo(r))
+	if errors.Is(err, services.ErrLocked) {
+		RenderError(w, 403, "Thread Locked", "This thread is locked and no longer accepts comments.")
 		return
 	}
-
-	http.Redirect(w, r, fmt.Sprintf("/post/%d", postID), http.StatusSeeOther)
-}
-
-// Database helper methods
-func (h *ForumHandler) getCategories() ([]models.Category, error) {
-	query := `SELECT id, name, description, slug, created_at FROM categories ORDER BY name`
-	rows, err := h.db.Query(query)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var categories []models.Category
-	for rows.Next() {
-		var c models.Category
-		err := rows.Scan(&c.ID, &c.Name, &c.Description, &c.Slug, &c.CreatedAt)
-		if err != nil {
-			return nil, err
-		}
-		categories = append(categories, c)
-	}
-	return categories, nil
-}
-
-// getRecentPosts retrieves recent posts with comment counts
-func (h *ForumHandler) getRecentPosts(userID int) ([]models.Post, error) {
-	query := `
-		SELECT p.id, p.title, p.content, p.user_id, p.view_count, 
-		       p.created_at, u.username,
-		       COUNT(DISTINCT c.id) as reply_count,
-		       COUNT(DISTINCT CASE WHEN pl.is_like = 1 THEN pl.id END) as like_count,
-		       COUNT(DISTINCT CASE WHEN pl.is_like = 0 THEN pl.id END) as dislike_count,
-		       upl.is_like as user_vote
-		FROM posts p
-		JOIN users u ON p.user_id = u.id
-		LEFT JOIN comments c ON p.id = c.post_id
-		LEFT JOIN post_likes pl ON p.id = pl.post_id
-		LEFT JOIN post_likes upl ON p.id = upl.post_id AND upl.user_id = ?
-		GROUP BY p.id
-		ORDER BY p.created_at DESC`
-
-	rows, err := h.db.Query(query, userID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var posts []models.Post
-	for rows.Next() {
-		var p models.Post
-		var userVote sql.NullBool
-		err := rows.Scan(&p.ID, &p.Title, &p.Content, &p.UserID,
-			&p.ViewCount, &p.CreatedAt, &p.Username, &p.ReplyCount,
-			&p.LikeCount, &p.DislikeCount, &userVote)
-		if err != nil {
-			return nil, err
-		}
-		p.HasVoted = userVote.Valid
-		if userVote.Valid {
-			p.IsLike = userVote.Bool
-		}
-
-		categories, categoryIDs, categorySlugs, err := h.getCategoriesForPost(p.ID)
-		if err != nil {
-			return nil, err
-		}
-		p.Categories = categories
-		p.CategoryIDs = categoryIDs
-		p.CategorySlugs = categorySlugs
-
-		posts = append(posts, p)
-	}
-	return posts, nil
-}
-
-func (h *ForumHandler) getCategoryBySlug(slug string) (*models.Category, error) {
-	query := `SELECT id, name, description, slug, created_at FROM categories WHERE slug = ?`
-	var c models.Category
-	err := h.db.QueryRow(query, slug).Scan(&c.ID, &c.Name, &c.Description, &c.Slug, &c.CreatedAt)
-	if err != nil {
-		return nil, err
-	}
-	return &c, nil
-}
-
-// getPostsByCategory retrieves posts in a specific category
-func (h *ForumHandler) getPostsByCategory(categoryID int, userID int) ([]models.Post, error) {
-	query := `
-		SELECT p.id, p.title, p.content, p.user_id, p.view_count,
-		       p.created_at, u.username,
-		       COUNT(DISTINCT c.id) as reply_count,
-		       COUNT(DISTINCT CASE WHEN pl.is_like = 1 THEN pl.id END) as like_count,
-		       COUNT(DISTINCT CASE WHEN pl.is_like = 0 THEN pl.id END) as dislike_count,
-		       upl.is_like as user_vote
-		FROM posts p
-		JOIN users u ON p.user_id = u.id
-		JOIN post_categories pc ON p.id = pc.post_id
-		LEFT JOIN comments c ON p.id = c.post_id
-		LEFT JOIN post_likes pl ON p.id = pl.post_id
-		LEFT JOIN post_likes upl ON p.id = upl.post_id AND upl.user_id = ?
-		WHERE pc.category_id = ?
-		GROUP BY p.id
-		ORDER BY p.is_pinned DESC, p.created_at DESC`
-
-	rows, err := h.db.Query(query, userID, categoryID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var posts []models.Post
-	for rows.Next() {
-		var p models.Post
-		var userVote sql.NullBool
-		err := rows.Scan(&p.ID, &p.Title, &p.Content, &p.UserID,
-			&p.ViewCount, &p.CreatedAt, &p.Username, &p.ReplyCount,
-			&p.LikeCount, &p.DislikeCount, &userVote)
-		if err != nil {
-			return nil, err
-		}
-		p.HasVoted = userVote.Valid
-		if userVote.Valid {
-			p.IsLike = userVote.Bool
-		}
-
-		categories, categoryIDs, categorySlugs, err := h.getCategoriesForPost(p.ID)
-		if err != nil {
-			return nil, err
-		}
-		p.Categories = categories
-		p.CategoryIDs = categoryIDs
-		p.CategorySlugs = categorySlugs
-
-		posts = append(posts, p)
-	}
-	return posts, nil
-}
-
-func (h *ForumHandler) getPostByID(id int, userID int) (*models.Post, error) {
-	query := `
-		SELECT p.id, p.title, p.content, p.user_id, p.view_count,
-			   p.created_at, u.username,
-			   COALESCE(SUM(CASE WHEN pl.is_like = 1 THEN 1 ELSE 0 END), 0) as like_count,
-			   COALESCE(SUM(CASE WHEN pl.is_like = 0 THEN 1 ELSE 0 END), 0) as dislike_count,
-			   upl.is_like as user_vote
-		FROM posts p
-		JOIN users u ON p.user_id = u.id
-		LEFT JOIN post_likes pl ON p.id = pl.post_id
-		LEFT JOIN post_likes upl ON p.id = upl.post_id AND upl.user_id = ?
-		WHERE p.id = ?
-		GROUP BY p.id`
-
-	var p models.Post
-	var userVote sql.NullBool
-	err := h.db.QueryRow(query, userID, id).Scan(&p.ID, &p.Title, &p.Content, &p.UserID,
-		&p.ViewCount, &p.CreatedAt, &p.Username,
-		&p.LikeCount, &p.DislikeCount, &userVote)
-	if err != nil {
-		return nil, err
-	}
-	p.HasVoted = userVote.Valid
-	if userVote.Valid {
-		p.IsLike = userVote.Bool
-	}
-
-	categories, categoryIDs, categorySlugs, err := h.getCategoriesForPost(p.ID)
-	if err != nil {
-		return nil, err
-	}
-	p.Categories = categories
-	p.CategoryIDs = categoryIDs
-	p.CategorySlugs = categorySlugs
-
-	return &p, nil
-}
-
-// getCommentsByPostID retrieves all comments for a given post
-func (h *ForumHandler) getCommentsByPostID(postID int, userID int) ([]models.Comment, error) {
-	query := `
-		SELECT c.id, c.content, c.user_id, c.post_id, c.parent_id,
-		       c.created_at, u.username,
-		       COALESCE(SUM(CASE WHEN cl.is_like = 1 THEN 1 ELSE 0 END), 0) as like_count,
-		       COALESCE(SUM(CASE WHEN cl.is_like = 0 THEN 1 ELSE 0 END), 0) as dislike_count,
-		       ucl.is_like as user_vote
-		FROM comments c
-		JOIN users u ON c.user_id = u.id
-		LEFT JOIN comment_likes cl ON c.id = cl.comment_id
-		LEFT JOIN comment_likes ucl ON c.id = ucl.comment_id AND ucl.user_id = ?
-		WHERE c.post_id = ?
-		GROUP BY c.id
-		ORDER BY c.created_at ASC`
-
-	rows, err := h.db.Query(query, userID, postID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var comments []models.Comment
-	for rows.Next() {
-		var c models.Comment
-		var userVote sql.NullBool
-		err := rows.Scan(&c.ID, &c.Content, &c.UserID, &c.PostID, &c.ParentID,
-			&c.CreatedAt, &c.Username, &c.LikeCount, &c.DislikeCount, &userVote)
-		if err != nil {
-			return nil, err
-		}
-		c.HasVoted = userVote.Valid
-		if userVote.Valid {
-			c.IsLike = userVote.Bool
-		}
-		comments = append(comments, c)
-	}
-	return comments, nil
-}
-
-func (h *ForumHandler) incrementViewCount(postID int) {
-	query := `UPDATE posts SET view_count = view_count + 1 WHERE id = ?`
-	h.db.Exec(query, postID)
-}
-
-func (h *ForumHandler) createPost(title, content string, userID int, categoryIDs []int) (int64, error) {
-	tx, err := h.db.Begin()
-	if err != nil {
-		return 0, err
-	}
-	defer tx.Rollback()
-
-	query := `
-		INSERT INTO posts (title, content, user_id, created_at, updated_at)
-		VALUES (?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`
-
-	result, err := tx.Exec(query, title, content, userID)
-	if err != nil {
-		return 0, err
+	if errors.Is(err, services.ErrUnverifiedLimit) {
+		RenderError(w, 403, "Email Not Verified", h.unverifiedLimitMessage())
+		return
 	}
-
-	postID, err := result.LastInsertId()
-	if err != nil {
-		return 0, err
+	if parentID != nil && errors.Is(err, store.ErrNotFound) {
+		RenderError(w, 404, "Comment Not Found", "The comment you're replying to doesn't exist.")
+		return
 	}
-
-	if len(categoryIDs) > 0 {
-		stmt, err := tx.Prepare("INSERT INTO post_categories (post_id, category_id) VALUES (?, ?)")
-		if err != nil {
-			return 0, err
-		}
-		defer stmt.Close()
-
-		for _, categoryID := range categoryIDs {
-			if _, err := stmt.Exec(postID, categoryID); err != nil {
-				return 0, err
-			}
-		}
+	if errors.Is(err, services.ErrWrongParent) {
+		RenderError(w, 400, "Bad Request", "The comment you're replying to belongs to a different post.")
+		return
 	}
-
-	if err := tx.Commit(); err != nil {
-		return 0, err
+	if errors.Is(err, services.ErrTooDeep) {
+		RenderError(w, 400, "Bad Request", "This reply chain is nested as deeply as allowed. Reply to an earlier comment instead.")
+		return
 	}
-
-	return postID, nil
-}
-
-// getMyPosts retrieves posts created by a specific user
-func (h *ForumHandler) getMyPosts(userID int) ([]models.Post, error) {
-	query := `
-		SELECT p.id, p.title, p.content, p.user_id, p.view_count, 
-		       p.created_at, u.username,
-		       COUNT(DISTINCT c.id) as reply_count,
-		       COUNT(DISTINCT CASE WHEN pl.is_like = 1 THEN pl.id END) as like_count,
-		       COUNT(DISTINCT CASE WHEN pl.is_like = 0 THEN pl.id END) as dislike_count,
-		       upl.is_like as user_vote
-		FROM posts p
-		JOIN users u ON p.user_id = u.id
-		LEFT JOIN comments c ON p.id = c.post_id
-		LEFT JOIN post_likes pl ON p.id = pl.post_id
-		LEFT JOIN post_likes upl ON p.id = upl.post_id AND upl.user_id = ?
-		WHERE p.user_id = ?
-		GROUP BY p.id
-		ORDER BY p.created_at DESC`
-
-	rows, err := h.db.Query(query, userID, userID)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var posts []models.Post
-	for rows.Next() {
-		var p models.Post
-		var userVote sql.NullBool
-		err := rows.Scan(&p.ID, &p.Title, &p.Content, &p.UserID,
-			&p.ViewCount, &p.CreatedAt, &p.Username, &p.ReplyCount,
-			&p.LikeCount, &p.DislikeCount, &userVote)
-		if err != nil {
-			return nil, err
-		}
-		p.HasVoted = userVote.Valid
-		if userVote.Valid {
-			p.IsLike = userVote.Bool
-		}
-
-		categories, categoryIDs, categorySlugs, err := h.getCategoriesForPost(p.ID)
-		if err != nil {
-			return nil, err
-		}
-		p.Categories = categories
-		p.CategoryIDs = categoryIDs
-		p.CategorySlugs = categorySlugs
-
-		posts = append(posts, p)
-	}
-	return posts, nil
-}
+		log.Printf("Error creating comment: %v", err)
 
-// getLikedPosts retrieves posts that a user has liked
-func (h *ForumHandler) getLikedPosts(userID int) ([]models.Post, error) {
-	query := `
-		SELECT p.id, p.title, p.content, p.user_id, p.view_count, 
-		       p.created_at, u.username,
-		       COUNT(DISTINCT c.id) as reply_count,
-		       COUNT(DISTINCT CASE WHEN pl.is_like = 1 THEN pl.id END) as like_count,
-		       COUNT(DISTINCT CASE WHEN pl.is_like = 0 THEN pl.id END) as dislike_count,
-		       upl.is_like as user_vote
-		FROM posts p
-		JOIN users u ON p.user_id = u.id
-		LEFT JOIN comments c ON p.id = c.post_id
-		LEFT JOIN post_likes pl ON p.id = pl.post_id
-		JOIN post_likes upl ON p.id = upl.post_id AND upl.user_id = ? AND upl.is_like = 1
-		GROUP BY p.id
-		ORDER BY upl.created_at DESC`
-
-	rows, err := h.db.Query(query, userID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var posts []models.Post
-	for rows.Next() {
-		var p models.Post
-		var userVote sql.NullBool
-		err := rows.Scan(&p.ID, &p.Title, &p.Content, &p.UserID,
-			&p.ViewCount, &p.CreatedAt, &p.Username, &p.ReplyCount,
-			&p.LikeCount, &p.DislikeCount, &userVote)
-		if err != nil {
-			return nil, err
-		}
-		p.HasVoted = userVote.Valid
-		if userVote.Valid {
-			p.IsLike = userVote.Bool
+		// ✅ NEW: On database error, also stay on page with error message
+		post, postErr := h.posts.GetPost(postID, user.ID)
+		if postErr != nil {
+			// Fallback to error page if we can't load post
+			RenderError(w, 500, "Internal Server Error", "Error creating comment. Please try again later.")
+			return
 		}
 
-		categories, categoryIDs, categorySlugs, err := h.getCategoriesForPost(p.ID)
+		comments, err := h.comments.ListComments(postID, user.ID)
 		if err != nil {
-			return nil, err
-		}
-		p.Categories = categories
-		p.CategoryIDs = categoryIDs
-		p.CategorySlugs = categorySlugs
-
-		posts = append(posts, p)
-	}
-	return posts, nil
-}
-
-func (h *ForumHandler) getCategoriesForPost(postID int) ([]string, []int, []string, error) {
-	query := `
-		SELECT c.id, c.name, c.slug
-		FROM categories c
-		JOIN post_categories pc ON c.id = pc.category_id
-		WHERE pc.post_id = ?
-		ORDER BY c.name`
-
-	rows, err := h.db.Query(query, postID)
-	if err != nil {
-		return nil, nil, nil, err
-	}
-	defer rows.Close()
-
-	var names []string
-	var ids []int
-	var slugs []string
-	for rows.Next() {
-		var id int
-		var name string
-		var slug string
-		if err := rows.Scan(&id, &name, &slug); err != nil {
-			return nil, nil, nil, err
+			log.Printf("Error loading comments: %v", err)
+			RenderError(w, 500, "Internal Server Error", "Error creating comment. Please try again later.")
+			return
 		}
-		ids = append(ids, id)
-		names = append(names, name)
-		slugs = append(slugs, slug)
-	}
-	return names, ids, slugs, nil
-}
-
-// postExists checks if a post with the given ID exists in the database
-func (h *ForumHandler) postExists(postID int) (bool, error) {
-	var exists int
-	query := `SELECT 1 FROM posts WHERE id = ? LIMIT 1`
-	err := h.db.QueryRow(query, postID).Scan(&exists)
-	if err == sql.ErrNoRows {
-		return false, nil
-	}
-	if err != nil {
-		return false, err
-	}
-	return true, nil
-}
-
-// insertComment inserts a new comment into the database
-func (h *ForumHandler) insertComment(content string, userID, postID int) error {
-	query := `
-		INSERT INTO comments (content, user_id, post_id, created_at, updated_at)
-		VALUES (?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`
-
-	_, err := h.db.Exec(query, content, userID, postID)
-	return err
-}
 
-// getMyPostsByCategory retrieves posts created by a specific user in a specific category
-func (h *ForumHandler) getMyPostsByCategory(userID int, categoryID int) ([]models.Post, error) {
-	query := `
-		SELECT p.id, p.title, p.content, p.user_id, p.view_count,
-		       p.created_at, u.username,
-		       COUNT(DISTINCT c.id) as reply_count,
-		       COUNT(DISTINCT CASE WHEN pl.is_like = 1 THEN pl.id END) as like_count,
-		       COUNT(DISTINCT CASE WHEN pl.is_like = 0 THEN pl.id END) as dislike_count,
-		       upl.is_like as user_vote
-		FROM posts p
-		JOIN users u ON p.user_id = u.id
-		JOIN post_categories pc ON p.id = pc.post_id
-		LEFT JOIN comments c ON p.id = c.post_id
-		LEFT JOIN post_likes pl ON p.id = pl.post_id
-		LEFT JOIN post_likes upl ON p.id = upl.post_id AND upl.user_id = ?
-		WHERE p.user_id = ? AND pc.category_id = ?
-		GROUP BY p.id
-		ORDER BY p.created_at DESC`
-
-	rows, err := h.db.Query(query, userID, userID, categoryID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var posts []models.Post
-	for rows.Next() {
-		var p models.Post
-		var userVote sql.NullBool
-		err := rows.Scan(&p.ID, &p.Title, &p.Content, &p.UserID,
-			&p.ViewCount, &p.CreatedAt, &p.Username, &p.ReplyCount,
-			&p.LikeCount, &p.DislikeCount, &userVote)
-		if err != nil {
-			return nil, err
+		extra := map[string]interface{}{
+			"CommentError":   "Error creating comment. Please try again later.",
+			"CommentContent": content, // Already trimmed at this point
 		}
-		p.HasVoted = userVote.Valid
-		if userVote.Valid {
-			p.IsLike = userVote.Bool
-		}
-
-		categories, categoryIDs, categorySlugs, err := h.getCategoriesForPost(p.ID)
-		if err != nil {
-			return nil, err
+		if parentID != nil {
+			extra["ReplyTo"] = *parentID
 		}
-		p.Categories = categories
-		p.CategoryIDs = categoryIDs
-		p.CategorySlugs = categorySlugs
 
-		posts = append(posts, p)
+		h.renderPost(w, r, post, comments, extra)
+		return
 	}
-	return posts, nil
-}
 
-// getLikedPostsByCategory retrieves posts that a user has liked in a specific category
-func (h *ForumHandler) getLikedPostsByCategory(userID int, categoryID int) ([]models.Post, error) {
-	query := `
-		SELECT p.id, p.title, p.content, p.user_id, p.view_count, 
-		       p.created_at, u.username,
-		       COUNT(DISTINCT c.id) as reply_count,
-		       COUNT(DISTINCT CASE WHEN pl.is_like = 1 THEN pl.id END) as like_count,
-		       COUNT(DISTINCT CASE WHEN pl.is_like = 0 THEN pl.id END) as dislike_count,
-		       upl.is_like as user_vote
-		FROM posts p
-		JOIN users u ON p.user_id = u.id
-		JOIN post_categories pc ON p.id = pc.post_id
-		LEFT JOIN comments c ON p.id = c.post_id
-		LEFT JOIN post_likes pl ON p.id = pl.post_id
-		JOIN post_likes upl ON p.id = upl.post_id AND upl.user_id = ? AND upl.is_like = 1
-		WHERE pc.category_id = ?
-		GROUP BY p.id
-		ORDER BY upl.created_at DESC`
-
-	rows, err := h.db.Query(query, userID, categoryID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var posts []models.Post
-	for rows.Next() {
-		var p models.Post
-		var userVote sql.NullBool
-		err := rows.Scan(&p.ID, &p.Title, &p.Content, &p.UserID,
-			&p.ViewCount, &p.CreatedAt, &p.Username, &p.ReplyCount,
-			&p.LikeCount, &p.DislikeCount, &userVote)
-		if err != nil {
-			return nil, err
-		}
-		p.HasVoted = userVote.Valid
-		if userVote.Valid {
-			p.IsLike = userVote.Bool
-		}
-
-		categories, categoryIDs, categorySlugs, err := h.getCategoriesForPost(p.ID)
-		if err != nil {
-			return nil, err
-		}
-		p.Categories = categories
-		p.CategoryIDs = categoryIDs
-		p.CategorySlugs = categorySlugs
-
-		posts = append(posts, p)
-	}
-	return posts, nil
+	http.Redirect(w, r, fmt.Sprintf("/post/%d", postID), http.StatusSeeOther)
 }
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"strconv"
@@ -9,6 +11,7 @@ import (
 	"forum/internal/middleware"
 	"forum/internal/models"
 	"forum/internal/services"
+	"forum/internal/store"
 )
 
 type LikesHandler struct {
@@ -30,16 +33,73 @@ func (h *LikesHandler) getUserFromContext(r *http.Request) *models.User {
 	return nil
 }
 
+// voteResponse is what the vote endpoints send to clients that accept JSON
+type voteResponse struct {
+	LikeCount    int     `json:"like_count"`
+	DislikeCount int     `json:"dislike_count"`
+	UserVote     *string `json:"user_vote"` // "like", "dislike" or null
+}
+
+// respondVote answers a successful vote: JSON clients get the fresh counts,
+// form posts are redirected back to the thread
+func respondVote(w http.ResponseWriter, r *http.Request, res store.VoteResult, redirect string) {
+	if !middleware.WantsJSON(r) {
+		http.Redirect(w, r, redirect, http.StatusSeeOther)
+		return
+	}
+
+	resp := voteResponse{LikeCount: res.Likes, DislikeCount: res.Dislikes}
+	if res.Vote != nil {
+		vote := models.ReactionDislike
+		if *res.Vote {
+			vote = models.ReactionLike
+		}
+		resp.UserVote = &vote
+	}
+	middleware.WriteJSON(w, http.StatusOK, resp)
+}
+
+// renderVoteError is RenderError for the vote endpoints, answering JSON
+// clients with {"error": message}
+func renderVoteError(w http.ResponseWriter, r *http.Request, statusCode int, title, message string) {
+	if middleware.WantsJSON(r) {
+		middleware.WriteJSON(w, statusCode, map[string]string{"error": message})
+		return
+	}
+	RenderError(w, statusCode, title, message)
+}
+
+// validRedirectPostID checks the post_id a comment form sends to be
+// redirected back to, rendering a 400 page if it is missing or malformed
+func validRedirectPostID(w http.ResponseWriter, postID string) bool {
+	if postID == "" {
+		RenderError(w, 400, "Bad Request", "Missing post ID.")
+		return false
+	}
+
+	postIDInt, err := strconv.Atoi(postID)
+	if err != nil {
+		RenderError(w, 400, "Bad Request", "Invalid post ID format. Must be a number.")
+		return false
+	}
+
+	if postIDInt <= 0 {
+		RenderError(w, 400, "Bad Request", "Post ID must be a positive number.")
+		return false
+	}
+	return true
+}
+
 // LikePost handles POST /post/{id}/like
 func (h *LikesHandler) LikePost(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		RenderError(w, 405, "Method Not Allowed", "This endpoint only accepts POST requests.")
+		renderVoteError(w, r, 405, "Method Not Allowed", "This endpoint only accepts POST requests.")
 		return
 	}
 
 	user := h.getUserFromContext(r)
 	if user == nil {
-		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		middleware.LoginRequired(w, r)
 		return
 	}
 
@@ -47,85 +107,94 @@ func (h *LikesHandler) LikePost(w http.ResponseWriter, r *http.Request) {
 	path := strings.TrimPrefix(r.URL.Path, "/post/")
 	parts := strings.Split(path, "/")
 	if len(parts) < 2 {
-		RenderError(w, 404, "Not Found", "Invalid post URL.")
+		renderVoteError(w, r, 404, "Not Found", "Invalid post URL.")
 		return
 	}
 
 	postID, err := strconv.Atoi(parts[0])
 	if err != nil {
-		RenderError(w, 404, "Not Found", "Invalid post ID.")
+		renderVoteError(w, r, 404, "Not Found", "Invalid post ID.")
 		return
 	}
 
-	err = h.likesService.LikePost(user.ID, postID)
+	res, err := h.likesService.LikePost(user.ID, postID, requestInfo(r))
 	if err != nil {
+		if errors.Is(err, services.ErrLocked) {
+			renderVoteError(w, r, 403, "Thread Locked", "This thread is locked and no longer accepts votes.")
+			return
+		}
+
 		log.Printf("Error liking post: %v", err)
 
-		if strings.Contains(err.Error(), "post not found") {
-			RenderError(w, 404, "Post Not Found", "The post you're trying to like doesn't exist.")
+		if errors.Is(err, store.ErrNotFound) {
+			renderVoteError(w, r, 404, "Post Not Found", "The post you're trying to like doesn't exist.")
 			return
 		}
 
-		RenderError(w, 500, "Internal Server Error", "Error processing like. Please try again.")
+		renderVoteError(w, r, 500, "Internal Server Error", "Error processing like. Please try again.")
 		return
 	}
 
-	// Redirect back to the post
-	http.Redirect(w, r, "/post/"+parts[0], http.StatusSeeOther)
+	respondVote(w, r, res, "/post/"+parts[0])
 }
 
 // DislikePost handles POST /post/{id}/dislike
 func (h *LikesHandler) DislikePost(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		RenderError(w, 405, "Method Not Allowed", "This endpoint only accepts POST requests.")
+		renderVoteError(w, r, 405, "Method Not Allowed", "This endpoint only accepts POST requests.")
 		return
 	}
 
 	user := h.getUserFromContext(r)
 	if user == nil {
-		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		middleware.LoginRequired(w, r)
 		return
 	}
 
 	path := strings.TrimPrefix(r.URL.Path, "/post/")
 	parts := strings.Split(path, "/")
 	if len(parts) < 2 {
-		RenderError(w, 404, "Not Found", "Invalid post URL.")
+		renderVoteError(w, r, 404, "Not Found", "Invalid post URL.")
 		return
 	}
 
 	postID, err := strconv.Atoi(parts[0])
 	if err != nil {
-		RenderError(w, 404, "Not Found", "Invalid post ID.")
+		renderVoteError(w, r, 404, "Not Found", "Invalid post ID.")
 		return
 	}
 
-	err = h.likesService.DislikePost(user.ID, postID)
+	res, err := h.likesService.DislikePost(user.ID, postID, requestInfo(r))
 	if err != nil {
+		if errors.Is(err, services.ErrLocked) {
+			renderVoteError(w, r, 403, "Thread Locked", "This thread is locked and no longer accepts votes.")
+			return
+		}
+
 		log.Printf("Error disliking post: %v", err)
 
-		if strings.Contains(err.Error(), "post not found") {
-			RenderError(w, 404, "Post Not Found", "The post you're trying to dislike doesn't exist.")
+		if errors.Is(err, store.ErrNotFound) {
+			renderVoteError(w, r, 404, "Post Not Found", "The post you're trying to dislike doesn't exist.")
 			return
 		}
 
-		RenderError(w, 500, "Internal Server Error", "Error processing dislike. Please try again.")
+		renderVoteError(w, r, 500, "Internal Server Error", "Error processing dislike. Please try again.")
 		return
 	}
 
-	http.Redirect(w, r, "/post/"+parts[0], http.StatusSeeOther)
+	respondVote(w, r, res, "/post/"+parts[0])
 }
 
 // LikeComment handles POST /comment/{id}/like
 func (h *LikesHandler) LikeComment(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		RenderError(w, 405, "Method Not Allowed", "This endpoint only accepts POST requests.")
+		renderVoteError(w, r, 405, "Method Not Allowed", "This endpoint only accepts POST requests.")
 		return
 	}
 
 	user := h.getUserFromContext(r)
 	if user == nil {
-		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		middleware.LoginRequired(w, r)
 		return
 	}
 
@@ -134,75 +203,67 @@ func (h *LikesHandler) LikeComment(w http.ResponseWriter, r *http.Request) {
 
 	// ✅ FIX 1: Better error message for malformed URL
 	if len(parts) < 2 {
-		RenderError(w, 400, "Bad Request", "Invalid comment URL format. Expected: /comment/{id}/like")
+		renderVoteError(w, r, 400, "Bad Request", "Invalid comment URL format. Expected: /comment/{id}/like")
 		return
 	}
 
 	// ✅ FIX 2: Check for empty comment ID
 	if parts[0] == "" {
-		RenderError(w, 400, "Bad Request", "Comment ID is required.")
+		renderVoteError(w, r, 400, "Bad Request", "Comment ID is required.")
 		return
 	}
 
 	commentID, err := strconv.Atoi(parts[0])
 	if err != nil {
 		// ✅ FIX 3: Change from 404 to 400 for invalid format
-		RenderError(w, 400, "Bad Request", "Invalid comment ID format. Must be a number.")
+		renderVoteError(w, r, 400, "Bad Request", "Invalid comment ID format. Must be a number.")
 		return
 	}
 
 	// ✅ FIX 4: Check for negative/zero IDs
 	if commentID <= 0 {
-		RenderError(w, 400, "Bad Request", "Comment ID must be a positive number.")
+		renderVoteError(w, r, 400, "Bad Request", "Comment ID must be a positive number.")
 		return
 	}
 
-	// Get the post ID to redirect back
+	// Get the post ID to redirect back; JSON clients get no redirect
 	postID := r.FormValue("post_id")
-	if postID == "" {
-		RenderError(w, 400, "Bad Request", "Missing post ID.")
+	if !middleware.WantsJSON(r) && !validRedirectPostID(w, postID) {
 		return
 	}
 
-	// ✅ FIX 5: Validate post ID format
-	postIDInt, err := strconv.Atoi(postID)
+	res, err := h.likesService.LikeComment(user.ID, commentID, requestInfo(r))
 	if err != nil {
-		RenderError(w, 400, "Bad Request", "Invalid post ID format. Must be a number.")
-		return
-	}
-
-	if postIDInt <= 0 {
-		RenderError(w, 400, "Bad Request", "Post ID must be a positive number.")
-		return
-	}
+		if errors.Is(err, services.ErrLocked) {
+			renderVoteError(w, r, 403, "Thread Locked", "This thread is locked and no longer accepts votes.")
+			return
+		}
 
-	err = h.likesService.LikeComment(user.ID, commentID)
-	if err != nil {
 		log.Printf("Error liking comment: %v", err)
 
-		if strings.Contains(err.Error(), "comment not found") {
+		if errors.Is(err, store.ErrNotFound) {
 			// ✅ CORRECT: 404 for missing resource (this is fine as-is)
-			RenderError(w, 404, "Comment Not Found", "The comment you're trying to like doesn't exist.")
+			renderVoteError(w, r, 404, "Comment Not Found", "The comment you're trying to like doesn't exist.")
 			return
 		}
 
-		RenderError(w, 500, "Internal Server Error", "Error processing like. Please try again.")
+		renderVoteError(w, r, 500, "Internal Server Error", "Error processing like. Please try again.")
 		return
 	}
 
-	http.Redirect(w, r, "/post/"+postID, http.StatusSeeOther)
+	respondVote(w, r, res, "/post/"+postID)
 }
 
 // DislikeComment handles POST /comment/{id}/dislike
 func (h *LikesHandler) DislikeComment(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		RenderError(w, 405, "Method Not Allowed", "This endpoint only accepts POST requests.")
+		renderVoteError(w, r, 405, "Method Not Allowed", "This endpoint only accepts POST requests.")
 		return
 	}
 
 	user := h.getUserFromContext(r)
 	if user == nil {
-		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		middleware.LoginRequired(w, r)
 		return
 	}
 
@@ -211,60 +272,158 @@ func (h *LikesHandler) DislikeComment(w http.ResponseWriter, r *http.Request) {
 
 	// ✅ FIX 1: Better error message for malformed URL
 	if len(parts) < 2 {
-		RenderError(w, 400, "Bad Request", "Invalid comment URL format. Expected: /comment/{id}/dislike")
+		renderVoteError(w, r, 400, "Bad Request", "Invalid comment URL format. Expected: /comment/{id}/dislike")
 		return
 	}
 
 	// ✅ FIX 2: Check for empty comment ID
 	if parts[0] == "" {
-		RenderError(w, 400, "Bad Request", "Comment ID is required.")
+		renderVoteError(w, r, 400, "Bad Request", "Comment ID is required.")
 		return
 	}
 
 	commentID, err := strconv.Atoi(parts[0])
 	if err != nil {
 		// ✅ FIX 3: Change from 404 to 400 for invalid format
-		RenderError(w, 400, "Bad Request", "Invalid comment ID format. Must be a number.")
+		renderVoteError(w, r, 400, "Bad Request", "Invalid comment ID format. Must be a number.")
 		return
 	}
 
 	// ✅ FIX 4: Check for negative/zero IDs
 	if commentID <= 0 {
-		RenderError(w, 400, "Bad Request", "Comment ID must be a positive number.")
+		renderVoteError(w, r, 400, "Bad Request", "Comment ID must be a positive number.")
 		return
 	}
 
+	// Get the post ID to redirect back; JSON clients get no redirect
 	postID := r.FormValue("post_id")
-	if postID == "" {
-		RenderError(w, 400, "Bad Request", "Missing post ID.")
+	if !middleware.WantsJSON(r) && !validRedirectPostID(w, postID) {
 		return
 	}
 
-	// ✅ FIX 5: Validate post ID format
-	postIDInt, err := strconv.Atoi(postID)
+	res, err := h.likesService.DislikeComment(user.ID, commentID, requestInfo(r))
 	if err != nil {
-		RenderError(w, 400, "Bad Request", "Invalid post ID format. Must be a number.")
+		if errors.Is(err, services.ErrLocked) {
+			renderVoteError(w, r, 403, "Thread Locked", "This thread is locked and no longer accepts votes.")
+			return
+		}
+
+		log.Printf("Error disliking comment: %v", err)
+
+		if errors.Is(err, store.ErrNotFound) {
+			// ✅ CORRECT: 404 for missing resource (this is fine as-is)
+			renderVoteError(w, r, 404, "Comment Not Found", "The comment you're trying to dislike doesn't exist.")
+			return
+		}
+
+		renderVoteError(w, r, 500, "Internal Server Error", "Error processing dislike. Please try again.")
 		return
 	}
 
-	if postIDInt <= 0 {
-		RenderError(w, 400, "Bad Request", "Post ID must be a positive number.")
+	respondVote(w, r, res, "/post/"+postID)
+}
+
+// reactResponse is what the reaction endpoints send to clients that accept JSON
+type reactResponse struct {
+	Reaction string `json:"reaction"`
+	Reacted  bool   `json:"reacted"` // Whether the user now has this reaction
+}
+
+// respondReaction answers a successful reaction like respondVote does
+func respondReaction(w http.ResponseWriter, r *http.Request, name string, reacted bool, redirect string) {
+	if !middleware.WantsJSON(r) {
+		http.Redirect(w, r, redirect, http.StatusSeeOther)
+		return
+	}
+	middleware.WriteJSON(w, http.StatusOK, reactResponse{Reaction: name, Reacted: reacted})
+}
+
+// ReactPost handles POST /post/{id}/react, toggling the reaction named by the
+// reaction field ("like" and "dislike" work like the vote buttons)
+func (h *LikesHandler) ReactPost(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		renderVoteError(w, r, 405, "Method Not Allowed", "This endpoint only accepts POST requests.")
+		return
+	}
+
+	user := h.getUserFromContext(r)
+	if user == nil {
+		middleware.LoginRequired(w, r)
 		return
 	}
 
-	err = h.likesService.DislikeComment(user.ID, commentID)
+	postID, problem := parseActionID(r, "/post/", "Post")
+	if problem != "" {
+		renderVoteError(w, r, 400, "Bad Request", problem)
+		return
+	}
+
+	name := r.FormValue("reaction")
+	reacted, err := h.likesService.ReactToPost(user.ID, postID, name, requestInfo(r))
 	if err != nil {
-		log.Printf("Error disliking comment: %v", err)
+		switch {
+		case errors.Is(err, services.ErrUnknownReaction):
+			renderVoteError(w, r, 400, "Bad Request", "Unknown reaction.")
+		case errors.Is(err, services.ErrLocked):
+			renderVoteError(w, r, 403, "Thread Locked", "This thread is locked and no longer accepts reactions.")
+		case errors.Is(err, store.ErrNotFound):
+			renderVoteError(w, r, 404, "Post Not Found", "The post you're trying to react to doesn't exist.")
+		default:
+			log.Printf("Error reacting to post: %v", err)
+			renderVoteError(w, r, 500, "Internal Server Error", "Error processing reaction. Please try again.")
+		}
+		return
+	}
 
-		if strings.Contains(err.Error(), "comment not found") {
-			// ✅ CORRECT: 404 for missing resource (this is fine as-is)
-			RenderError(w, 404, "Comment Not Found", "The comment you're trying to dislike doesn't exist.")
+	respondReaction(w, r, name, reacted, "/post/"+strconv.Itoa(postID))
+}
+
+// ReactComment handles POST /comment/{id}/react; post_id says where to go
+// back to and is only needed when the answer is a redirect
+func (h *LikesHandler) ReactComment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		renderVoteError(w, r, 405, "Method Not Allowed", "This endpoint only accepts POST requests.")
+		return
+	}
+
+	user := h.getUserFromContext(r)
+	if user == nil {
+		middleware.LoginRequired(w, r)
+		return
+	}
+
+	commentID, problem := parseActionID(r, "/comment/", "Comment")
+	if problem != "" {
+		renderVoteError(w, r, 400, "Bad Request", problem)
+		return
+	}
+
+	var postID int
+	if !middleware.WantsJSON(r) {
+		var err error
+		postID, err = strconv.Atoi(r.FormValue("post_id"))
+		if err != nil || postID <= 0 {
+			RenderError(w, 400, "Bad Request", "Missing or invalid post ID.")
 			return
 		}
+	}
 
-		RenderError(w, 500, "Internal Server Error", "Error processing dislike. Please try again.")
+	name := r.FormValue("reaction")
+	reacted, err := h.likesService.ReactToComment(user.ID, commentID, name, requestInfo(r))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrUnknownReaction):
+			renderVoteError(w, r, 400, "Bad Request", "Unknown reaction.")
+		case errors.Is(err, services.ErrLocked):
+			renderVoteError(w, r, 403, "Thread Locked", "This thread is locked and no longer accepts reactions.")
+		case errors.Is(err, store.ErrNotFound):
+			renderVoteError(w, r, 404, "Comment Not Found", "The comment you're trying to react to doesn't exist.")
+		default:
+			log.Printf("Error reacting to comment: %v", err)
+			renderVoteError(w, r, 500, "Internal Server Error", "Error processing reaction. Please try again.")
+		}
 		return
 	}
 
-	http.Redirect(w, r, "/post/"+postID, http.StatusSeeOther)
+	respondReaction(w, r, name, reacted, fmt.Sprintf("/post/%d#comment-%d", postID, commentID))
 }
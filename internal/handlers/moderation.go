@@ -0,0 +1,388 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"forum/internal/models"
+	"forum/internal/services"
+	"forum/internal/store"
+)
+
+// DeletedPlaceholder replaces the text of deleted posts and comments
+const DeletedPlaceholder = "[deleted]"
+
+type ModerationHandler struct {
+	forum      *ForumHandler // Stores and template helpers
+	moderation *services.ModerationService
+}
+
+func NewModerationHandler(forum *ForumHandler, moderation *services.ModerationService) *ModerationHandler {
+	return &ModerationHandler{
+		forum:      forum,
+		moderation: moderation,
+	}
+}
+
+// hideDeleted blanks deleted posts and comments for everyone but admins,
+// who see the original text so they can decide whether to restore it
+func hideDeleted(post *models.Post, comments []models.Comment, viewer *models.User) {
+	if viewer != nil && viewer.IsAdmin {
+		return
+	}
+	if post != nil && post.IsDeleted() {
+		post.Title = DeletedPlaceholder
+		post.Content = ""
+		post.Username = ""
+		post.AuthorKarma = 0
+	}
+	for i := range comments {
+		if comments[i].IsDeleted() {
+			comments[i].Content = ""
+			comments[i].Username = ""
+			comments[i].AuthorKarma = 0
+		}
+	}
+}
+
+// localTimePtr is toLocalTime for optional times such as DeletedAt
+func localTimePtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	local := toLocalTime(*t)
+	return &local
+}
+
+// actionID reads the ID from /post/{id}/action or /comment/{id}/action,
+// rendering a 400 page and returning false if it is malformed
+func actionID(w http.ResponseWriter, r *http.Request, prefix, kind string) (int, bool) {
+	id, problem := parseActionID(r, prefix, kind)
+	if problem != "" {
+		RenderError(w, 400, "Bad Request", problem)
+		return 0, false
+	}
+	return id, true
+}
+
+// parseActionID is actionID without the response; it returns what is wrong
+// with the ID, or "" if it is fine
+func parseActionID(r *http.Request, prefix, kind string) (int, string) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, prefix), "/")
+	if len(parts) != 2 || parts[0] == "" {
+		return 0, fmt.Sprintf("%s ID is required.", kind)
+	}
+
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, fmt.Sprintf("Invalid %s ID format. Must be a number.", strings.ToLower(kind))
+	}
+	if id <= 0 {
+		return 0, fmt.Sprintf("%s ID must be a positive number.", kind)
+	}
+	return id, ""
+}
+
+// renderModerationError maps service errors to error pages
+func renderModerationError(w http.ResponseWriter, err error, kind, action string) {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		RenderError(w, 404, kind+" Not Found",
+			fmt.Sprintf("The %s you're trying to %s doesn't exist.", strings.ToLower(kind), action))
+	case errors.Is(err, services.ErrForbidden):
+		RenderError(w, 403, "Forbidden",
+			fmt.Sprintf("You don't have permission to %s this %s.", action, strings.ToLower(kind)))
+	default:
+		log.Printf("Error trying to %s %s: %v", action, strings.ToLower(kind), err)
+		RenderError(w, 500, "Internal Server Error",
+			fmt.Sprintf("Error trying to %s the %s. Please try again later.", action, strings.ToLower(kind)))
+	}
+}
+
+// DeletePost handles POST /post/{id}/delete (author or admin)
+func (h *ModerationHandler) DeletePost(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		RenderError(w, 405, "Method Not Allowed", "This endpoint only accepts POST requests.")
+		return
+	}
+
+	user := h.forum.getUserFromContext(r)
+	if user == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	postID, ok := actionID(w, r, "/post/", "Post")
+	if !ok {
+		return
+	}
+
+	if err := h.moderation.DeletePost(user, postID, requestInfo(r)); err != nil {
+		renderModerationError(w, err, "Post", "delete")
+		return
+	}
+
+	// Admins stay on the post, where they can undo the deletion
+	if user.IsAdmin {
+		http.Redirect(w, r, fmt.Sprintf("/post/%d", postID), http.StatusSeeOther)
+		return
+	}
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// RestorePost handles POST /post/{id}/restore (admin only)
+func (h *ModerationHandler) RestorePost(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		RenderError(w, 405, "Method Not Allowed", "This endpoint only accepts POST requests.")
+		return
+	}
+
+	user := h.forum.getUserFromContext(r)
+	if user == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	postID, ok := actionID(w, r, "/post/", "Post")
+	if !ok {
+		return
+	}
+
+	if err := h.moderation.RestorePost(user, postID, requestInfo(r)); err != nil {
+		renderModerationError(w, err, "Post", "restore")
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/post/%d", postID), http.StatusSeeOther)
+}
+
+// DeleteComment handles POST /comment/{id}/delete (author or admin)
+func (h *ModerationHandler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		RenderError(w, 405, "Method Not Allowed", "This endpoint only accepts POST requests.")
+		return
+	}
+
+	user := h.forum.getUserFromContext(r)
+	if user == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	commentID, ok := actionID(w, r, "/comment/", "Comment")
+	if !ok {
+		return
+	}
+
+	comment, err := h.moderation.DeleteComment(user, commentID, requestInfo(r))
+	if err != nil {
+		renderModerationError(w, err, "Comment", "delete")
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/post/%d", comment.PostID), http.StatusSeeOther)
+}
+
+// RestoreComment handles POST /comment/{id}/restore (admin only)
+func (h *ModerationHandler) RestoreComment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		RenderError(w, 405, "Method Not Allowed", "This endpoint only accepts POST requests.")
+		return
+	}
+
+	user := h.forum.getUserFromContext(r)
+	if user == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	commentID, ok := actionID(w, r, "/comment/", "Comment")
+	if !ok {
+		return
+	}
+
+	comment, err := h.moderation.RestoreComment(user, commentID, requestInfo(r))
+	if err != nil {
+		renderModerationError(w, err, "Comment", "restore")
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/post/%d", comment.PostID), http.StatusSeeOther)
+}
+
+// PinPost handles POST /post/{id}/pin with scope=category or scope=global (admin only)
+func (h *ModerationHandler) PinPost(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		RenderError(w, 405, "Method Not Allowed", "This endpoint only accepts POST requests.")
+		return
+	}
+
+	user := h.forum.getUserFromContext(r)
+	if user == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	postID, ok := actionID(w, r, "/post/", "Post")
+	if !ok {
+		return
+	}
+
+	var global bool
+	switch r.FormValue("scope") {
+	case "category":
+	case "global":
+		global = true
+	default:
+		RenderError(w, 400, "Bad Request", "Pin scope must be \"category\" or \"global\".")
+		return
+	}
+
+	if err := h.moderation.PinPost(user, postID, global, requestInfo(r)); err != nil {
+		renderModerationError(w, err, "Post", "pin")
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/post/%d", postID), http.StatusSeeOther)
+}
+
+// UnpinPost handles POST /post/{id}/unpin (admin only)
+func (h *ModerationHandler) UnpinPost(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		RenderError(w, 405, "Method Not Allowed", "This endpoint only accepts POST requests.")
+		return
+	}
+
+	user := h.forum.getUserFromContext(r)
+	if user == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	postID, ok := actionID(w, r, "/post/", "Post")
+	if !ok {
+		return
+	}
+
+	if err := h.moderation.UnpinPost(user, postID, requestInfo(r)); err != nil {
+		renderModerationError(w, err, "Post", "unpin")
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/post/%d", postID), http.StatusSeeOther)
+}
+
+// maxLockReasonLength bounds the reason shown on a locked thread
+const maxLockReasonLength = 200
+
+// LockPost handles POST /post/{id}/lock with an optional reason (admin only)
+func (h *ModerationHandler) LockPost(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		RenderError(w, 405, "Method Not Allowed", "This endpoint only accepts POST requests.")
+		return
+	}
+
+	user := h.forum.getUserFromContext(r)
+	if user == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	postID, ok := actionID(w, r, "/post/", "Post")
+	if !ok {
+		return
+	}
+
+	reason := strings.TrimSpace(r.FormValue("reason"))
+	if utf8.RuneCountInString(reason) > maxLockReasonLength {
+		RenderError(w, 400, "Bad Request",
+			fmt.Sprintf("Lock reason must be at most %d characters.", maxLockReasonLength))
+		return
+	}
+
+	if err := h.moderation.LockPost(user, postID, reason, requestInfo(r)); err != nil {
+		renderModerationError(w, err, "Post", "lock")
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/post/%d", postID), http.StatusSeeOther)
+}
+
+// UnlockPost handles POST /post/{id}/unlock (admin only)
+func (h *ModerationHandler) UnlockPost(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		RenderError(w, 405, "Method Not Allowed", "This endpoint only accepts POST requests.")
+		return
+	}
+
+	user := h.forum.getUserFromContext(r)
+	if user == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	postID, ok := actionID(w, r, "/post/", "Post")
+	if !ok {
+		return
+	}
+
+	if err := h.moderation.UnlockPost(user, postID, requestInfo(r)); err != nil {
+		renderModerationError(w, err, "Post", "unlock")
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/post/%d", postID), http.StatusSeeOther)
+}
+
+// DeletedContent handles GET /admin/deleted: deleted posts and comments with restore buttons
+func (h *ModerationHandler) DeletedContent(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		RenderError(w, 405, "Method Not Allowed", "This endpoint only accepts GET requests.")
+		return
+	}
+
+	user := h.forum.getUserFromContext(r)
+	if user == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+	if !user.IsAdmin {
+		RenderError(w, 403, "Forbidden", "Only administrators can view deleted content.")
+		return
+	}
+
+	posts, err := h.forum.posts.ListPosts(store.PostFilter{Deleted: true})
+	if err != nil {
+		log.Printf("Error loading deleted posts: %v", err)
+		RenderError(w, 500, "Internal Server Error", "Error loading deleted posts. Please try again later.")
+		return
+	}
+
+	comments, err := h.forum.comments.ListDeletedComments()
+	if err != nil {
+		log.Printf("Error loading deleted comments: %v", err)
+		RenderError(w, 500, "Internal Server Error", "Error loading deleted comments. Please try again later.")
+		return
+	}
+
+	for i := range posts {
+		posts[i].CreatedAt = toLocalTime(posts[i].CreatedAt)
+		posts[i].DeletedAt = localTimePtr(posts[i].DeletedAt)
+	}
+	for i := range comments {
+		comments[i].CreatedAt = toLocalTime(comments[i].CreatedAt)
+		comments[i].DeletedAt = localTimePtr(comments[i].DeletedAt)
+	}
+
+	data := h.forum.templateData(r, "Deleted Content")
+	data["Posts"] = posts
+	data["Comments"] = comments
+
+	h.forum.renderTemplate(w, "admin_deleted", data)
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"forum/internal/services"
+	"forum/internal/store"
+)
+
+// profileComments is how many recent comments a profile lists
+const profileComments = 10
+
+type ProfileHandler struct {
+	forum *ForumHandler // Stores and template helpers
+	users *services.UserService
+}
+
+func NewProfileHandler(forum *ForumHandler, users *services.UserService) *ProfileHandler {
+	return &ProfileHandler{
+		forum: forum,
+		users: users,
+	}
+}
+
+// Profile handles GET /user/{username}: the user's public details, their
+// posts (paged like "My Posts") and their most recent comments
+func (h *ProfileHandler) Profile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		RenderError(w, 405, "Method Not Allowed", "This endpoint only accepts GET requests.")
+		return
+	}
+
+	username := strings.TrimPrefix(r.URL.Path, "/user/")
+	if username == "" || strings.Contains(username, "/") {
+		RenderError(w, 404, "Not Found", "Invalid user URL.")
+		return
+	}
+
+	profile, err := h.users.GetProfile(username)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			RenderError(w, 404, "User Not Found", "The user you're looking for doesn't exist.")
+			return
+		}
+		log.Printf("Error loading profile of %q: %v", username, err)
+		RenderError(w, 500, "Internal Server Error", "Error loading profile. Please try again later.")
+		return
+	}
+
+	var viewerID int
+	if viewer := h.forum.getUserFromContext(r); viewer != nil {
+		viewerID = viewer.ID
+	}
+
+	page, err := h.forum.pageRequest(r)
+	if err != nil {
+		RenderError(w, 400, "Bad Request", "Invalid page cursor.")
+		return
+	}
+	postPage, err := h.forum.posts.ListPostsPage(store.PostFilter{AuthorID: profile.ID, ViewerID: viewerID}, page)
+	if err != nil {
+		log.Printf("Error loading posts of %q: %v", username, err)
+		RenderError(w, 500, "Internal Server Error", "Error loading posts. Please try again later.")
+		return
+	}
+
+	comments, err := h.forum.comments.ListUserComments(profile.ID, profileComments)
+	if err != nil {
+		log.Printf("Error loading comments of %q: %v", username, err)
+		RenderError(w, 500, "Internal Server Error", "Error loading comments. Please try again later.")
+		return
+	}
+
+	// Titles of the posts the comments were made on
+	var postIDs []int
+	for _, c := range comments {
+		postIDs = append(postIDs, c.PostID)
+	}
+	postTitles := make(map[int]string)
+	if len(postIDs) > 0 {
+		commented, err := h.forum.posts.ListPosts(store.PostFilter{IDs: postIDs})
+		if err != nil {
+			log.Printf("Error loading commented posts of %q: %v", username, err)
+			RenderError(w, 500, "Internal Server Error", "Error loading comments. Please try again later.")
+			return
+		}
+		for _, p := range commented {
+			postTitles[p.ID] = p.Title
+		}
+	}
+
+	profile.JoinedAt = toLocalTime(profile.JoinedAt)
+	posts := postPage.Posts
+	for i := range posts {
+		posts[i].CreatedAt = toLocalTime(posts[i].CreatedAt)
+	}
+	for i := range comments {
+		comments[i].CreatedAt = toLocalTime(comments[i].CreatedAt)
+	}
+
+	data := h.forum.templateData(r, profile.Username)
+	data["Profile"] = profile
+	data["Posts"] = posts
+	data["Comments"] = comments
+	data["PostTitles"] = postTitles
+	data["NextURL"] = pageURL(r, "before", postPage.Next)
+	data["PrevURL"] = pageURL(r, "after", postPage.Prev)
+
+	h.forum.renderTemplate(w, "profile", data)
+}
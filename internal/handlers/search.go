@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"errors"
+	"html"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"forum/internal/models"
+	"forum/internal/store"
+	"forum/internal/validation"
+)
+
+// maxSearchQueryLength limits ?q= (in characters)
+const maxSearchQueryLength = 200
+
+// maxSearchResults is how many posts one search returns
+const maxSearchResults = 50
+
+// SearchResult is a post matched by a search, with its highlighted snippet
+type SearchResult struct {
+	Post      models.Post
+	Snippet   template.HTML
+	InComment bool
+}
+
+// Search handles GET /search?q=...&category=<slug>&author=<username>
+func (h *ForumHandler) Search(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		RenderError(w, 405, "Method Not Allowed", "This endpoint only accepts GET requests.")
+		return
+	}
+
+	categories, err := h.categories.ListCategories()
+	if err != nil {
+		RenderError(w, 500, "Internal Server Error", "Error loading categories. Please try again later.")
+		log.Printf("Error loading categories: %v", err)
+		return
+	}
+
+	params := r.URL.Query()
+	query := strings.TrimSpace(validation.CleanText(params.Get("q")))
+	categorySlug := strings.TrimSpace(params.Get("category"))
+	author := strings.TrimSpace(validation.CleanText(params.Get("author")))
+
+	data := h.templateData(r, "Search")
+	data["Categories"] = categories
+	data["Query"] = query
+	data["SelectedCategory"] = categorySlug
+	data["Author"] = author
+
+	if utf8.RuneCountInString(query) > maxSearchQueryLength {
+		RenderError(w, 400, "Bad Request", "Search query is too long (maximum 200 characters).")
+		return
+	}
+
+	// Empty query: just show the search form
+	if query == "" {
+		h.renderTemplate(w, "search", data)
+		return
+	}
+
+	var categoryID int
+	if categorySlug != "" {
+		category, err := h.categories.GetCategoryBySlug(categorySlug)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				RenderError(w, 400, "Bad Request", "Unknown category.")
+				return
+			}
+			log.Printf("Error loading category: %v", err)
+			RenderError(w, 500, "Internal Server Error", "Error loading category. Please try again later.")
+			return
+		}
+		categoryID = category.ID
+	}
+
+	hits, err := h.search.SearchPosts(store.SearchQuery{
+		Text:       query,
+		CategoryID: categoryID,
+		Author:     author,
+		Limit:      maxSearchResults,
+	})
+	if err != nil {
+		log.Printf("Error searching posts: %v", err)
+		RenderError(w, 500, "Internal Server Error", "Error searching posts. Please try again later.")
+		return
+	}
+
+	user := h.getUserFromContext(r)
+	var userID int
+	if user != nil {
+		userID = user.ID
+	}
+
+	// Load the matched posts the same way the listings do (counts, categories, vote state)
+	ids := make([]int, 0, len(hits))
+	for _, hit := range hits {
+		ids = append(ids, hit.PostID)
+	}
+	posts, err := h.posts.ListPosts(store.PostFilter{IDs: ids, ViewerID: userID})
+	if err != nil {
+		log.Printf("Error loading posts: %v", err)
+		RenderError(w, 500, "Internal Server Error", "Error loading posts. Please try again later.")
+		return
+	}
+
+	postsByID := make(map[int]models.Post, len(posts))
+	for _, p := range posts {
+		p.CreatedAt = toLocalTime(p.CreatedAt)
+		postsByID[p.ID] = p
+	}
+
+	// Keep the ranking order from the search
+	results := make([]SearchResult, 0, len(hits))
+	for _, hit := range hits {
+		p, ok := postsByID[hit.PostID]
+		if !ok {
+			continue
+		}
+		results = append(results, SearchResult{
+			Post:      p,
+			Snippet:   highlightSnippet(hit.Snippet),
+			InComment: hit.InComment,
+		})
+	}
+
+	data["Title"] = "Search: " + query
+	data["Results"] = results
+	data["Searched"] = true
+
+	h.renderTemplate(w, "search", data)
+}
+
+// highlightSnippet HTML-escapes a snippet and turns the store's highlight
+// markers into <mark> tags. Unbalanced markers are closed at the end.
+func highlightSnippet(snippet string) template.HTML {
+	escaped := html.EscapeString(snippet)
+
+	var b strings.Builder
+	open := false
+	for _, r := range escaped {
+		switch string(r) {
+		case store.HighlightStart:
+			if !open {
+				b.WriteString("<mark>")
+				open = true
+			}
+		case store.HighlightEnd:
+			if open {
+				b.WriteString("</mark>")
+				open = false
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+	if open {
+		b.WriteString("</mark>")
+	}
+	return template.HTML(b.String())
+}
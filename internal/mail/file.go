@@ -0,0 +1,61 @@
+package mail
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+// separator ends each message in a file or stream
+const separator = "----\r\n"
+
+// FileMailer appends messages to a file, for development and tests
+type FileMailer struct {
+	mu   sync.Mutex
+	path string
+	from string
+}
+
+func NewFileMailer(path, from string) *FileMailer {
+	return &FileMailer{path: path, from: from}
+}
+
+func (m *FileMailer) Send(msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := writeMessage(f, m.from, msg); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// WriterMailer writes messages to a stream such as os.Stdout
+type WriterMailer struct {
+	mu   sync.Mutex
+	w    io.Writer
+	from string
+}
+
+func NewWriterMailer(w io.Writer, from string) *WriterMailer {
+	return &WriterMailer{w: w, from: from}
+}
+
+func (m *WriterMailer) Send(msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return writeMessage(m.w, m.from, msg)
+}
+
+func writeMessage(w io.Writer, from string, msg Message) error {
+	if err := format(w, from, msg); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, separator)
+	return err
+}
@@ -0,0 +1,35 @@
+// Package mail sends the forum's emails (password reset links and the like)
+// through a Mailer: SMTP in production, a file or stdout in development and
+// tests, where the messages can be read back.
+package mail
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Message is a plain-text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers messages
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// format writes msg as an RFC 5322 message from the given address
+func format(w io.Writer, from string, msg Message) error {
+	// Header values come from the forum itself and validated addresses, but
+	// a stray newline would start a new header
+	clean := strings.NewReplacer("\r", "", "\n", " ").Replace
+	_, err := fmt.Fprintf(w,
+		"From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		clean(from), clean(msg.To), clean(msg.Subject), time.Now().Format(time.RFC1123Z),
+		strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return err
+}
@@ -0,0 +1,35 @@
+package mail
+
+import (
+	"bytes"
+	"net"
+	"net/smtp"
+	"strconv"
+)
+
+// SMTPMailer sends messages through an SMTP server. net/smtp upgrades the
+// connection with STARTTLS when the server offers it, and refuses to send
+// the password over an unencrypted connection except to localhost.
+type SMTPMailer struct {
+	addr string
+	auth smtp.Auth // nil when no username is configured
+	from string
+}
+
+// NewSMTPMailer returns a mailer for host:port. Authentication is skipped
+// when username is empty.
+func NewSMTPMailer(host string, port int, username, password, from string) *SMTPMailer {
+	m := &SMTPMailer{addr: net.JoinHostPort(host, strconv.Itoa(port)), from: from}
+	if username != "" {
+		m.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return m
+}
+
+func (m *SMTPMailer) Send(msg Message) error {
+	var buf bytes.Buffer
+	if err := format(&buf, m.from, msg); err != nil {
+		return err
+	}
+	return smtp.SendMail(m.addr, m.auth, m.from, []string{msg.To}, buf.Bytes())
+}
@@ -0,0 +1,47 @@
+package markdown
+
+import "sync"
+
+// Cache keeps rendered HTML by key (e.g. "post:42"). An entry is reused
+// while its source is unchanged, so edits never show stale HTML.
+type Cache struct {
+	mu      sync.Mutex
+	max     int
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	src  string
+	html string
+}
+
+// NewCache returns a cache holding at most max entries
+func NewCache(max int) *Cache {
+	return &Cache{
+		max:     max,
+		entries: make(map[string]cacheEntry),
+	}
+}
+
+// Render returns the rendered HTML of src, from the cache when possible
+func (c *Cache) Render(key, src string) string {
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && e.src == src {
+		return e.html
+	}
+
+	out := Render(src)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.max {
+		for k := range c.entries { // evict an arbitrary entry
+			delete(c.entries, k)
+			break
+		}
+	}
+	c.entries[key] = cacheEntry{src: src, html: out}
+	return out
+}
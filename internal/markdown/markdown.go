@@ -0,0 +1,403 @@
+// Package markdown renders the Markdown subset allowed in posts and comments:
+// headings, emphasis, links, inline code, fenced code blocks, lists and
+// blockquotes. Raw HTML in the source is escaped rather than passed through,
+// and the result is run through Sanitize before it reaches a page.
+package markdown
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxDepth bounds the nesting of blockquotes, lists, links and emphasis;
+// anything deeper is shown as plain text
+const maxDepth = 8
+
+// Render converts Markdown source to sanitized HTML
+func Render(src string) string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = strings.ReplaceAll(src, "\t", "    ")
+
+	var b strings.Builder
+	blocks(&b, strings.Split(src, "\n"), 0)
+	return Sanitize(b.String())
+}
+
+var (
+	headingRe = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ ]+(.*?))?(?:[ ]+#+)?[ ]*$`)
+	fenceRe   = regexp.MustCompile("^( {0,3})(```+|~~~+)[ ]*([^`\\s]*)[^`]*$")
+	markerRe  = regexp.MustCompile(`^( {0,3})([-*+]|(\d{1,9})[.)])(?:[ ]+|$)`)
+	langRe    = regexp.MustCompile(`^[A-Za-z0-9_+-]+$`)
+	quoteRe   = regexp.MustCompile(`^ {0,3}> ?`)
+)
+
+// blocks renders lines as a sequence of block elements
+func blocks(b *strings.Builder, lines []string, depth int) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case isBlank(line):
+			i++
+		case fenceRe.MatchString(line):
+			i = fencedCode(b, lines, i)
+		case headingRe.MatchString(line):
+			m := headingRe.FindStringSubmatch(line)
+			tag := "h" + strconv.Itoa(len(m[1]))
+			b.WriteString("<" + tag + ">")
+			inline(b, m[2], 0, false)
+			b.WriteString("</" + tag + ">\n")
+			i++
+		case depth < maxDepth && quoteRe.MatchString(line):
+			var inner []string
+			for ; i < len(lines) && quoteRe.MatchString(lines[i]); i++ {
+				inner = append(inner, quoteRe.ReplaceAllString(lines[i], ""))
+			}
+			b.WriteString("<blockquote>\n")
+			blocks(b, inner, depth+1)
+			b.WriteString("</blockquote>\n")
+		case depth < maxDepth && markerRe.MatchString(line):
+			i = list(b, lines, i, depth)
+		default:
+			i = paragraph(b, lines, i)
+		}
+	}
+}
+
+// fencedCode renders a ``` or ~~~ block starting at lines[i] and returns
+// the index of the line after it. An unclosed fence runs to the end.
+func fencedCode(b *strings.Builder, lines []string, i int) int {
+	m := fenceRe.FindStringSubmatch(lines[i])
+	fence := m[2]
+	if langRe.MatchString(m[3]) {
+		b.WriteString(`<pre><code class="language-` + m[3] + `">`)
+	} else {
+		b.WriteString("<pre><code>")
+	}
+
+	var code []string
+	for i++; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+			i++
+			break
+		}
+		code = append(code, lines[i])
+	}
+	b.WriteString(html.EscapeString(strings.Join(code, "\n")))
+	b.WriteString("</code></pre>\n")
+	return i
+}
+
+// listItem is the marker that starts a list item
+type listItem struct {
+	ordered bool
+	start   int // Number of an ordered item
+	width   int // Columns taken by the marker; continuation lines are indented this far
+}
+
+func parseMarker(line string) (listItem, bool) {
+	m := markerRe.FindStringSubmatch(line)
+	if m == nil {
+		return listItem{}, false
+	}
+	item := listItem{width: len(m[0])}
+	if m[3] != "" {
+		item.ordered = true
+		item.start, _ = strconv.Atoi(m[3])
+	}
+	if strings.TrimSpace(line[len(m[0]):]) == "" {
+		item.width = len(m[1]) + len(m[2]) + 1 // empty item: content starts after "- "
+	}
+	return item, true
+}
+
+// list renders a list starting at lines[i] and returns the index of the line after it
+func list(b *strings.Builder, lines []string, i, depth int) int {
+	first, _ := parseMarker(lines[i])
+	tag := "ul"
+	if first.ordered {
+		tag = "ol"
+		if first.start != 1 {
+			b.WriteString(`<ol start="` + strconv.Itoa(first.start) + `">` + "\n")
+		} else {
+			b.WriteString("<ol>\n")
+		}
+	} else {
+		b.WriteString("<ul>\n")
+	}
+
+	for i < len(lines) {
+		m, ok := parseMarker(lines[i])
+		if !ok || m.ordered != first.ordered {
+			break
+		}
+
+		content := []string{lines[i][min(m.width, len(lines[i])):]}
+		tight := true
+		for i++; i < len(lines); i++ {
+			line := lines[i]
+			if isBlank(line) {
+				// A blank line continues the item only if indented text follows
+				j := i
+				for j < len(lines) && isBlank(lines[j]) {
+					j++
+				}
+				if j == len(lines) || indent(lines[j]) < m.width {
+					break
+				}
+				tight = false
+				content = append(content, "")
+				continue
+			}
+			if indent(line) >= m.width {
+				content = append(content, line[m.width:])
+				continue
+			}
+			if startsBlock(line) {
+				break
+			}
+			content = append(content, strings.TrimLeft(line, " ")) // lazy continuation
+		}
+
+		var item strings.Builder
+		blocks(&item, content, depth+1)
+		out := item.String()
+		if tight && strings.HasPrefix(out, "<p>") {
+			// Tight items show their first paragraph without <p>
+			end := strings.Index(out, "</p>\n")
+			out = out[len("<p>"):end] + "\n" + out[end+len("</p>\n"):]
+		}
+		b.WriteString("<li>" + strings.TrimSuffix(out, "\n") + "</li>\n")
+
+		// Blank lines between items keep the list going
+		j := i
+		for j < len(lines) && isBlank(lines[j]) {
+			j++
+		}
+		if j < len(lines) {
+			if next, ok := parseMarker(lines[j]); ok && next.ordered == first.ordered {
+				i = j
+			}
+		}
+	}
+
+	b.WriteString("</" + tag + ">\n")
+	return i
+}
+
+// paragraph renders lines up to the next blank line or block as a paragraph,
+// keeping line breaks, and returns the index of the line after it
+func paragraph(b *strings.Builder, lines []string, i int) int {
+	b.WriteString("<p>")
+	for start := i; i < len(lines); i++ {
+		line := lines[i]
+		if isBlank(line) || (i > start && startsBlock(line)) {
+			break
+		}
+		if i > start {
+			b.WriteString("<br>\n")
+		}
+		inline(b, strings.TrimSpace(line), 0, false)
+	}
+	b.WriteString("</p>\n")
+	return i
+}
+
+// startsBlock reports whether a line starts a block that ends a paragraph
+func startsBlock(line string) bool {
+	return fenceRe.MatchString(line) || headingRe.MatchString(line) ||
+		quoteRe.MatchString(line) || markerRe.MatchString(line)
+}
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+// indent counts a line's leading spaces
+func indent(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// escapable are the characters a backslash makes literal
+const escapable = "\\`*_[]()#+-.!>~"
+
+// inline renders the inline markup of one line of text. inLink stops links
+// from nesting inside link text.
+func inline(b *strings.Builder, s string, depth int, inLink bool) {
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == '\\' && i+1 < len(s) && strings.IndexByte(escapable, s[i+1]) >= 0:
+			b.WriteString(html.EscapeString(s[i+1 : i+2]))
+			i += 2
+			continue
+
+		case c == '`':
+			n := runLength(s, i)
+			if end := closingRun(s, i+n, n); end >= 0 {
+				code := s[i+n : end]
+				if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' {
+					code = code[1 : len(code)-1]
+				}
+				b.WriteString("<code>" + html.EscapeString(code) + "</code>")
+				i = end + n
+			} else {
+				b.WriteString(s[i : i+n])
+				i += n
+			}
+			continue
+
+		case c == '[' && !inLink && depth < maxDepth:
+			if text, href, end, ok := link(s, i); ok {
+				b.WriteString(`<a href="` + html.EscapeString(href) + `">`)
+				inline(b, text, depth+1, true)
+				b.WriteString("</a>")
+				i = end
+				continue
+			}
+
+		case (c == '*' || c == '_') && depth < maxDepth:
+			if tag, inner, end, ok := emphasis(s, i); ok {
+				b.WriteString("<" + tag + ">")
+				inline(b, inner, depth+1, inLink)
+				b.WriteString("</" + tag + ">")
+				i = end
+				continue
+			}
+		}
+
+		b.WriteString(html.EscapeString(s[i : i+1]))
+		i++
+	}
+}
+
+// runLength counts the repeats of the character at s[i]
+func runLength(s string, i int) int {
+	n := 1
+	for i+n < len(s) && s[i+n] == s[i] {
+		n++
+	}
+	return n
+}
+
+// closingRun finds a run of exactly n backticks at or after from
+func closingRun(s string, from, n int) int {
+	for i := from; i < len(s); {
+		if s[i] != '`' {
+			i++
+			continue
+		}
+		run := runLength(s, i)
+		if run == n {
+			return i
+		}
+		i += run
+	}
+	return -1
+}
+
+// link parses [text](url) starting at s[i], returning the index after it.
+// Links with unsafe URLs are not links.
+func link(s string, i int) (text, href string, end int, ok bool) {
+	level := 0
+	j := i
+	for ; j < len(s); j++ {
+		if s[j] == '\\' {
+			j++
+			continue
+		}
+		if s[j] == '[' {
+			level++
+		} else if s[j] == ']' {
+			level--
+			if level == 0 {
+				break
+			}
+		}
+	}
+	if j >= len(s)-1 || s[j+1] != '(' {
+		return "", "", 0, false
+	}
+	close := strings.IndexByte(s[j+2:], ')')
+	if close < 0 {
+		return "", "", 0, false
+	}
+	href = strings.TrimSpace(s[j+2 : j+2+close])
+	if href == "" || strings.ContainsAny(href, " <>") || !SafeURL(href) {
+		return "", "", 0, false
+	}
+	return s[i+1 : j], href, j + 2 + close + 1, true
+}
+
+// emphasis parses *em*, _em_, **strong** or __strong__ starting at s[i]
+func emphasis(s string, i int) (tag, inner string, end int, ok bool) {
+	c := s[i]
+	// Underscores inside words (snake_case) are not emphasis
+	if c == '_' && i > 0 && isWordChar(s[i-1]) {
+		return "", "", 0, false
+	}
+
+	run := runLength(s, i)
+	for _, n := range []int{2, 1} {
+		if run < n || (n == 1 && run > 1) {
+			continue
+		}
+		open := i + n
+		if open >= len(s) || s[open] == ' ' {
+			continue
+		}
+		for k := open + 1; k < len(s); k++ {
+			if s[k] != c {
+				continue
+			}
+			closing := runLength(s, k)
+			if closing < n || (n == 1 && closing > 1) {
+				k += closing - 1 // part of a different delimiter
+				continue
+			}
+			if s[k-1] == ' ' || (c == '_' && k+n < len(s) && isWordChar(s[k+n])) {
+				continue
+			}
+			tag = "em"
+			if n == 2 {
+				tag = "strong"
+			}
+			return tag, s[open:k], k + n, true
+		}
+	}
+	return "", "", 0, false
+}
+
+func isWordChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
+
+// SafeURL reports whether a link target may be used as an href: http, https
+// and mailto URLs, and relative links within the forum
+func SafeURL(href string) bool {
+	u, err := url.Parse(href)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "mailto":
+		return true
+	case "":
+		// url.Parse rejects a colon before the first slash, so this is a path,
+		// unless it names a host: //evil.example, or \\evil.example and
+		// /\evil.example, which browsers read the same way
+		return u.Host == "" && !leavesSite(href)
+	default:
+		return false
+	}
+}
+
+// leavesSite reports whether a relative link starts with two slashes or
+// backslashes. Browsers drop tabs and newlines in URLs first.
+func leavesSite(href string) bool {
+	href = strings.NewReplacer("\t", "", "\n", "", "\r", "").Replace(href)
+	return strings.HasPrefix(href, `\`) ||
+		len(href) > 1 && href[0] == '/' && (href[1] == '/' || href[1] == '\\')
+}
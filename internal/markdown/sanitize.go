@@ -0,0 +1,123 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// allowedTags are the only elements Sanitize keeps. Every other tag is
+// removed, keeping its text.
+var allowedTags = map[string]bool{
+	"p": true, "br": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"strong": true, "em": true, "code": true, "pre": true,
+	"blockquote": true, "ul": true, "ol": true, "li": true, "a": true,
+}
+
+// droppedTags are removed together with their content
+var droppedTags = map[string]bool{
+	"script": true, "style": true, "iframe": true, "object": true,
+	"embed": true, "template": true, "noscript": true, "textarea": true,
+}
+
+var (
+	codeClassRe = regexp.MustCompile(`^language-[A-Za-z0-9_+-]+$`)
+	olStartRe   = regexp.MustCompile(`^[0-9]{1,9}$`)
+)
+
+// linkRel is set on every link: posts are user content
+const linkRel = "nofollow ugc noopener noreferrer"
+
+// Sanitize reduces HTML to the allow-listed tags and attributes: href on
+// links (http, https, mailto or relative), a language-* class on code and
+// start on ordered lists. Unclosed tags are closed, stray end tags dropped,
+// and comments removed.
+func Sanitize(s string) string {
+	z := html.NewTokenizer(strings.NewReader(s))
+	var b strings.Builder
+	var open []string // allowed elements not yet closed
+	skip := 0         // depth inside dropped elements
+
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			for i := len(open) - 1; i >= 0; i-- {
+				b.WriteString("</" + open[i] + ">")
+			}
+			return b.String()
+
+		case html.TextToken:
+			if skip == 0 {
+				b.WriteString(html.EscapeString(string(z.Text())))
+			}
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tok := z.Token()
+			if droppedTags[tok.Data] {
+				if tt == html.StartTagToken {
+					skip++
+				}
+				continue
+			}
+			if skip > 0 || !allowedTags[tok.Data] {
+				continue
+			}
+			b.WriteString("<" + tok.Data + attributes(tok) + ">")
+			if tok.Data != "br" {
+				open = append(open, tok.Data)
+			}
+
+		case html.EndTagToken:
+			tok := z.Token()
+			if droppedTags[tok.Data] {
+				if skip > 0 {
+					skip--
+				}
+				continue
+			}
+			if skip > 0 || !allowedTags[tok.Data] {
+				continue
+			}
+			// Close everything opened since the matching start tag
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] != tok.Data {
+					continue
+				}
+				for j := len(open) - 1; j >= i; j-- {
+					b.WriteString("</" + open[j] + ">")
+				}
+				open = open[:i]
+				break
+			}
+		}
+	}
+}
+
+// attributes returns the allowed attributes of a tag, escaped and ready to write
+func attributes(tok html.Token) string {
+	var b strings.Builder
+	for _, a := range tok.Attr {
+		if a.Namespace != "" {
+			continue
+		}
+		ok := false
+		switch {
+		case tok.Data == "a" && a.Key == "href":
+			ok = SafeURL(a.Val)
+		case tok.Data == "code" && a.Key == "class":
+			ok = codeClassRe.MatchString(a.Val)
+		case tok.Data == "ol" && a.Key == "start":
+			ok = olStartRe.MatchString(a.Val)
+		}
+		if ok {
+			b.WriteString(" " + a.Key + `="` + html.EscapeString(a.Val) + `"`)
+		}
+	}
+	if tok.Data == "a" {
+		b.WriteString(` rel="` + linkRel + `"`)
+	}
+	return b.String()
+}
@@ -2,8 +2,11 @@ package middleware
 
 import (
 	"context"
+	"encoding/json"
 	"log"
+	"mime"
 	"net/http"
+	"strings"
 
 	"forum/internal/services"
 )
@@ -44,6 +47,7 @@ func (m *AuthMiddleware) OptionalAuth(next http.Handler) http.Handler {
 }
 
 // Required authentication - redirects to login if not authenticated
+// (JSON clients get a 401 instead, see LoginRequired)
 func (m *AuthMiddleware) RequireAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		log.Printf("RequireAuth middleware called for: %s", r.URL.Path)
@@ -51,7 +55,7 @@ func (m *AuthMiddleware) RequireAuth(next http.Handler) http.Handler {
 		cookie, err := r.Cookie("session_token")
 		if err != nil {
 			log.Printf("RequireAuth: No session cookie found - %v", err)
-			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			LoginRequired(w, r)
 			return
 		}
 
@@ -65,7 +69,7 @@ func (m *AuthMiddleware) RequireAuth(next http.Handler) http.Handler {
 		user, err := m.sessionService.GetUserByToken(cookie.Value)
 		if err != nil {
 			log.Printf("RequireAuth: Invalid session token - %v", err)
-			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			LoginRequired(w, r)
 			return
 		}
 
@@ -76,3 +80,36 @@ func (m *AuthMiddleware) RequireAuth(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// LoginRequired sends an anonymous request to the login page, or answers a
+// JSON client with 401 and an error object
+func LoginRequired(w http.ResponseWriter, r *http.Request) {
+	if WantsJSON(r) {
+		WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "You need to log in first."})
+		return
+	}
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
+
+// WantsJSON reports whether the client asked for JSON with
+// "Accept: application/json"; browsers submitting forms never do
+func WantsJSON(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(accept, ",") {
+			mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err == nil && mediaType == "application/json" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// WriteJSON writes v as a JSON response with the given status code
+func WriteJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Error writing JSON response: %v", err)
+	}
+}
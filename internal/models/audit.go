@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// AuditEvent records one state-changing action: who did what to which row
+type AuditEvent struct {
+	ID         int       `json:"id" db:"id"`
+	ActorID    *int      `json:"actor_id,omitempty" db:"actor_id"` // nil for anonymous actions
+	Action     string    `json:"action" db:"action"`               // e.g. "post.create", "comment.vote"
+	TargetType string    `json:"target_type" db:"target_type"`     // "user", "post" or "comment"
+	TargetID   int       `json:"target_id" db:"target_id"`
+	IP         string    `json:"ip" db:"ip"`
+	UserAgent  string    `json:"user_agent" db:"user_agent"`
+	Diff       string    `json:"diff" db:"diff"` // JSON object of {"field": [old, new]}
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+
+	// Joined fields
+	ActorName string `json:"actor_name"`
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"database/sql"
+	"html/template"
 	"time"
 )
 
@@ -14,21 +15,46 @@ type Category struct {
 }
 
 type Comment struct {
-	ID        int       `json:"id" db:"id"`
-	Content   string    `json:"content" db:"content"`
-	UserID    int       `json:"user_id" db:"user_id"`
-	PostID    int       `json:"post_id" db:"post_id"`
-	ParentID  *int      `json:"parent_id" db:"parent_id"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID        int        `json:"id" db:"id"`
+	Content   string     `json:"content" db:"content"`
+	UserID    int        `json:"user_id" db:"user_id"`
+	PostID    int        `json:"post_id" db:"post_id"`
+	ParentID  *int       `json:"parent_id" db:"parent_id"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"` // Soft delete: nil while visible
+	DeletedBy *int       `json:"deleted_by,omitempty" db:"deleted_by"`
 
 	// Joined fields
-	Username     string `json:"username" db:"username"`
-	LikeCount    int    `json:"like_count" db:"like_count"`
-	DislikeCount int    `json:"dislike_count" db:"dislike_count"`
+	Username      string `json:"username" db:"username"`
+	AuthorKarma   int    `json:"author_karma" db:"karma"`
+	DeletedByName string `json:"-"` // Username of DeletedBy
+	ReplyCount    int    `json:"reply_count" db:"reply_count"`
+	LikeCount     int    `json:"like_count" db:"like_count"`
+	DislikeCount  int    `json:"dislike_count" db:"dislike_count"`
+
+	// Rendered Markdown of Content (see PostService.RenderContent)
+	ContentHTML template.HTML `json:"-"`
+
+	// Position in the thread (see PostService.ThreadComments)
+	Depth    int  `json:"depth"`     // 0 for top-level comments
+	CanReply bool `json:"can_reply"` // Below the maximum reply depth and not deleted
+
+	// Configured reactions with their counts (see LikesService.AttachReactions)
+	Reactions []ReactionCount `json:"reactions,omitempty"`
 
 	// Current user's vote - exported for templates
 	UserVoteValue sql.NullBool `json:"-"` // Internal field
 	HasVoted      bool         `json:"has_voted"`
 	IsLike        bool         `json:"is_like"`
 }
+
+// IsDeleted reports whether the comment has been soft-deleted
+func (c Comment) IsDeleted() bool {
+	return c.DeletedAt != nil
+}
+
+// IsEdited reports whether the comment's content changed after it was posted
+func (c Comment) IsEdited() bool {
+	return c.UpdatedAt.After(c.CreatedAt)
+}
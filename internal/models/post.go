@@ -2,31 +2,69 @@ package models
 
 import (
 	"database/sql"
+	"html/template"
 	"time"
 )
 
 type Post struct {
-	ID        int       `json:"id" db:"id"`
-	Title     string    `json:"title" db:"title"`
-	Content   string    `json:"content" db:"content"`
-	UserID    int       `json:"user_id" db:"user_id"`
-	IsPinned  bool      `json:"is_pinned" db:"is_pinned"`
-	IsLocked  bool      `json:"is_locked" db:"is_locked"`
-	ViewCount int       `json:"view_count" db:"view_count"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID               int        `json:"id" db:"id"`
+	Title            string     `json:"title" db:"title"`
+	Content          string     `json:"content" db:"content"`
+	UserID           int        `json:"user_id" db:"user_id"`
+	IsPinned         bool       `json:"is_pinned" db:"is_pinned"`                // Pinned to the top of its categories
+	IsPinnedGlobally bool       `json:"is_pinned_globally" db:"pinned_globally"` // Also pinned to the top of the home page
+	IsLocked         bool       `json:"is_locked" db:"is_locked"`                // No new comments or votes
+	ViewCount        int        `json:"view_count" db:"view_count"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at" db:"updated_at"`
+	DeletedAt        *time.Time `json:"deleted_at,omitempty" db:"deleted_at"` // Soft delete: nil while visible
+	DeletedBy        *int       `json:"deleted_by,omitempty" db:"deleted_by"`
+	EditedAt         *time.Time `json:"edited_at,omitempty" db:"edited_at"` // Last edit; nil if never edited
+	LockedAt         *time.Time `json:"locked_at,omitempty" db:"locked_at"`
+	LockedBy         *int       `json:"locked_by,omitempty" db:"locked_by"`
+	LockReason       string     `json:"lock_reason,omitempty" db:"lock_reason"`
 
 	// Joined fields
 	Username      string   `json:"username" db:"username"`
+	AuthorKarma   int      `json:"author_karma" db:"karma"`
+	DeletedByName string   `json:"-"`              // Username of DeletedBy
+	LockedByName  string   `json:"-"`              // Username of LockedBy
 	Categories    []string `json:"categories"`     // Category names (for display)
 	CategoryIDs   []int    `json:"category_ids"`   // Category IDs (for processing)
 	CategorySlugs []string `json:"category_slugs"` // Category slugs (for URLs) - ADD THIS
 	ReplyCount    int      `json:"reply_count" db:"reply_count"`
 	LikeCount     int      `json:"like_count" db:"like_count"`
 	DislikeCount  int      `json:"dislike_count" db:"dislike_count"`
+	PinnedInList  bool     `json:"-"` // Sorted with the pinned posts of the listing it was loaded for
+
+	// Rendered Markdown of Content (see PostService.RenderContent)
+	ContentHTML template.HTML `json:"-"`
+
+	// Configured reactions with their counts (see LikesService.AttachReactions)
+	Reactions []ReactionCount `json:"reactions,omitempty"`
 
 	// Current user's vote - exported for templates
 	UserVoteValue sql.NullBool `json:"-"` // Internal field
 	HasVoted      bool         `json:"has_voted"`
 	IsLike        bool         `json:"is_like"`
 }
+
+// IsDeleted reports whether the post has been soft-deleted
+func (p Post) IsDeleted() bool {
+	return p.DeletedAt != nil
+}
+
+// PostRevision is an earlier version of an edited post. It is written by the
+// edit that replaced it, so EditedBy and CreatedAt describe that edit.
+type PostRevision struct {
+	ID         int       `json:"id" db:"id"`
+	PostID     int       `json:"post_id" db:"post_id"`
+	Title      string    `json:"title" db:"title"`
+	Content    string    `json:"content" db:"content"`
+	Categories string    `json:"categories" db:"categories"` // Category names, comma separated
+	EditedBy   *int      `json:"edited_by,omitempty" db:"edited_by"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+
+	// Joined fields
+	EditedByName string `json:"edited_by_name"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Kinds of rows a reaction can point at
+const (
+	ReactionTargetPost    = "post"
+	ReactionTargetComment = "comment"
+)
+
+// Reactions that make up a user's vote: a user has at most one of the two on
+// a target, and they feed like_count/dislike_count
+const (
+	ReactionLike    = "like"
+	ReactionDislike = "dislike"
+)
+
+// Reaction is one user's reaction to a post or comment
+type Reaction struct {
+	UserID     int       `json:"user_id" db:"user_id"`
+	TargetType string    `json:"target_type" db:"target_type"` // ReactionTargetPost or ReactionTargetComment
+	TargetID   int       `json:"target_id" db:"target_id"`
+	Name       string    `json:"reaction" db:"reaction"` // e.g. "like" or "love"
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+// IsVote reports whether the reaction is a like or dislike
+func (r Reaction) IsVote() bool {
+	return r.Name == ReactionLike || r.Name == ReactionDislike
+}
+
+// ReactionKind is a reaction users can pick, e.g. {"love", "❤️"}
+type ReactionKind struct {
+	Name  string `json:"name"`
+	Emoji string `json:"emoji"`
+}
+
+// ReactionCount is how many users gave a reaction to a post or comment, and
+// whether the current user is one of them
+type ReactionCount struct {
+	ReactionKind
+	Count int  `json:"count"`
+	Mine  bool `json:"mine"`
+}
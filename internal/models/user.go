@@ -10,6 +10,49 @@ type User struct {
 	PasswordHash string    `json:"-" db:"password_hash"`
 	AvatarURL    string    `json:"avatar_url" db:"avatar_url"`
 	IsAdmin      bool      `json:"is_admin" db:"is_admin"`
+	Karma        int       `json:"karma" db:"karma"` // Reputation from votes received (see KarmaWeights)
 	CreatedAt    time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty" db:"email_verified_at"` // nil until the email is confirmed
+}
+
+// EmailVerified reports whether the user confirmed their current email address
+func (u User) EmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// UserStats counts a user's visible posts and comments and the votes they received
+type UserStats struct {
+	PostCount        int `json:"post_count"`
+	CommentCount     int `json:"comment_count"`
+	LikesReceived    int `json:"likes_received"`
+	DislikesReceived int `json:"dislikes_received"`
+}
+
+// UserProfile is the public view of a user shown on /user/{username}.
+// It must only hold what anyone may see - never the email address.
+type UserProfile struct {
+	ID        int       `json:"id"`
+	Username  string    `json:"username"`
+	AvatarURL string    `json:"avatar_url"`
+	IsAdmin   bool      `json:"is_admin"`
+	Karma     int       `json:"karma"`
+	JoinedAt  time.Time `json:"joined_at"`
+	UserStats
+}
+
+// KarmaWeights is how much karma a vote on a post or comment is worth to its
+// author: a like adds the weight, a dislike subtracts it
+type KarmaWeights struct {
+	Post    int
+	Comment int
+}
+
+// Weight returns the weight for a reaction target type
+func (w KarmaWeights) Weight(targetType string) int {
+	if targetType == ReactionTargetPost {
+		return w.Post
+	}
+	return w.Comment
 }
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"encoding/json"
+	"log"
+
+	"forum/internal/models"
+	"forum/internal/store"
+)
+
+// Audit actions, named "<target type>.<verb>"
+const (
+	ActionUserRegister   = "user.register"
+	ActionUserAvatar     = "user.avatar"
+	ActionUserPassword   = "user.password"
+	ActionUserEmail      = "user.email"
+	ActionUserRename     = "user.rename"
+	ActionUserResetReq   = "user.reset_request"
+	ActionUserReset      = "user.password_reset"
+	ActionUserVerifyReq  = "user.verify_request"
+	ActionUserVerify     = "user.verify_email"
+	ActionSessionCreate  = "session.create"
+	ActionSessionDelete  = "session.delete"
+	ActionPostCreate     = "post.create"
+	ActionPostEdit       = "post.edit"
+	ActionPostVote       = "post.vote"
+	ActionPostReact      = "post.react"
+	ActionPostDelete     = "post.delete"
+	ActionPostRestore    = "post.restore"
+	ActionPostPin        = "post.pin"
+	ActionPostUnpin      = "post.unpin"
+	ActionPostLock       = "post.lock"
+	ActionPostUnlock     = "post.unlock"
+	ActionCommentCreate  = "comment.create"
+	ActionCommentEdit    = "comment.edit"
+	ActionCommentVote    = "comment.vote"
+	ActionCommentReact   = "comment.react"
+	ActionCommentDelete  = "comment.delete"
+	ActionCommentRestore = "comment.restore"
+)
+
+// AuditActions lists every action, for the admin filter
+var AuditActions = []string{
+	ActionUserRegister, ActionUserAvatar, ActionUserPassword, ActionUserEmail, ActionUserRename,
+	ActionUserResetReq, ActionUserReset, ActionUserVerifyReq, ActionUserVerify,
+	ActionSessionCreate, ActionSessionDelete,
+	ActionPostCreate, ActionPostEdit, ActionPostVote, ActionPostReact, ActionPostDelete, ActionPostRestore,
+	ActionPostPin, ActionPostUnpin, ActionPostLock, ActionPostUnlock,
+	ActionCommentCreate, ActionCommentEdit, ActionCommentVote, ActionCommentReact,
+	ActionCommentDelete, ActionCommentRestore,
+}
+
+// AuditTargetTypes lists the kinds of rows events point at
+var AuditTargetTypes = []string{"user", "post", "comment"}
+
+// maxUserAgent matches audit_events.user_agent
+const maxUserAgent = 255
+
+// RequestInfo says where a change came from; handlers fill it in
+// from the HTTP request and services record it with each audit event
+type RequestInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// Diff maps each changed field to its [old, new] values;
+// a nil old value means the field was set by a create
+type Diff map[string][2]interface{}
+
+// AuditService writes and reads the audit log
+type AuditService struct {
+	events store.AuditStore
+}
+
+func NewAuditService(events store.AuditStore) *AuditService {
+	return &AuditService{events: events}
+}
+
+// Record appends an event. actorID 0 means an anonymous actor.
+// Failures are logged rather than returned: the change being audited
+// has already been made, and a broken audit log must not block users.
+func (s *AuditService) Record(req RequestInfo, actorID int, action, targetType string, targetID int, diff Diff) {
+	if diff == nil {
+		diff = Diff{}
+	}
+	encoded, err := json.Marshal(diff)
+	if err != nil {
+		log.Printf("Audit: cannot encode %s diff: %v", action, err)
+		encoded = []byte("{}")
+	}
+
+	event := &models.AuditEvent{
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		IP:         req.IP,
+		UserAgent:  truncate(req.UserAgent, maxUserAgent),
+		Diff:       string(encoded),
+	}
+	if actorID > 0 {
+		event.ActorID = &actorID
+	}
+
+	if err := s.events.RecordAuditEvent(event); err != nil {
+		log.Printf("Audit: cannot record %s on %s %d: %v", action, targetType, targetID, err)
+	}
+}
+
+// ListEvents returns matching events, newest first
+func (s *AuditService) ListEvents(filter store.AuditFilter) ([]models.AuditEvent, error) {
+	return s.events.ListAuditEvents(filter)
+}
+
+// truncate shortens s to at most n bytes without splitting a UTF-8 sequence
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && s[n]&0xC0 == 0x80 {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"forum/internal/mail"
+	"forum/internal/models"
+	"forum/internal/store"
+)
+
+// verifyThrottle is the least time between two verification emails to one user
+const verifyThrottle = 5 * time.Minute
+
+var (
+	ErrInvalidVerifyToken = errors.New("this verification link is invalid or has expired")
+	ErrAlreadyVerified    = errors.New("email address is already verified")
+	// ErrVerifyThrottled is returned by Resend within verifyThrottle of the last email
+	ErrVerifyThrottled = errors.New("a verification email was sent recently")
+)
+
+// EmailVerificationService emails signed links that confirm a user owns
+// their email address. A link names the user, the address and an expiry,
+// signed with HMAC-SHA256, so nothing has to be stored for it; changing the
+// address makes older links invalid.
+type EmailVerificationService struct {
+	users   store.UserStore
+	mailer  mail.Mailer
+	audit   *AuditService
+	secret  []byte        // Signing key
+	baseURL string        // Start of the emailed links
+	ttl     time.Duration // How long a link works
+}
+
+func NewEmailVerificationService(users store.UserStore, mailer mail.Mailer, audit *AuditService,
+	secret, baseURL string, ttl time.Duration) *EmailVerificationService {
+	return &EmailVerificationService{
+		users:   users,
+		mailer:  mailer,
+		audit:   audit,
+		secret:  []byte(secret),
+		baseURL: baseURL,
+		ttl:     ttl,
+	}
+}
+
+// Send emails a verification link to the user's current address. It is
+// used after registration and email changes, which are not throttled.
+func (s *EmailVerificationService) Send(user *models.User, req RequestInfo) error {
+	if user.EmailVerified() {
+		return ErrAlreadyVerified
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.ttl)
+	link := s.baseURL + "/verify-email?token=" + url.QueryEscape(s.sign(user.ID, user.Email, expiresAt))
+	err := s.mailer.Send(mail.Message{
+		To:      user.Email,
+		Subject: "Confirm your forum email address",
+		Body: fmt.Sprintf("Hello %s,\n\n"+
+			"Please confirm that this is your email address by opening this link\n"+
+			"within %s:\n\n%s\n\n"+
+			"Until then your account can only post a little. If you didn't create a\n"+
+			"forum account, you can ignore this email.\n", user.Username, describeDuration(s.ttl), link),
+	})
+	if err != nil {
+		return fmt.Errorf("sending verification email: %w", err)
+	}
+	if err := s.users.SetVerificationSent(user.ID, now); err != nil {
+		return err
+	}
+
+	s.audit.Record(req, user.ID, ActionUserVerifyReq, "user", user.ID, Diff{
+		"email": {nil, user.Email},
+	})
+	return nil
+}
+
+// Resend is Send for the user asking again, at most once per verifyThrottle
+func (s *EmailVerificationService) Resend(user *models.User, req RequestInfo) error {
+	if user.EmailVerified() {
+		return ErrAlreadyVerified
+	}
+	last, err := s.users.VerificationSent(user.ID)
+	if err != nil {
+		return err
+	}
+	if !last.IsZero() && time.Since(last) < verifyThrottle {
+		return ErrVerifyThrottled
+	}
+	return s.Send(user, req)
+}
+
+// NextResend is when the user may ask for another email (zero if now)
+func (s *EmailVerificationService) NextResend(userID int) (time.Time, error) {
+	last, err := s.users.VerificationSent(userID)
+	if err != nil || last.IsZero() || time.Since(last) >= verifyThrottle {
+		return time.Time{}, err
+	}
+	return last.Add(verifyThrottle), nil
+}
+
+// Verify checks a link's token and marks the address it was sent to as
+// verified. Opening a link again once verified is not an error.
+func (s *EmailVerificationService) Verify(token string, req RequestInfo) (*models.User, error) {
+	userID, expiresAt, ok := s.parse(token)
+	if !ok || time.Now().After(expiresAt) {
+		return nil, ErrInvalidVerifyToken
+	}
+	user, err := s.users.GetUserByID(userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, ErrInvalidVerifyToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal([]byte(token), []byte(s.sign(user.ID, user.Email, expiresAt))) {
+		return nil, ErrInvalidVerifyToken
+	}
+	if user.EmailVerified() {
+		return user, nil
+	}
+
+	now := time.Now().UTC()
+	err = s.users.VerifyEmail(user.ID, user.Email, now)
+	if errors.Is(err, store.ErrNotFound) {
+		// The address changed since the lookup
+		return nil, ErrInvalidVerifyToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	user.EmailVerifiedAt = &now
+
+	s.audit.Record(req, user.ID, ActionUserVerify, "user", user.ID, Diff{
+		"email_verified_at": {nil, now},
+	})
+	return user, nil
+}
+
+// sign builds a token "<user id>.<expiry unix time>.<signature>", where the
+// signature also covers the email address
+func (s *EmailVerificationService) sign(userID int, email string, expiresAt time.Time) string {
+	payload := strconv.Itoa(userID) + "." + strconv.FormatInt(expiresAt.Unix(), 10)
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte("verify-email:" + payload + ":" + email))
+	return payload + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// parse reads the user ID and expiry of a token; the signature is checked
+// by comparing with sign
+func (s *EmailVerificationService) parse(token string) (int, time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return 0, time.Time{}, false
+	}
+	userID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, time.Time{}, false
+	}
+	unix, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, time.Time{}, false
+	}
+	return userID, time.Unix(unix, 0), true
+}
@@ -1,256 +1,334 @@
 package services
 
 import (
-	"database/sql"
+	"errors"
 	"fmt"
+
+	"forum/internal/models"
+	"forum/internal/store"
 )
 
+// ErrUnknownReaction is returned for a reaction that is not in the configured set
+var ErrUnknownReaction = errors.New("unknown reaction")
+
+// LikesService changes votes and other reactions on posts and comments.
+// Likes and dislikes are the reactions "like" and "dislike"; the others come
+// from the configured reaction set. Every vote change also updates the
+// karma of the post's or comment's author.
 type LikesService struct {
-	db *sql.DB
+	votes     store.VoteStore
+	reactions store.ReactionStore
+	posts     store.PostStore
+	comments  store.CommentStore
+	audit     *AuditService
+	kinds     []models.ReactionKind // Configured reactions besides like and dislike
+	karma     models.KarmaWeights
 }
 
-func NewLikesService(db *sql.DB) *LikesService {
-	return &LikesService{db: db}
+func NewLikesService(votes store.VoteStore, reactions store.ReactionStore, posts store.PostStore, comments store.CommentStore, audit *AuditService, kinds []models.ReactionKind, karma models.KarmaWeights) *LikesService {
+	return &LikesService{
+		votes:     votes,
+		reactions: reactions,
+		posts:     posts,
+		comments:  comments,
+		audit:     audit,
+		kinds:     kinds,
+		karma:     karma,
+	}
 }
 
-// postExists checks if a post with the given ID exists
-func (s *LikesService) postExists(postID int) (bool, error) {
-	var exists int
-	query := `SELECT 1 FROM posts WHERE id = ? LIMIT 1`
-	err := s.db.QueryRow(query, postID).Scan(&exists)
-	if err == sql.ErrNoRows {
-		return false, nil
+// voteDiff describes a vote change for the audit log
+func voteDiff(before, after *bool) Diff {
+	name := func(v *bool) interface{} {
+		switch {
+		case v == nil:
+			return nil
+		case *v:
+			return "like"
+		default:
+			return "dislike"
+		}
 	}
+	return Diff{"vote": {name(before), name(after)}}
+}
+
+// checkUnlocked returns ErrLocked if the post's thread is locked
+func (s *LikesService) checkUnlocked(postID int) error {
+	post, err := s.posts.GetPost(postID, 0)
 	if err != nil {
-		return false, err
+		return err
 	}
-	return true, nil
+	if post.IsLocked {
+		return ErrLocked
+	}
+	return nil
 }
 
-// commentExists checks if a comment with the given ID exists
-func (s *LikesService) commentExists(commentID int) (bool, error) {
-	var exists int
-	query := `SELECT 1 FROM comments WHERE id = ? LIMIT 1`
-	err := s.db.QueryRow(query, commentID).Scan(&exists)
-	if err == sql.ErrNoRows {
-		return false, nil
-	}
+// checkCommentUnlocked returns ErrLocked if the comment's thread is locked
+func (s *LikesService) checkCommentUnlocked(commentID int) error {
+	comment, err := s.comments.GetComment(commentID)
 	if err != nil {
-		return false, err
+		return err
 	}
-	return true, nil
+	return s.checkUnlocked(comment.PostID)
 }
 
-// LikePost toggles or sets a like on a post
-func (s *LikesService) LikePost(userID, postID int) error {
+// votePost toggles a like or dislike on a post
+func (s *LikesService) votePost(userID, postID int, isLike bool, req RequestInfo) (store.VoteResult, error) {
 	// Check if post exists FIRST
-	exists, err := s.postExists(postID)
+	exists, err := s.posts.PostExists(postID)
 	if err != nil {
-		return fmt.Errorf("database error: %w", err)
+		return store.VoteResult{}, fmt.Errorf("database error: %w", err)
 	}
 	if !exists {
-		return fmt.Errorf("post not found")
+		return store.VoteResult{}, store.ErrNotFound
 	}
-
-	var currentVote sql.NullBool
-	checkQuery := `SELECT is_like FROM post_likes WHERE user_id = ? AND post_id = ?`
-	err = s.db.QueryRow(checkQuery, userID, postID).Scan(&currentVote)
-
-	if err == sql.ErrNoRows {
-		// No existing vote - insert new like
-		insertQuery := `INSERT INTO post_likes (user_id, post_id, is_like) VALUES (?, ?, TRUE)`
-		_, err = s.db.Exec(insertQuery, userID, postID)
-		return err
+	if err := s.checkUnlocked(postID); err != nil {
+		return store.VoteResult{}, err
 	}
 
+	res, err := s.votes.TogglePostVote(userID, postID, isLike, s.karma.Weight(models.ReactionTargetPost))
 	if err != nil {
-		return err
-	}
-
-	// If already liked, remove the vote (toggle off)
-	if currentVote.Valid && currentVote.Bool {
-		deleteQuery := `DELETE FROM post_likes WHERE user_id = ? AND post_id = ?`
-		_, err = s.db.Exec(deleteQuery, userID, postID)
-		return err
+		return res, err
 	}
 
-	// If disliked, change to like
-	updateQuery := `UPDATE post_likes SET is_like = TRUE WHERE user_id = ? AND post_id = ?`
-	_, err = s.db.Exec(updateQuery, userID, postID)
-	return err
+	s.audit.Record(req, userID, ActionPostVote, "post", postID, voteDiff(res.Before, res.Vote))
+	return res, nil
 }
 
-// DislikePost toggles or sets a dislike on a post
-func (s *LikesService) DislikePost(userID, postID int) error {
-	// Check if post exists FIRST
-	exists, err := s.postExists(postID)
+// voteComment toggles a like or dislike on a comment
+func (s *LikesService) voteComment(userID, commentID int, isLike bool, req RequestInfo) (store.VoteResult, error) {
+	// Check if comment exists FIRST
+	exists, err := s.comments.CommentExists(commentID)
 	if err != nil {
-		return fmt.Errorf("database error: %w", err)
+		return store.VoteResult{}, fmt.Errorf("database error: %w", err)
 	}
 	if !exists {
-		return fmt.Errorf("post not found")
+		return store.VoteResult{}, store.ErrNotFound
 	}
-
-	var currentVote sql.NullBool
-	checkQuery := `SELECT is_like FROM post_likes WHERE user_id = ? AND post_id = ?`
-	err = s.db.QueryRow(checkQuery, userID, postID).Scan(&currentVote)
-
-	if err == sql.ErrNoRows {
-		// No existing vote - insert new dislike
-		insertQuery := `INSERT INTO post_likes (user_id, post_id, is_like) VALUES (?, ?, FALSE)`
-		_, err = s.db.Exec(insertQuery, userID, postID)
-		return err
+	if err := s.checkCommentUnlocked(commentID); err != nil {
+		return store.VoteResult{}, err
 	}
 
+	res, err := s.votes.ToggleCommentVote(userID, commentID, isLike, s.karma.Weight(models.ReactionTargetComment))
 	if err != nil {
-		return err
+		return res, err
 	}
 
-	// If already disliked, remove the vote (toggle off)
-	if currentVote.Valid && !currentVote.Bool {
-		deleteQuery := `DELETE FROM post_likes WHERE user_id = ? AND post_id = ?`
-		_, err = s.db.Exec(deleteQuery, userID, postID)
-		return err
-	}
+	s.audit.Record(req, userID, ActionCommentVote, "comment", commentID, voteDiff(res.Before, res.Vote))
+	return res, nil
+}
 
-	// If liked, change to dislike
-	updateQuery := `UPDATE post_likes SET is_like = FALSE WHERE user_id = ? AND post_id = ?`
-	_, err = s.db.Exec(updateQuery, userID, postID)
-	return err
+// LikePost toggles or sets a like on a post and returns the new vote and counts
+func (s *LikesService) LikePost(userID, postID int, req RequestInfo) (store.VoteResult, error) {
+	return s.votePost(userID, postID, true, req)
 }
 
-// RemovePostVote removes a user's vote from a post
-func (s *LikesService) RemovePostVote(userID, postID int) error {
-	query := `DELETE FROM post_likes WHERE user_id = ? AND post_id = ?`
-	_, err := s.db.Exec(query, userID, postID)
-	return err
+// DislikePost toggles or sets a dislike on a post and returns the new vote and counts
+func (s *LikesService) DislikePost(userID, postID int, req RequestInfo) (store.VoteResult, error) {
+	return s.votePost(userID, postID, false, req)
 }
 
-// LikeComment toggles or sets a like on a comment
-func (s *LikesService) LikeComment(userID, commentID int) error {
-	// Check if comment exists FIRST
-	exists, err := s.commentExists(commentID)
+// RemovePostVote removes a user's vote from a post
+func (s *LikesService) RemovePostVote(userID, postID int, req RequestInfo) error {
+	current, err := s.votes.GetPostVote(userID, postID)
+	if err != nil || current == nil {
+		return err
+	}
+	if err := s.checkUnlocked(postID); err != nil {
+		return err
+	}
+	res, err := s.votes.ClearPostVote(userID, postID, s.karma.Weight(models.ReactionTargetPost))
 	if err != nil {
-		return fmt.Errorf("database error: %w", err)
+		return err
 	}
-	if !exists {
-		return fmt.Errorf("comment not found")
+	if res.Before != nil {
+		s.audit.Record(req, userID, ActionPostVote, "post", postID, voteDiff(res.Before, nil))
 	}
+	return nil
+}
 
-	var currentVote sql.NullBool
-	checkQuery := `SELECT is_like FROM comment_likes WHERE user_id = ? AND comment_id = ?`
-	err = s.db.QueryRow(checkQuery, userID, commentID).Scan(&currentVote)
+// LikeComment toggles or sets a like on a comment and returns the new vote and counts
+func (s *LikesService) LikeComment(userID, commentID int, req RequestInfo) (store.VoteResult, error) {
+	return s.voteComment(userID, commentID, true, req)
+}
 
-	if err == sql.ErrNoRows {
-		insertQuery := `INSERT INTO comment_likes (user_id, comment_id, is_like) VALUES (?, ?, TRUE)`
-		_, err = s.db.Exec(insertQuery, userID, commentID)
+// DislikeComment toggles or sets a dislike on a comment and returns the new vote and counts
+func (s *LikesService) DislikeComment(userID, commentID int, req RequestInfo) (store.VoteResult, error) {
+	return s.voteComment(userID, commentID, false, req)
+}
+
+// RemoveCommentVote removes a user's vote from a comment
+func (s *LikesService) RemoveCommentVote(userID, commentID int, req RequestInfo) error {
+	current, err := s.votes.GetCommentVote(userID, commentID)
+	if err != nil || current == nil {
 		return err
 	}
-
-	if err != nil {
+	if err := s.checkCommentUnlocked(commentID); err != nil {
 		return err
 	}
-
-	// If already liked, remove the vote (toggle off)
-	if currentVote.Valid && currentVote.Bool {
-		deleteQuery := `DELETE FROM comment_likes WHERE user_id = ? AND comment_id = ?`
-		_, err = s.db.Exec(deleteQuery, userID, commentID)
+	res, err := s.votes.ClearCommentVote(userID, commentID, s.karma.Weight(models.ReactionTargetComment))
+	if err != nil {
 		return err
 	}
+	if res.Before != nil {
+		s.audit.Record(req, userID, ActionCommentVote, "comment", commentID, voteDiff(res.Before, nil))
+	}
+	return nil
+}
 
-	// If disliked, change to like
-	updateQuery := `UPDATE comment_likes SET is_like = TRUE WHERE user_id = ? AND comment_id = ?`
-	_, err = s.db.Exec(updateQuery, userID, commentID)
-	return err
+// GetPostLikeCounts returns like and dislike counts for a post
+func (s *LikesService) GetPostLikeCounts(postID int) (likes int, dislikes int, err error) {
+	return s.votes.PostVoteCounts(postID)
 }
 
-// DislikeComment toggles or sets a dislike on a comment
-func (s *LikesService) DislikeComment(userID, commentID int) error {
-	// Check if comment exists FIRST
-	exists, err := s.commentExists(commentID)
+// GetUserPostVote returns the user's vote on a post (nil = no vote, true = like, false = dislike)
+func (s *LikesService) GetUserPostVote(userID, postID int) (*bool, error) {
+	return s.votes.GetPostVote(userID, postID)
+}
+
+// GetUserCommentVote returns the user's vote on a comment
+func (s *LikesService) GetUserCommentVote(userID, commentID int) (*bool, error) {
+	return s.votes.GetCommentVote(userID, commentID)
+}
+
+// ReactToPost toggles one of the user's reactions on a post and reports
+// whether it is now set. "like" and "dislike" toggle the user's vote like
+// LikePost and DislikePost; any other name must be in the configured set.
+func (s *LikesService) ReactToPost(userID, postID int, name string, req RequestInfo) (bool, error) {
+	switch name {
+	case models.ReactionLike, models.ReactionDislike:
+		res, err := s.votePost(userID, postID, name == models.ReactionLike, req)
+		return res.Vote != nil, err
+	}
+	if !s.isReaction(name) {
+		return false, ErrUnknownReaction
+	}
+
+	exists, err := s.posts.PostExists(postID)
 	if err != nil {
-		return fmt.Errorf("database error: %w", err)
+		return false, fmt.Errorf("database error: %w", err)
 	}
 	if !exists {
-		return fmt.Errorf("comment not found")
+		return false, store.ErrNotFound
+	}
+	if err := s.checkUnlocked(postID); err != nil {
+		return false, err
 	}
 
-	var currentVote sql.NullBool
-	checkQuery := `SELECT is_like FROM comment_likes WHERE user_id = ? AND comment_id = ?`
-	err = s.db.QueryRow(checkQuery, userID, commentID).Scan(&currentVote)
+	set, err := s.reactions.ToggleReaction(models.Reaction{
+		UserID: userID, TargetType: models.ReactionTargetPost, TargetID: postID, Name: name,
+	})
+	if err != nil {
+		return false, err
+	}
 
-	if err == sql.ErrNoRows {
-		insertQuery := `INSERT INTO comment_likes (user_id, comment_id, is_like) VALUES (?, ?, FALSE)`
-		_, err = s.db.Exec(insertQuery, userID, commentID)
-		return err
+	s.audit.Record(req, userID, ActionPostReact, "post", postID, reactionDiff(name, set))
+	return set, nil
+}
+
+// ReactToComment is ReactToPost for a comment
+func (s *LikesService) ReactToComment(userID, commentID int, name string, req RequestInfo) (bool, error) {
+	switch name {
+	case models.ReactionLike, models.ReactionDislike:
+		res, err := s.voteComment(userID, commentID, name == models.ReactionLike, req)
+		return res.Vote != nil, err
+	}
+	if !s.isReaction(name) {
+		return false, ErrUnknownReaction
 	}
 
+	exists, err := s.comments.CommentExists(commentID)
 	if err != nil {
-		return err
+		return false, fmt.Errorf("database error: %w", err)
+	}
+	if !exists {
+		return false, store.ErrNotFound
+	}
+	if err := s.checkCommentUnlocked(commentID); err != nil {
+		return false, err
 	}
 
-	// If already disliked, remove the vote (toggle off)
-	if currentVote.Valid && !currentVote.Bool {
-		deleteQuery := `DELETE FROM comment_likes WHERE user_id = ? AND comment_id = ?`
-		_, err = s.db.Exec(deleteQuery, userID, commentID)
-		return err
+	set, err := s.reactions.ToggleReaction(models.Reaction{
+		UserID: userID, TargetType: models.ReactionTargetComment, TargetID: commentID, Name: name,
+	})
+	if err != nil {
+		return false, err
 	}
 
-	// If liked, change to dislike
-	updateQuery := `UPDATE comment_likes SET is_like = FALSE WHERE user_id = ? AND comment_id = ?`
-	_, err = s.db.Exec(updateQuery, userID, commentID)
-	return err
+	s.audit.Record(req, userID, ActionCommentReact, "comment", commentID, reactionDiff(name, set))
+	return set, nil
 }
 
-// RemoveCommentVote removes a user's vote from a comment
-func (s *LikesService) RemoveCommentVote(userID, commentID int) error {
-	query := `DELETE FROM comment_likes WHERE user_id = ? AND comment_id = ?`
-	_, err := s.db.Exec(query, userID, commentID)
-	return err
+// isReaction reports whether name is in the configured reaction set
+func (s *LikesService) isReaction(name string) bool {
+	for _, k := range s.kinds {
+		if k.Name == name {
+			return true
+		}
+	}
+	return false
 }
 
-// GetPostLikeCounts returns like and dislike counts for a post
-func (s *LikesService) GetPostLikeCounts(postID int) (likes int, dislikes int, err error) {
-	query := `
-		SELECT 
-			SUM(CASE WHEN is_like = TRUE THEN 1 ELSE 0 END) as likes,
-			SUM(CASE WHEN is_like = FALSE THEN 1 ELSE 0 END) as dislikes
-		FROM post_likes
-		WHERE post_id = ?`
-
-	err = s.db.QueryRow(query, postID).Scan(&likes, &dislikes)
-	if err == sql.ErrNoRows {
-		return 0, 0, nil
-	}
-	return likes, dislikes, err
+// reactionDiff describes a reaction being added or removed for the audit log
+func reactionDiff(name string, set bool) Diff {
+	if set {
+		return Diff{"reaction": {nil, name}}
+	}
+	return Diff{"reaction": {name, nil}}
 }
 
-// GetUserPostVote returns the user's vote on a post (nil = no vote, true = like, false = dislike)
-func (s *LikesService) GetUserPostVote(userID, postID int) (*bool, error) {
-	query := `SELECT is_like FROM post_likes WHERE user_id = ? AND post_id = ?`
+// AttachReactions sets Reactions on a post and its comments: every configured
+// reaction in order, with its count and whether the viewer gave it.
+// viewerID 0 is an anonymous visitor.
+func (s *LikesService) AttachReactions(viewerID int, post *models.Post, comments []models.Comment) error {
+	if len(s.kinds) == 0 {
+		return nil
+	}
+
+	counts, mine, err := s.loadReactions(viewerID, models.ReactionTargetPost, []int{post.ID})
+	if err != nil {
+		return err
+	}
+	post.Reactions = s.reactionCounts(counts[post.ID], mine[post.ID])
 
-	var isLike bool
-	err := s.db.QueryRow(query, userID, postID).Scan(&isLike)
-	if err == sql.ErrNoRows {
-		return nil, nil
+	ids := make([]int, len(comments))
+	for i, c := range comments {
+		ids[i] = c.ID
 	}
+	counts, mine, err = s.loadReactions(viewerID, models.ReactionTargetComment, ids)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	for i := range comments {
+		comments[i].Reactions = s.reactionCounts(counts[comments[i].ID], mine[comments[i].ID])
 	}
-	return &isLike, nil
+	return nil
 }
 
-// GetUserCommentVote returns the user's vote on a comment
-func (s *LikesService) GetUserCommentVote(userID, commentID int) (*bool, error) {
-	query := `SELECT is_like FROM comment_likes WHERE user_id = ? AND comment_id = ?`
-
-	var isLike bool
-	err := s.db.QueryRow(query, userID, commentID).Scan(&isLike)
-	if err == sql.ErrNoRows {
-		return nil, nil
+// loadReactions reads the reaction counts of some targets and the viewer's own reactions on them
+func (s *LikesService) loadReactions(viewerID int, targetType string, ids []int) (map[int]map[string]int, map[int][]string, error) {
+	counts, err := s.reactions.ReactionCounts(targetType, ids)
+	if err != nil {
+		return nil, nil, err
 	}
+	mine, err := s.reactions.UserReactions(viewerID, targetType, ids)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	return counts, mine, nil
+}
+
+// reactionCounts lists the configured reactions with one target's counts
+func (s *LikesService) reactionCounts(counts map[string]int, mine []string) []models.ReactionCount {
+	list := make([]models.ReactionCount, len(s.kinds))
+	for i, k := range s.kinds {
+		list[i] = models.ReactionCount{ReactionKind: k, Count: counts[k.Name]}
+		for _, name := range mine {
+			if name == k.Name {
+				list[i].Mine = true
+			}
+		}
 	}
-	return &isLike, nil
+	return list
 }
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"errors"
+
+	"forum/internal/models"
+	"forum/internal/store"
+)
+
+// ErrForbidden is returned when the user may not change the post or comment
+var ErrForbidden = errors.New("permission denied")
+
+// ErrLocked is returned when a comment or vote targets a locked thread
+var ErrLocked = errors.New("thread is locked")
+
+// ModerationService soft-deletes and restores posts and comments, and pins
+// and locks posts. Authors may delete their own posts and comments, admins
+// may delete anything, and only admins can restore, pin or lock. Votes on
+// deleted content don't count towards its author's karma.
+type ModerationService struct {
+	posts    store.PostStore
+	comments store.CommentStore
+	audit    *AuditService
+	karma    models.KarmaWeights
+}
+
+func NewModerationService(posts store.PostStore, comments store.CommentStore, audit *AuditService, karma models.KarmaWeights) *ModerationService {
+	return &ModerationService{
+		posts:    posts,
+		comments: comments,
+		audit:    audit,
+		karma:    karma,
+	}
+}
+
+// deletedDiff records a soft delete (deleted true) or restore (false)
+func deletedDiff(deleted bool) Diff {
+	return Diff{"deleted": {!deleted, deleted}}
+}
+
+// canChange reports whether user may edit or delete content written by authorID
+func canChange(user *models.User, authorID int) bool {
+	return user.IsAdmin || user.ID == authorID
+}
+
+// DeletePost soft-deletes a post. Deleted posts count as not found.
+func (s *ModerationService) DeletePost(user *models.User, postID int, req RequestInfo) error {
+	post, err := s.posts.GetPost(postID, 0)
+	if err != nil {
+		return err
+	}
+	if post.IsDeleted() {
+		return store.ErrNotFound
+	}
+	if !canChange(user, post.UserID) {
+		return ErrForbidden
+	}
+	if err := s.posts.DeletePost(postID, user.ID, s.karma.Post); err != nil {
+		return err
+	}
+	s.audit.Record(req, user.ID, ActionPostDelete, "post", postID, deletedDiff(true))
+	return nil
+}
+
+// RestorePost brings back a deleted post (admins only)
+func (s *ModerationService) RestorePost(user *models.User, postID int, req RequestInfo) error {
+	if !user.IsAdmin {
+		return ErrForbidden
+	}
+	if err := s.posts.RestorePost(postID, s.karma.Post); err != nil {
+		return err
+	}
+	s.audit.Record(req, user.ID, ActionPostRestore, "post", postID, deletedDiff(false))
+	return nil
+}
+
+// DeleteComment soft-deletes a comment and returns it (for the post ID)
+func (s *ModerationService) DeleteComment(user *models.User, commentID int, req RequestInfo) (*models.Comment, error) {
+	comment, err := s.comments.GetComment(commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.IsDeleted() {
+		return nil, store.ErrNotFound
+	}
+	if !canChange(user, comment.UserID) {
+		return nil, ErrForbidden
+	}
+	if err := s.comments.DeleteComment(commentID, user.ID, s.karma.Comment); err != nil {
+		return nil, err
+	}
+	s.audit.Record(req, user.ID, ActionCommentDelete, "comment", commentID, deletedDiff(true))
+	return comment, nil
+}
+
+// RestoreComment brings back a deleted comment (admins only) and returns it
+func (s *ModerationService) RestoreComment(user *models.User, commentID int, req RequestInfo) (*models.Comment, error) {
+	if !user.IsAdmin {
+		return nil, ErrForbidden
+	}
+	comment, err := s.comments.GetComment(commentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.comments.RestoreComment(commentID, s.karma.Comment); err != nil {
+		return nil, err
+	}
+	s.audit.Record(req, user.ID, ActionCommentRestore, "comment", commentID, deletedDiff(false))
+	return comment, nil
+}
+
+// pinName describes a post's pin for the audit log
+func pinName(pinned, global bool) string {
+	switch {
+	case global:
+		return "global"
+	case pinned:
+		return "category"
+	default:
+		return "none"
+	}
+}
+
+// PinPost pins a post to the top of its categories, and of the home page
+// too if global (admins only). Pinning again changes the scope.
+func (s *ModerationService) PinPost(user *models.User, postID int, global bool, req RequestInfo) error {
+	if !user.IsAdmin {
+		return ErrForbidden
+	}
+	post, err := s.posts.GetPost(postID, 0)
+	if err != nil {
+		return err
+	}
+	if err := s.posts.PinPost(postID, global); err != nil {
+		return err
+	}
+	s.audit.Record(req, user.ID, ActionPostPin, "post", postID, Diff{
+		"pin": {pinName(post.IsPinned, post.IsPinnedGlobally), pinName(true, global)},
+	})
+	return nil
+}
+
+// UnpinPost removes a post's pin (admins only)
+func (s *ModerationService) UnpinPost(user *models.User, postID int, req RequestInfo) error {
+	if !user.IsAdmin {
+		return ErrForbidden
+	}
+	post, err := s.posts.GetPost(postID, 0)
+	if err != nil {
+		return err
+	}
+	if err := s.posts.UnpinPost(postID); err != nil {
+		return err
+	}
+	s.audit.Record(req, user.ID, ActionPostUnpin, "post", postID, Diff{
+		"pin": {pinName(post.IsPinned, post.IsPinnedGlobally), pinName(false, false)},
+	})
+	return nil
+}
+
+// LockPost locks a thread so it accepts no new comments or votes (admins only).
+// Locking a locked thread replaces the reason.
+func (s *ModerationService) LockPost(user *models.User, postID int, reason string, req RequestInfo) error {
+	if !user.IsAdmin {
+		return ErrForbidden
+	}
+	post, err := s.posts.GetPost(postID, 0)
+	if err != nil {
+		return err
+	}
+	if err := s.posts.LockPost(postID, user.ID, reason); err != nil {
+		return err
+	}
+	s.audit.Record(req, user.ID, ActionPostLock, "post", postID, Diff{
+		"locked": {post.IsLocked, true},
+		"reason": {post.LockReason, reason},
+	})
+	return nil
+}
+
+// UnlockPost reopens a locked thread (admins only)
+func (s *ModerationService) UnlockPost(user *models.User, postID int, req RequestInfo) error {
+	if !user.IsAdmin {
+		return ErrForbidden
+	}
+	post, err := s.posts.GetPost(postID, 0)
+	if err != nil {
+		return err
+	}
+	if err := s.posts.UnlockPost(postID); err != nil {
+		return err
+	}
+	s.audit.Record(req, user.ID, ActionPostUnlock, "post", postID, Diff{
+		"locked": {post.IsLocked, false},
+		"reason": {post.LockReason, ""},
+	})
+	return nil
+}
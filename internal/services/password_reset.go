@@ -0,0 +1,145 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"forum/internal/mail"
+	"forum/internal/store"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// resetThrottle is the least time between two reset emails to one user
+const resetThrottle = 5 * time.Minute
+
+var ErrInvalidResetToken = errors.New("this password reset link is invalid or has expired")
+
+// PasswordResetService emails one-time links that let a user who forgot
+// their password choose a new one
+type PasswordResetService struct {
+	resets  store.PasswordResetStore
+	users   store.UserStore
+	mailer  mail.Mailer
+	audit   *AuditService
+	baseURL string        // Start of the emailed links
+	ttl     time.Duration // How long a link works
+}
+
+func NewPasswordResetService(resets store.PasswordResetStore, users store.UserStore,
+	mailer mail.Mailer, audit *AuditService, baseURL string, ttl time.Duration) *PasswordResetService {
+	return &PasswordResetService{
+		resets:  resets,
+		users:   users,
+		mailer:  mailer,
+		audit:   audit,
+		baseURL: baseURL,
+		ttl:     ttl,
+	}
+}
+
+// RequestReset emails a reset link to the account with this username or
+// email. Unknown accounts, and requests within resetThrottle of the last
+// one, are ignored without an error so the form never tells who has an
+// account.
+func (s *PasswordResetService) RequestReset(login string, req RequestInfo) error {
+	user, err := s.users.GetUserByLogin(login)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	last, err := s.resets.LastPasswordReset(user.ID)
+	if err != nil {
+		return err
+	}
+	if !last.IsZero() && time.Since(last) < resetThrottle {
+		return nil
+	}
+
+	// The link carries the token; only its hash is stored
+	token, err := randomToken()
+	if err != nil {
+		return err
+	}
+	expiresAt := time.Now().Add(s.ttl)
+	if err := s.resets.CreatePasswordReset(user.ID, hashToken(token), expiresAt); err != nil {
+		return err
+	}
+
+	link := s.baseURL + "/reset-password?token=" + url.QueryEscape(token)
+	err = s.mailer.Send(mail.Message{
+		To:      user.Email,
+		Subject: "Reset your forum password",
+		Body: fmt.Sprintf("Hello %s,\n\n"+
+			"Someone asked to reset the password of your forum account. To choose a new\n"+
+			"password, open this link within %s:\n\n%s\n\n"+
+			"The link works once. If you didn't ask for this, you can ignore this email;\n"+
+			"your password stays the same.\n", user.Username, describeDuration(s.ttl), link),
+	})
+	if err != nil {
+		return fmt.Errorf("sending password reset email: %w", err)
+	}
+
+	// Whoever asked isn't logged in, so the event has no actor
+	s.audit.Record(req, 0, ActionUserResetReq, "user", user.ID, Diff{
+		"expires_at": {nil, expiresAt.UTC()},
+	})
+	return nil
+}
+
+// CheckToken returns ErrInvalidResetToken unless the token can be used
+func (s *PasswordResetService) CheckToken(token string) error {
+	_, err := s.resets.PasswordResetUser(hashToken(token))
+	if errors.Is(err, store.ErrNotFound) {
+		return ErrInvalidResetToken
+	}
+	return err
+}
+
+// ResetPassword uses up the token (and any other unused token of the user),
+// sets the new password and ends every session of the user, in one store
+// transaction. The password must already have passed
+// validation.ValidatePassword.
+func (s *PasswordResetService) ResetPassword(token, password string, req RequestInfo) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	userID, err := s.resets.ResetPassword(hashToken(token), string(hash))
+	if errors.Is(err, store.ErrNotFound) {
+		return ErrInvalidResetToken
+	}
+	if err != nil {
+		return err
+	}
+
+	// As with ChangePassword, nothing about the password goes into the diff
+	s.audit.Record(req, userID, ActionUserReset, "user", userID, nil)
+	return nil
+}
+
+// hashToken is what password_resets.token_hash stores for a token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// describeDuration writes a link lifetime for an email, e.g. "1 hour" or "90 minutes"
+func describeDuration(d time.Duration) string {
+	n, unit := int(d/time.Minute), "minute"
+	if d >= time.Hour && d%time.Hour == 0 {
+		n, unit = int(d/time.Hour), "hour"
+	}
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
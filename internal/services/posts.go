@@ -0,0 +1,368 @@
+package services
+
+import (
+	"errors"
+	"html/template"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"forum/internal/markdown"
+	"forum/internal/models"
+	"forum/internal/store"
+	"forum/internal/textdiff"
+)
+
+// renderCacheSize is the number of posts and comments whose rendered
+// Markdown is kept in memory
+const renderCacheSize = 2000
+
+// unverifiedWindow is the period over which posts and comments of an
+// unverified account are counted against its limit
+const unverifiedWindow = 24 * time.Hour
+
+var (
+	// ErrWrongParent is returned when a reply names a comment on another post
+	ErrWrongParent = errors.New("parent comment belongs to another post")
+	// ErrTooDeep is returned when a reply would nest deeper than allowed
+	ErrTooDeep = errors.New("reply nesting too deep")
+	// ErrUnverifiedLimit is returned when an account that hasn't verified
+	// its email address has posted as much as it may for now
+	ErrUnverifiedLimit = errors.New("posting limit reached until the email address is verified")
+)
+
+// PostService creates, edits and renders posts and comments. Input is
+// validated by the handlers before it gets here.
+type PostService struct {
+	posts           store.PostStore
+	comments        store.CommentStore
+	users           store.UserStore
+	audit           *AuditService
+	rendered        *markdown.Cache
+	maxReplyDepth   int // Deepest level a reply may have; top-level comments are 0
+	unverifiedLimit int // Posts and comments an unverified account may create per unverifiedWindow
+}
+
+func NewPostService(posts store.PostStore, comments store.CommentStore, users store.UserStore, audit *AuditService,
+	maxReplyDepth, unverifiedLimit int) *PostService {
+	return &PostService{
+		posts:           posts,
+		comments:        comments,
+		users:           users,
+		audit:           audit,
+		rendered:        markdown.NewCache(renderCacheSize),
+		maxReplyDepth:   maxReplyDepth,
+		unverifiedLimit: unverifiedLimit,
+	}
+}
+
+// UnverifiedLimit is how many posts and comments an unverified account may
+// create per day
+func (s *PostService) UnverifiedLimit() int {
+	return s.unverifiedLimit
+}
+
+// RenderContent sets ContentHTML on a post and its comments from their
+// Markdown source. Posts with their content hidden (deleted) stay empty.
+func (s *PostService) RenderContent(post *models.Post, comments []models.Comment) {
+	if post.Content != "" {
+		post.ContentHTML = template.HTML(s.rendered.Render("post:"+strconv.Itoa(post.ID), post.Content))
+	}
+	for i := range comments {
+		if c := &comments[i]; c.Content != "" {
+			c.ContentHTML = template.HTML(s.rendered.Render("comment:"+strconv.Itoa(c.ID), c.Content))
+		}
+	}
+}
+
+// CreatePost stores a new post and returns its ID. Unverified accounts
+// over their limit get ErrUnverifiedLimit.
+func (s *PostService) CreatePost(user *models.User, title, content string, categoryIDs []int, req RequestInfo) (int64, error) {
+	if err := s.checkUnverified(user); err != nil {
+		return 0, err
+	}
+	id, err := s.posts.CreatePost(title, content, user.ID, categoryIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	s.audit.Record(req, user.ID, ActionPostCreate, "post", int(id), Diff{
+		"title":      {nil, title},
+		"content":    {nil, content},
+		"categories": {nil, categoryIDs},
+	})
+	return id, nil
+}
+
+// CreateComment stores a new comment on a post and returns its ID. A
+// non-nil parentID makes it a reply to that comment, which must be a visible
+// comment on the same post (ErrNotFound, ErrWrongParent) and not already at
+// the deepest reply level (ErrTooDeep). Locked threads refuse it with
+// ErrLocked, and unverified accounts over their limit with ErrUnverifiedLimit.
+func (s *PostService) CreateComment(user *models.User, postID int, parentID *int, content string, req RequestInfo) (int64, error) {
+	if err := s.checkUnverified(user); err != nil {
+		return 0, err
+	}
+	post, err := s.posts.GetPost(postID, 0)
+	if err != nil {
+		return 0, err
+	}
+	if post.IsLocked {
+		return 0, ErrLocked
+	}
+	if parentID != nil {
+		if err := s.checkParent(postID, *parentID); err != nil {
+			return 0, err
+		}
+	}
+
+	id, err := s.comments.CreateComment(content, user.ID, postID, parentID)
+	if err != nil {
+		return 0, err
+	}
+
+	diff := Diff{
+		"post_id": {nil, postID},
+		"content": {nil, content},
+	}
+	if parentID != nil {
+		diff["parent_id"] = [2]interface{}{nil, *parentID}
+	}
+	s.audit.Record(req, user.ID, ActionCommentCreate, "comment", int(id), diff)
+	return id, nil
+}
+
+// checkUnverified returns ErrUnverifiedLimit if the user hasn't verified
+// their email address and has already created unverifiedLimit posts and
+// comments in the last unverifiedWindow
+func (s *PostService) checkUnverified(user *models.User) error {
+	if user.EmailVerified() {
+		return nil
+	}
+	n, err := s.users.CountContentSince(user.ID, time.Now().Add(-unverifiedWindow))
+	if err != nil {
+		return err
+	}
+	if n >= s.unverifiedLimit {
+		return ErrUnverifiedLimit
+	}
+	return nil
+}
+
+// checkParent returns nil if a new reply may be attached to parentID,
+// walking up the parent chain to find how deep the parent already is
+func (s *PostService) checkParent(postID, parentID int) error {
+	parent, err := s.comments.GetComment(parentID)
+	if err != nil {
+		return err
+	}
+	if parent.IsDeleted() {
+		return store.ErrNotFound
+	}
+	if parent.PostID != postID {
+		return ErrWrongParent
+	}
+
+	depth := 0 // The parent's depth: how many ancestors it has
+	for c := parent; c.ParentID != nil && depth < s.maxReplyDepth; depth++ {
+		if c, err = s.comments.GetComment(*c.ParentID); err != nil {
+			return err
+		}
+	}
+	if depth >= s.maxReplyDepth {
+		return ErrTooDeep
+	}
+	return nil
+}
+
+// ThreadComments orders a post's comments (oldest first) as a thread: each
+// comment is followed by its replies, and Depth says how far to indent it.
+// Replies whose parent is missing from the list are shown at the top level.
+func (s *PostService) ThreadComments(comments []models.Comment) []models.Comment {
+	present := make(map[int]bool, len(comments))
+	for _, c := range comments {
+		present[c.ID] = true
+	}
+	var roots []int
+	replies := make(map[int][]int) // parent ID -> indexes of its replies
+	for i, c := range comments {
+		if c.ParentID != nil && *c.ParentID != c.ID && present[*c.ParentID] {
+			replies[*c.ParentID] = append(replies[*c.ParentID], i)
+		} else {
+			roots = append(roots, i)
+		}
+	}
+
+	threaded := make([]models.Comment, 0, len(comments))
+	var add func(i, depth int)
+	add = func(i, depth int) {
+		c := comments[i]
+		c.Depth = depth
+		c.CanReply = depth < s.maxReplyDepth && !c.IsDeleted()
+		threaded = append(threaded, c)
+		for _, r := range replies[c.ID] {
+			add(r, depth+1)
+		}
+	}
+	for _, i := range roots {
+		add(i, 0)
+	}
+	return threaded
+}
+
+// PostForEdit returns a post the user is allowed to edit, for the edit form
+func (s *PostService) PostForEdit(user *models.User, postID int) (*models.Post, error) {
+	post, err := s.posts.GetPost(postID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if post.IsDeleted() {
+		return nil, store.ErrNotFound
+	}
+	if !canChange(user, post.UserID) {
+		return nil, ErrForbidden
+	}
+	return post, nil
+}
+
+// EditPost replaces a post's title, content and categories, keeping the
+// previous version as a revision. Authors may edit their own posts and
+// admins any post; deleted posts count as not found. Submitting the post
+// unchanged stores nothing.
+func (s *PostService) EditPost(user *models.User, postID int, title, content string, categoryIDs []int, req RequestInfo) error {
+	post, err := s.PostForEdit(user, postID)
+	if err != nil {
+		return err
+	}
+
+	diff := Diff{}
+	if title != post.Title {
+		diff["title"] = [2]interface{}{post.Title, title}
+	}
+	if content != post.Content {
+		diff["content"] = [2]interface{}{post.Content, content}
+	}
+	oldIDs := slices.Sorted(slices.Values(post.CategoryIDs))
+	newIDs := slices.Sorted(slices.Values(categoryIDs))
+	if !slices.Equal(oldIDs, newIDs) {
+		diff["categories"] = [2]interface{}{oldIDs, newIDs}
+	}
+	if len(diff) == 0 {
+		return nil
+	}
+
+	if err := s.posts.UpdatePost(postID, title, content, categoryIDs, user.ID); err != nil {
+		return err
+	}
+	s.audit.Record(req, user.ID, ActionPostEdit, "post", postID, diff)
+	return nil
+}
+
+// CommentForEdit returns a comment the user is allowed to edit, for the edit
+// form. Deleted comments, and comments on deleted posts, count as not found;
+// comments in locked threads are refused with ErrLocked.
+func (s *PostService) CommentForEdit(user *models.User, commentID int) (*models.Comment, error) {
+	comment, err := s.comments.GetComment(commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.IsDeleted() {
+		return nil, store.ErrNotFound
+	}
+	if !canChange(user, comment.UserID) {
+		return nil, ErrForbidden
+	}
+	post, err := s.posts.GetPost(comment.PostID, 0)
+	if err != nil {
+		return nil, err
+	}
+	if post.IsDeleted() {
+		return nil, store.ErrNotFound
+	}
+	if post.IsLocked {
+		return nil, ErrLocked
+	}
+	return comment, nil
+}
+
+// EditComment replaces a comment's content, with the same rules as
+// CommentForEdit. Submitting the comment unchanged stores nothing.
+func (s *PostService) EditComment(user *models.User, commentID int, content string, req RequestInfo) error {
+	comment, err := s.CommentForEdit(user, commentID)
+	if err != nil {
+		return err
+	}
+	if content == comment.Content {
+		return nil
+	}
+
+	if err := s.comments.UpdateComment(commentID, content); err != nil {
+		return err
+	}
+	s.audit.Record(req, user.ID, ActionCommentEdit, "comment", commentID, Diff{
+		"content": {comment.Content, content},
+	})
+	return nil
+}
+
+// PostVersion is one version of a post, with what changed since the version before it
+type PostVersion struct {
+	Number     int
+	Title      string
+	Content    string
+	Categories string
+	Author     string    // Who wrote this version (the poster, then each editor)
+	CreatedAt  time.Time // When this version was written
+
+	PrevTitle         string // Set when the title changed
+	PrevCategories    string // Set when the categories changed
+	TitleChanged      bool
+	CategoriesChanged bool
+	ContentDiff       []textdiff.Line // nil for the first version
+}
+
+// History returns a post and all its versions, newest first
+func (s *PostService) History(postID int) (*models.Post, []PostVersion, error) {
+	post, err := s.posts.GetPost(postID, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	revisions, err := s.posts.ListPostRevisions(postID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// revisions[i] holds version i+1 and was replaced by the edit that wrote version i+2
+	versions := make([]PostVersion, len(revisions)+1)
+	for i, r := range revisions {
+		versions[i] = PostVersion{Title: r.Title, Content: r.Content, Categories: r.Categories}
+	}
+	versions[len(revisions)] = PostVersion{
+		Title:      post.Title,
+		Content:    post.Content,
+		Categories: strings.Join(post.Categories, ", "),
+	}
+
+	for i := range versions {
+		v := &versions[i]
+		v.Number = i + 1
+		if i == 0 {
+			v.Author, v.CreatedAt = post.Username, post.CreatedAt
+			continue
+		}
+
+		edit, prev := revisions[i-1], versions[i-1]
+		v.Author, v.CreatedAt = edit.EditedByName, edit.CreatedAt
+		if v.Title != prev.Title {
+			v.TitleChanged, v.PrevTitle = true, prev.Title
+		}
+		if v.Categories != prev.Categories {
+			v.CategoriesChanged, v.PrevCategories = true, prev.Categories
+		}
+		v.ContentDiff = textdiff.Lines(prev.Content, v.Content)
+	}
+
+	slices.Reverse(versions)
+	return post, versions, nil
+}
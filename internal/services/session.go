@@ -2,94 +2,91 @@ package services
 
 import (
 	"crypto/rand"
-	"database/sql"
 	"encoding/base64"
 	"errors"
 	"time"
 
 	"forum/internal/models"
+	"forum/internal/store"
 )
 
 type SessionService struct {
-	db *sql.DB
+	sessions store.SessionStore
+	audit    *AuditService
 }
 
-func NewSessionService(db *sql.DB) *SessionService {
-	return &SessionService{db: db}
+func NewSessionService(sessions store.SessionStore, audit *AuditService) *SessionService {
+	return &SessionService{sessions: sessions, audit: audit}
 }
 
 // Create a new session (and delete any existing sessions for this user)
-func (s *SessionService) CreateSession(userID int) (string, error) {
+func (s *SessionService) CreateSession(userID int, req RequestInfo) (string, error) {
 	// First, delete any existing sessions for this user
-	deleteQuery := `DELETE FROM sessions WHERE user_id = ?`
-	_, err := s.db.Exec(deleteQuery, userID)
-	if err != nil {
+	if err := s.sessions.DeleteUserSessions(userID); err != nil {
 		return "", err
 	}
 
 	// Generate random session token
-	tokenBytes := make([]byte, 32)
-	if _, err := rand.Read(tokenBytes); err != nil {
+	token, err := randomToken()
+	if err != nil {
 		return "", err
 	}
-	token := base64.URLEncoding.EncodeToString(tokenBytes)
-
-	// Store new session in database
-	query := `
-		INSERT INTO sessions (token, user_id, expires_at, created_at)
-		VALUES (?, ?, ?, CURRENT_TIMESTAMP)`
 
+	// Store new session
 	expiresAt := time.Now().Add(24 * time.Hour) // 24 hour sessions
-	_, err = s.db.Exec(query, token, userID, expiresAt)
-	if err != nil {
+	if err := s.sessions.CreateSession(token, userID, expiresAt); err != nil {
 		return "", err
 	}
 
+	// The token itself is never logged; sessions are audited against their user
+	s.audit.Record(req, userID, ActionSessionCreate, "user", userID, Diff{
+		"expires_at": {nil, expiresAt.UTC()},
+	})
 	return token, nil
 }
 
 // Get user by session token
 func (s *SessionService) GetUserByToken(token string) (*models.User, error) {
-	query := `
-		SELECT u.id, u.uuid, u.username, u.email, u.avatar_url, u.is_admin, u.created_at
-		FROM users u
-		JOIN sessions s ON u.id = s.user_id
-		WHERE s.token = ? AND s.expires_at > CURRENT_TIMESTAMP`
-
-	var user models.User
-	var avatarURL sql.NullString // Use sql.NullString for nullable fields
-
-	err := s.db.QueryRow(query, token).Scan(
-		&user.ID, &user.UUID, &user.Username, &user.Email,
-		&avatarURL, &user.IsAdmin, &user.CreatedAt,
-	)
+	user, err := s.sessions.GetSessionUser(token)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, store.ErrNotFound) {
 			return nil, errors.New("invalid or expired session")
 		}
 		return nil, err
 	}
+	return user, nil
+}
 
-	// Convert sql.NullString to regular string
-	if avatarURL.Valid {
-		user.AvatarURL = avatarURL.String
-	} else {
-		user.AvatarURL = ""
+// Delete session (logout)
+func (s *SessionService) DeleteSession(token string, req RequestInfo) error {
+	// Look the owner up first for the audit log; expired sessions are
+	// deleted without an event
+	user, err := s.sessions.GetSessionUser(token)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return err
 	}
 
-	return &user, nil
-}
+	if err := s.sessions.DeleteSession(token); err != nil {
+		return err
+	}
 
-// Delete session (logout)
-func (s *SessionService) DeleteSession(token string) error {
-	query := `DELETE FROM sessions WHERE token = ?`
-	_, err := s.db.Exec(query, token)
-	return err
+	if user != nil {
+		s.audit.Record(req, user.ID, ActionSessionDelete, "user", user.ID, nil)
+	}
+	return nil
 }
 
 // Clean expired sessions
 func (s *SessionService) CleanExpiredSessions() error {
-	query := `DELETE FROM sessions WHERE expires_at < CURRENT_TIMESTAMP`
-	_, err := s.db.Exec(query)
-	return err
+	return s.sessions.DeleteExpiredSessions()
+}
+
+// randomToken returns 32 random bytes, base64url-encoded, for session
+// cookies and emailed links
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(b), nil
 }
@@ -1,73 +1,86 @@
 package services
 
 import (
-	"database/sql"
 	"errors"
-	"strings"
+	"time"
 
+	"forum/internal/avatar"
 	"forum/internal/models"
+	"forum/internal/store"
 
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
+var (
+	ErrWrongPassword = errors.New("current password is incorrect")
+	// ErrUsernameReserved is returned for a name another user gave up
+	// within the reserve period
+	ErrUsernameReserved = errors.New("username is reserved: another user changed away from it recently")
+	// ErrRenameTooSoon is returned when the user renamed themselves within
+	// the rename interval; NextUsernameChange says when they can again
+	ErrRenameTooSoon = errors.New("username was changed too recently")
+)
+
 type UserService struct {
-	db *sql.DB
+	users    store.UserStore
+	sessions store.SessionStore
+	audit    *AuditService
+	avatars  *avatar.Store
+
+	renameInterval time.Duration // Time between username changes
+	reservePeriod  time.Duration // How long an old username stays with its owner
 }
 
-func NewUserService(db *sql.DB) *UserService {
-	return &UserService{db: db}
+func NewUserService(users store.UserStore, sessions store.SessionStore, audit *AuditService, avatars *avatar.Store,
+	renameInterval, reservePeriod time.Duration) *UserService {
+	return &UserService{
+		users:          users,
+		sessions:       sessions,
+		audit:          audit,
+		avatars:        avatars,
+		renameInterval: renameInterval,
+		reservePeriod:  reservePeriod,
+	}
 }
 
-func (s *UserService) CreateUser(username, email, password string) (*models.User, error) {
+func (s *UserService) CreateUser(username, email, password string, req RequestInfo) (*models.User, error) {
 	// Hash password with bcrypt
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		return nil, err
 	}
 
-	// Generate UUID for unique identification
-	userID := uuid.New().String()
-
-	// Insert user
-	query := `
-		INSERT INTO users (uuid, username, email, password_hash, created_at, updated_at)
-		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`
-
-	result, err := s.db.Exec(query, userID, username, email, string(hashedPassword))
-	if err != nil {
-		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
-			if strings.Contains(err.Error(), "username") {
-				return nil, errors.New("username already exists")
-			}
-			if strings.Contains(err.Error(), "email") {
-				return nil, errors.New("email already exists")
-			}
-		}
+	if err := s.checkReserved(0, username); err != nil {
 		return nil, err
 	}
 
-	id, _ := result.LastInsertId()
+	// Generate UUID for unique identification
+	user := &models.User{
+		UUID:         uuid.New().String(),
+		Username:     username,
+		Email:        email,
+		PasswordHash: string(hashedPassword),
+	}
 
-	return &models.User{
-		ID:       int(id),
-		UUID:     userID,
-		Username: username,
-		Email:    email,
-	}, nil
+	// Duplicate username/email come back as store.ErrUsernameTaken / store.ErrEmailTaken,
+	// whose messages are shown on the registration form
+	if err := s.users.CreateUser(user); err != nil {
+		return nil, err
+	}
+
+	user.PasswordHash = ""
+	s.audit.Record(req, user.ID, ActionUserRegister, "user", user.ID, Diff{
+		"username": {nil, user.Username},
+		"email":    {nil, user.Email},
+	})
+	return user, nil
 }
 
 func (s *UserService) AuthenticateUser(username, password string) (*models.User, error) {
-	var user models.User
-	query := `SELECT id, uuid, username, email, password_hash, is_admin, created_at 
-			  FROM users WHERE username = ? OR email = ?`
-
-	err := s.db.QueryRow(query, username, username).Scan(
-		&user.ID, &user.UUID, &user.Username, &user.Email,
-		&user.PasswordHash, &user.IsAdmin, &user.CreatedAt,
-	)
+	user, err := s.users.GetUserByLogin(username)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, store.ErrNotFound) {
 			return nil, errors.New("invalid username or password")
 		}
 		return nil, err
@@ -78,27 +91,220 @@ func (s *UserService) AuthenticateUser(username, password string) (*models.User,
 		return nil, errors.New("invalid username or password")
 	}
 
-	return &user, nil
+	return user, nil
 }
 
 func (s *UserService) GetUserByID(id int) (*models.User, error) {
-	var user models.User
-	var avatarURL sql.NullString
-
-	query := `SELECT id, uuid, username, email, avatar_url, is_admin, created_at 
-			  FROM users WHERE id = ?`
+	return s.users.GetUserByID(id)
+}
 
-	err := s.db.QueryRow(query, id).Scan(
-		&user.ID, &user.UUID, &user.Username, &user.Email,
-		&avatarURL, &user.IsAdmin, &user.CreatedAt,
-	)
+// GetProfile returns the public profile of the user with this exact
+// username (store.ErrNotFound if there is none). Only public fields are
+// copied from the user, so the email address never reaches the page.
+func (s *UserService) GetProfile(username string) (*models.UserProfile, error) {
+	id, err := s.users.GetUserIDByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.GetUserByID(id)
 	if err != nil {
 		return nil, err
 	}
+	stats, err := s.users.GetUserStats(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.UserProfile{
+		ID:        user.ID,
+		Username:  user.Username,
+		AvatarURL: user.AvatarURL,
+		IsAdmin:   user.IsAdmin,
+		Karma:     user.Karma,
+		JoinedAt:  user.CreatedAt,
+		UserStats: *stats,
+	}, nil
+}
+
+// AvatarMaxBytes is the largest avatar upload SetAvatar accepts
+func (s *UserService) AvatarMaxBytes() int {
+	return s.avatars.MaxBytes()
+}
+
+// SetAvatar stores a thumbnail of the uploaded image and makes it the user's
+// avatar, returning its URL. Images that are too large, not a PNG, JPEG or
+// GIF, or unreadable come back as the avatar package's errors.
+func (s *UserService) SetAvatar(userID int, data []byte, req RequestInfo) (string, error) {
+	user, err := s.users.GetUserByID(userID)
+	if err != nil {
+		return "", err
+	}
+	url, err := s.avatars.Save(data)
+	if err != nil {
+		return "", err
+	}
+	if err := s.changeAvatar(user, url, req); err != nil {
+		return "", err
+	}
+	return url, nil
+}
 
-	if avatarURL.Valid {
-		user.AvatarURL = avatarURL.String
+// RemoveAvatar clears the user's avatar. The file stays, since other users
+// may have uploaded the same picture.
+func (s *UserService) RemoveAvatar(userID int, req RequestInfo) error {
+	user, err := s.users.GetUserByID(userID)
+	if err != nil {
+		return err
 	}
+	return s.changeAvatar(user, "", req)
+}
 
-	return &user, nil
+func (s *UserService) changeAvatar(user *models.User, url string, req RequestInfo) error {
+	if user.AvatarURL == url {
+		return nil
+	}
+	if err := s.users.UpdateAvatar(user.ID, url); err != nil {
+		return err
+	}
+	s.audit.Record(req, user.ID, ActionUserAvatar, "user", user.ID, Diff{
+		"avatar_url": {nullable(user.AvatarURL), nullable(url)},
+	})
+	return nil
+}
+
+// nullable records "" as null in audit diffs
+func nullable(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// ChangePassword sets a new password after checking the current one, and
+// ends every session of the user: the caller logs the current browser in
+// again. The new password must already have passed validation.ValidatePassword.
+func (s *UserService) ChangePassword(userID int, current, password string, req RequestInfo) error {
+	user, err := s.checkPassword(userID, current)
+	if err != nil {
+		return err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	if err := s.users.UpdatePassword(user.ID, string(hash)); err != nil {
+		return err
+	}
+	if err := s.sessions.DeleteUserSessions(user.ID); err != nil {
+		return err
+	}
+
+	// The diff is left empty: password hashes never go into the audit log
+	s.audit.Record(req, user.ID, ActionUserPassword, "user", user.ID, nil)
+	return nil
+}
+
+// ChangeEmail sets a new email address after checking the password. A
+// duplicate address comes back as store.ErrEmailTaken.
+func (s *UserService) ChangeEmail(userID int, password, email string, req RequestInfo) error {
+	user, err := s.checkPassword(userID, password)
+	if err != nil {
+		return err
+	}
+	if user.Email == email {
+		return nil
+	}
+
+	if err := s.users.UpdateEmail(user.ID, email); err != nil {
+		return err
+	}
+	s.audit.Record(req, user.ID, ActionUserEmail, "user", user.ID, Diff{
+		"email": {user.Email, email},
+	})
+	return nil
+}
+
+// ChangeUsername renames the user at most once per rename interval. The old
+// name stays reserved for them for the reserve period, so nobody else can
+// pick it up and pass as them; taking it back is allowed.
+func (s *UserService) ChangeUsername(userID int, username string, req RequestInfo) error {
+	user, err := s.users.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.Username == username {
+		return nil
+	}
+
+	next, err := s.NextUsernameChange(user.ID)
+	if err != nil {
+		return err
+	}
+	if time.Now().Before(next) {
+		return ErrRenameTooSoon
+	}
+	if err := s.checkReserved(user.ID, username); err != nil {
+		return err
+	}
+
+	if err := s.users.ChangeUsername(user.ID, username); err != nil {
+		return err
+	}
+	s.audit.Record(req, user.ID, ActionUserRename, "user", user.ID, Diff{
+		"username": {user.Username, username},
+	})
+	return nil
+}
+
+// UsernameChangeInterval is the time a user must wait between renames
+func (s *UserService) UsernameChangeInterval() time.Duration {
+	return s.renameInterval
+}
+
+// UsernameReservePeriod is how long a username stays reserved for the user
+// who changed away from it
+func (s *UserService) UsernameReservePeriod() time.Duration {
+	return s.reservePeriod
+}
+
+// NextUsernameChange is when the user may rename themselves again; the
+// zero time if they never have
+func (s *UserService) NextUsernameChange(userID int) (time.Time, error) {
+	last, err := s.users.LastUsernameChange(userID)
+	if err != nil || last.IsZero() {
+		return time.Time{}, err
+	}
+	return last.Add(s.renameInterval), nil
+}
+
+// checkReserved returns ErrUsernameReserved if a user other than userID
+// gave up username within the reserve period (userID 0 for a new account)
+func (s *UserService) checkReserved(userID int, username string) error {
+	releasedBy, err := s.users.UsernameReleasedBy(username, time.Now().Add(-s.reservePeriod))
+	if err != nil {
+		return err
+	}
+	if releasedBy != 0 && releasedBy != userID {
+		return ErrUsernameReserved
+	}
+	return nil
+}
+
+// checkPassword returns the user (with password hash) if password is theirs,
+// and ErrWrongPassword otherwise
+func (s *UserService) checkPassword(userID int, password string) (*models.User, error) {
+	user, err := s.users.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	// Usernames can't contain "@", so this can only match the user by name
+	user, err = s.users.GetUserByLogin(user.Username)
+	if err != nil {
+		return nil, err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return nil, ErrWrongPassword
+	}
+	return user, nil
 }
@@ -0,0 +1,48 @@
+package memory
+
+import (
+	"time"
+
+	"forum/internal/models"
+	"forum/internal/store"
+)
+
+func (s *Store) RecordAuditEvent(event *models.AuditEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event.ID = len(s.auditEvents) + 1
+	event.CreatedAt = time.Now().UTC()
+	s.auditEvents = append(s.auditEvents, *event)
+	return nil
+}
+
+func (s *Store) ListAuditEvents(filter store.AuditFilter) ([]models.AuditEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var events []models.AuditEvent
+	for i := len(s.auditEvents) - 1; i >= 0; i-- {
+		e := s.auditEvents[i]
+		if e.ActorID != nil {
+			if u, ok := s.users[*e.ActorID]; ok {
+				e.ActorName = u.Username
+			}
+		}
+
+		switch {
+		case filter.ActorName != "" && e.ActorName != filter.ActorName,
+			filter.Action != "" && e.Action != filter.Action,
+			filter.TargetType != "" && e.TargetType != filter.TargetType,
+			filter.TargetID > 0 && e.TargetID != filter.TargetID,
+			filter.BeforeID > 0 && e.ID >= filter.BeforeID:
+			continue
+		}
+
+		events = append(events, e)
+		if filter.Limit > 0 && len(events) == filter.Limit {
+			break
+		}
+	}
+	return events, nil
+}
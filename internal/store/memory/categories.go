@@ -0,0 +1,31 @@
+package memory
+
+import (
+	"sort"
+
+	"forum/internal/models"
+	"forum/internal/store"
+)
+
+func (s *Store) ListCategories() ([]models.Category, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	categories := make([]models.Category, len(s.categories))
+	copy(categories, s.categories)
+	sort.Slice(categories, func(i, j int) bool { return categories[i].Name < categories[j].Name })
+	return categories, nil
+}
+
+func (s *Store) GetCategoryBySlug(slug string) (*models.Category, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, c := range s.categories {
+		if c.Slug == slug {
+			category := c
+			return &category, nil
+		}
+	}
+	return nil, store.ErrNotFound
+}
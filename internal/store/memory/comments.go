@@ -0,0 +1,193 @@
+package memory
+
+import (
+	"sort"
+	"time"
+
+	"forum/internal/models"
+	"forum/internal/store"
+)
+
+func (s *Store) ListComments(postID, viewerID int) ([]models.Comment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var comments []models.Comment
+	for _, c := range s.comments {
+		if c.PostID == postID {
+			comments = append(comments, s.buildComment(c, viewerID))
+		}
+	}
+
+	sort.Slice(comments, func(i, j int) bool { return comments[i].ID < comments[j].ID })
+	return comments, nil
+}
+
+func (s *Store) ListDeletedComments() ([]models.Comment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var comments []models.Comment
+	for _, c := range s.comments {
+		if c.IsDeleted() {
+			comments = append(comments, s.buildComment(c, 0))
+		}
+	}
+
+	sort.Slice(comments, func(i, j int) bool {
+		a, b := comments[i], comments[j]
+		if !a.DeletedAt.Equal(*b.DeletedAt) {
+			return a.DeletedAt.After(*b.DeletedAt)
+		}
+		return a.ID > b.ID
+	})
+	return comments, nil
+}
+
+func (s *Store) ListUserComments(userID, limit int) ([]models.Comment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var comments []models.Comment
+	for _, c := range s.comments {
+		if c.UserID == userID && s.commentVisible(c) {
+			comments = append(comments, s.buildComment(c, 0))
+		}
+	}
+
+	sort.Slice(comments, func(i, j int) bool {
+		a, b := comments[i], comments[j]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return a.ID > b.ID
+	})
+	if len(comments) > limit {
+		comments = comments[:limit]
+	}
+	return comments, nil
+}
+
+// commentVisible reports whether neither the comment nor its post is deleted (caller holds mu)
+func (s *Store) commentVisible(c *models.Comment) bool {
+	if c.IsDeleted() {
+		return false
+	}
+	p, ok := s.posts[c.PostID]
+	return ok && !p.IsDeleted()
+}
+
+func (s *Store) GetComment(id int) (*models.Comment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, ok := s.comments[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	out := s.buildComment(c, 0)
+	return &out, nil
+}
+
+// buildComment fills the joined fields of a stored comment (caller holds mu)
+func (s *Store) buildComment(c *models.Comment, viewerID int) models.Comment {
+	out := *c
+	if u, ok := s.users[c.UserID]; ok {
+		out.Username = u.Username
+		out.AuthorKarma = u.Karma
+	}
+	if c.DeletedBy != nil {
+		if u, ok := s.users[*c.DeletedBy]; ok {
+			out.DeletedByName = u.Username
+		}
+	}
+	out.LikeCount, out.DislikeCount = s.voteCounts(models.ReactionTargetComment, c.ID)
+	for _, r := range s.comments {
+		if r.ParentID != nil && *r.ParentID == c.ID {
+			out.ReplyCount++
+		}
+	}
+	if v := s.getVote(models.ReactionTargetComment, viewerID, c.ID); v != nil {
+		out.HasVoted = true
+		out.IsLike = *v
+	}
+	return out
+}
+
+func (s *Store) CommentExists(id int) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, ok := s.comments[id]
+	return ok && s.commentVisible(c), nil
+}
+
+func (s *Store) CreateComment(content string, userID, postID int, parentID *int) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.posts[postID]; !ok {
+		return 0, store.ErrNotFound
+	}
+	if parentID != nil {
+		if _, ok := s.comments[*parentID]; !ok {
+			return 0, store.ErrNotFound
+		}
+	}
+
+	now := time.Now().UTC()
+	id := s.nextCommentID
+	s.nextCommentID++
+	s.comments[id] = &models.Comment{
+		ID:        id,
+		Content:   content,
+		UserID:    userID,
+		PostID:    postID,
+		ParentID:  parentID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	return int64(id), nil
+}
+
+func (s *Store) UpdateComment(id int, content string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.comments[id]
+	if !ok || c.IsDeleted() {
+		return store.ErrNotFound
+	}
+	c.Content = content
+	c.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+func (s *Store) DeleteComment(id, deletedBy, karmaWeight int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.comments[id]
+	if !ok || c.IsDeleted() {
+		return store.ErrNotFound
+	}
+	now := time.Now().UTC()
+	c.DeletedAt = &now
+	c.DeletedBy = &deletedBy
+	s.addTargetKarma(models.ReactionTargetComment, id, c.UserID, -karmaWeight)
+	return nil
+}
+
+func (s *Store) RestoreComment(id, karmaWeight int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.comments[id]
+	if !ok || !c.IsDeleted() {
+		return store.ErrNotFound
+	}
+	c.DeletedAt = nil
+	c.DeletedBy = nil
+	s.addTargetKarma(models.ReactionTargetComment, id, c.UserID, karmaWeight)
+	return nil
+}
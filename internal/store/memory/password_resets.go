@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"time"
+
+	"forum/internal/store"
+)
+
+func (s *Store) CreatePasswordReset(userID int, tokenHash string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.passwordResets[tokenHash] = &passwordReset{userID: userID, expiresAt: expiresAt, createdAt: time.Now().UTC()}
+	return nil
+}
+
+func (s *Store) PasswordResetUser(tokenHash string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	r, ok := s.passwordResets[tokenHash]
+	if !ok || !r.valid(time.Now()) {
+		return 0, store.ErrNotFound
+	}
+	return r.userID, nil
+}
+
+func (s *Store) ResetPassword(tokenHash, passwordHash string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	r, ok := s.passwordResets[tokenHash]
+	if !ok || !r.valid(now) {
+		return 0, store.ErrNotFound
+	}
+	u, ok := s.users[r.userID]
+	if !ok {
+		return 0, store.ErrNotFound
+	}
+	for _, other := range s.passwordResets {
+		if other.userID == r.userID && other.usedAt.IsZero() {
+			other.usedAt = now.UTC()
+		}
+	}
+	u.PasswordHash = passwordHash
+	u.UpdatedAt = now.UTC()
+	for token, sess := range s.sessions {
+		if sess.userID == r.userID {
+			delete(s.sessions, token)
+		}
+	}
+	return r.userID, nil
+}
+
+func (s *Store) LastPasswordReset(userID int) (time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var last time.Time
+	for _, r := range s.passwordResets {
+		if r.userID == userID && r.createdAt.After(last) {
+			last = r.createdAt
+		}
+	}
+	return last, nil
+}
@@ -0,0 +1,334 @@
+package memory
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"forum/internal/models"
+	"forum/internal/store"
+)
+
+func (s *Store) ListPosts(filter store.PostFilter) ([]models.Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	posts, _ := s.listPosts(filter)
+	return posts, nil
+}
+
+func (s *Store) ListPostsPage(filter store.PostFilter, page store.PageRequest) (*store.PostPage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	posts, keys := s.listPosts(filter)
+
+	// Window of the full listing selected by the cursor
+	start, end := 0, len(posts)
+	switch {
+	case page.Before != nil:
+		start = sort.Search(len(keys), func(i int) bool { return page.Before.Less(keys[i]) })
+	case page.After != nil:
+		end = sort.Search(len(keys), func(i int) bool { return !keys[i].Less(*page.After) })
+	}
+	if page.Limit > 0 && end-start > page.Limit {
+		if page.After != nil {
+			start = end - page.Limit
+		} else {
+			end = start + page.Limit
+		}
+	}
+
+	result := &store.PostPage{Posts: posts[start:end]}
+	if start < end {
+		if start > 0 {
+			result.Prev = &keys[start]
+		}
+		if end < len(posts) {
+			result.Next = &keys[end-1]
+		}
+	}
+	return result, nil
+}
+
+// listPosts returns the whole listing in order with each post's sort key (caller holds mu)
+func (s *Store) listPosts(filter store.PostFilter) ([]models.Post, []store.Cursor) {
+	pins := filter.Pins()
+
+	var posts []models.Post
+	var keys []store.Cursor
+	for _, p := range s.posts {
+		if p.IsDeleted() != filter.Deleted {
+			continue
+		}
+		if filter.CategoryID > 0 && !containsInt(p.categoryIDs, filter.CategoryID) {
+			continue
+		}
+		if filter.AuthorID > 0 && p.UserID != filter.AuthorID {
+			continue
+		}
+		if filter.IDs != nil && !containsInt(filter.IDs, p.ID) {
+			continue
+		}
+
+		k := store.Cursor{Pinned: pins.Pinned(p.Post), Time: p.CreatedAt, ID: p.ID}
+		if filter.LikedBy > 0 {
+			likedAt, ok := s.reactions[reactionKey{filter.LikedBy, models.ReactionTargetPost, p.ID, models.ReactionLike}]
+			if !ok {
+				continue
+			}
+			k.Time = likedAt // most recently liked first
+		}
+		out := s.buildPost(p, filter.ViewerID)
+		out.PinnedInList = k.Pinned
+		posts = append(posts, out)
+		keys = append(keys, k)
+	}
+
+	sort.Sort(byCursor{posts, keys})
+	return posts, keys
+}
+
+// byCursor sorts posts and their keys together in listing order
+type byCursor struct {
+	posts []models.Post
+	keys  []store.Cursor
+}
+
+func (b byCursor) Len() int           { return len(b.posts) }
+func (b byCursor) Less(i, j int) bool { return b.keys[i].Less(b.keys[j]) }
+func (b byCursor) Swap(i, j int) {
+	b.posts[i], b.posts[j] = b.posts[j], b.posts[i]
+	b.keys[i], b.keys[j] = b.keys[j], b.keys[i]
+}
+
+func (s *Store) GetPost(id, viewerID int) (*models.Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.posts[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	post := s.buildPost(p, viewerID)
+	return &post, nil
+}
+
+// buildPost fills the joined fields of a stored post (caller holds mu)
+func (s *Store) buildPost(p *post, viewerID int) models.Post {
+	out := p.Post
+	if u, ok := s.users[p.UserID]; ok {
+		out.Username = u.Username
+		out.AuthorKarma = u.Karma
+	}
+	if p.DeletedBy != nil {
+		if u, ok := s.users[*p.DeletedBy]; ok {
+			out.DeletedByName = u.Username
+		}
+	}
+	if p.LockedBy != nil {
+		if u, ok := s.users[*p.LockedBy]; ok {
+			out.LockedByName = u.Username
+		}
+	}
+
+	for _, c := range s.comments {
+		if c.PostID == p.ID {
+			out.ReplyCount++
+		}
+	}
+	out.LikeCount, out.DislikeCount = s.voteCounts(models.ReactionTargetPost, p.ID)
+
+	if v := s.getVote(models.ReactionTargetPost, viewerID, p.ID); v != nil {
+		out.HasVoted = true
+		out.IsLike = *v
+	}
+
+	var categories []models.Category
+	for _, c := range s.categories {
+		if containsInt(p.categoryIDs, c.ID) {
+			categories = append(categories, c)
+		}
+	}
+	sort.Slice(categories, func(i, j int) bool { return categories[i].Name < categories[j].Name })
+	for _, c := range categories {
+		out.Categories = append(out.Categories, c.Name)
+		out.CategoryIDs = append(out.CategoryIDs, c.ID)
+		out.CategorySlugs = append(out.CategorySlugs, c.Slug)
+	}
+	return out
+}
+
+func (s *Store) PostExists(id int) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.posts[id]
+	return ok && !p.IsDeleted(), nil
+}
+
+func (s *Store) CreatePost(title, content string, userID int, categoryIDs []int) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[userID]; !ok {
+		return 0, store.ErrNotFound
+	}
+
+	now := time.Now().UTC()
+	id := s.nextPostID
+	s.nextPostID++
+	s.posts[id] = &post{
+		Post: models.Post{
+			ID:        id,
+			Title:     title,
+			Content:   content,
+			UserID:    userID,
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+		categoryIDs: append([]int(nil), categoryIDs...),
+	}
+	return int64(id), nil
+}
+
+func (s *Store) IncrementViewCount(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if p, ok := s.posts[id]; ok {
+		p.ViewCount++
+	}
+	return nil
+}
+
+func (s *Store) DeletePost(id, deletedBy, karmaWeight int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.posts[id]
+	if !ok || p.IsDeleted() {
+		return store.ErrNotFound
+	}
+	now := time.Now().UTC()
+	p.DeletedAt = &now
+	p.DeletedBy = &deletedBy
+	s.addTargetKarma(models.ReactionTargetPost, id, p.UserID, -karmaWeight)
+	return nil
+}
+
+func (s *Store) RestorePost(id, karmaWeight int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.posts[id]
+	if !ok || !p.IsDeleted() {
+		return store.ErrNotFound
+	}
+	p.DeletedAt = nil
+	p.DeletedBy = nil
+	s.addTargetKarma(models.ReactionTargetPost, id, p.UserID, karmaWeight)
+	return nil
+}
+
+func (s *Store) PinPost(id int, global bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.posts[id]
+	if !ok || p.IsDeleted() {
+		return store.ErrNotFound
+	}
+	p.IsPinned = true
+	p.IsPinnedGlobally = global
+	return nil
+}
+
+func (s *Store) UnpinPost(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.posts[id]
+	if !ok || p.IsDeleted() {
+		return store.ErrNotFound
+	}
+	p.IsPinned = false
+	p.IsPinnedGlobally = false
+	return nil
+}
+
+func (s *Store) LockPost(id, lockedBy int, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.posts[id]
+	if !ok || p.IsDeleted() {
+		return store.ErrNotFound
+	}
+	now := time.Now().UTC()
+	p.IsLocked = true
+	p.LockedAt = &now
+	p.LockedBy = &lockedBy
+	p.LockReason = reason
+	return nil
+}
+
+func (s *Store) UnlockPost(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.posts[id]
+	if !ok || p.IsDeleted() {
+		return store.ErrNotFound
+	}
+	p.IsLocked = false
+	p.LockedAt = nil
+	p.LockedBy = nil
+	p.LockReason = ""
+	return nil
+}
+
+func (s *Store) UpdatePost(id int, title, content string, categoryIDs []int, editedBy int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.posts[id]
+	if !ok || p.IsDeleted() {
+		return store.ErrNotFound
+	}
+
+	now := time.Now().UTC()
+	s.revisions = append(s.revisions, models.PostRevision{
+		ID:         len(s.revisions) + 1,
+		PostID:     id,
+		Title:      p.Title,
+		Content:    p.Content,
+		Categories: strings.Join(s.buildPost(p, 0).Categories, ", "),
+		EditedBy:   &editedBy,
+		CreatedAt:  now,
+	})
+
+	p.Title = title
+	p.Content = content
+	p.UpdatedAt = now
+	p.EditedAt = &now
+	p.categoryIDs = append([]int(nil), categoryIDs...)
+	return nil
+}
+
+func (s *Store) ListPostRevisions(postID int) ([]models.PostRevision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var revisions []models.PostRevision
+	for _, r := range s.revisions {
+		if r.PostID != postID {
+			continue
+		}
+		if u, ok := s.users[*r.EditedBy]; ok {
+			r.EditedByName = u.Username
+		}
+		revisions = append(revisions, r)
+	}
+	return revisions, nil
+}
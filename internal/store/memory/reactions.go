@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"forum/internal/models"
+	"forum/internal/store"
+)
+
+func (s *Store) ToggleReaction(r models.Reaction) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var exists bool
+	switch r.TargetType {
+	case models.ReactionTargetPost:
+		_, exists = s.posts[r.TargetID]
+	case models.ReactionTargetComment:
+		_, exists = s.comments[r.TargetID]
+	default:
+		return false, fmt.Errorf("unknown reaction target type %q", r.TargetType)
+	}
+	if !exists {
+		return false, store.ErrNotFound
+	}
+
+	k := reactionKey{r.UserID, r.TargetType, r.TargetID, r.Name}
+	if _, ok := s.reactions[k]; ok {
+		delete(s.reactions, k)
+		return false, nil
+	}
+	s.reactions[k] = time.Now().UTC()
+	return true, nil
+}
+
+func (s *Store) ReactionCounts(targetType string, targetIDs []int) (map[int]map[string]int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := make(map[int]map[string]int)
+	for k := range s.reactions {
+		if k.targetType != targetType || !containsInt(targetIDs, k.targetID) {
+			continue
+		}
+		if counts[k.targetID] == nil {
+			counts[k.targetID] = make(map[string]int)
+		}
+		counts[k.targetID][k.name]++
+	}
+	return counts, nil
+}
+
+func (s *Store) UserReactions(userID int, targetType string, targetIDs []int) (map[int][]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	type given struct {
+		name string
+		at   time.Time
+	}
+	byTarget := make(map[int][]given)
+	for k, at := range s.reactions {
+		if userID == 0 || k.userID != userID || k.targetType != targetType || !containsInt(targetIDs, k.targetID) {
+			continue
+		}
+		byTarget[k.targetID] = append(byTarget[k.targetID], given{k.name, at})
+	}
+
+	// Oldest first, like sqlstore
+	mine := make(map[int][]string)
+	for id, list := range byTarget {
+		sort.Slice(list, func(i, j int) bool { return list[i].at.Before(list[j].at) })
+		for _, g := range list {
+			mine[id] = append(mine[id], g.name)
+		}
+	}
+	return mine, nil
+}
@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"sort"
+
+	"forum/internal/store"
+)
+
+// SearchPosts matches like the SQL LIKE fallback: every term must appear in
+// the post or one of its comments. Results are newest first.
+func (s *Store) SearchPosts(q store.SearchQuery) ([]store.SearchHit, error) {
+	terms := store.SearchTerms(q.Text)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var matched []*post
+	for _, p := range s.posts {
+		if p.IsDeleted() {
+			continue
+		}
+		if q.CategoryID > 0 && !containsInt(p.categoryIDs, q.CategoryID) {
+			continue
+		}
+		if q.Author != "" {
+			if u, ok := s.users[p.UserID]; !ok || u.Username != q.Author {
+				continue
+			}
+		}
+
+		text := p.Title + " " + p.Content
+		for _, c := range s.comments {
+			if c.PostID == p.ID && !c.IsDeleted() {
+				text += " " + c.Content
+			}
+		}
+		if store.MatchesTerms(text, terms) {
+			matched = append(matched, p)
+		}
+	}
+
+	sort.Slice(matched, func(i, j int) bool {
+		a, b := matched[i], matched[j]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return a.ID > b.ID
+	})
+	if q.Limit > 0 && len(matched) > q.Limit {
+		matched = matched[:q.Limit]
+	}
+
+	hits := make([]store.SearchHit, 0, len(matched))
+	for _, p := range matched {
+		hit := store.SearchHit{PostID: p.ID}
+		text := p.Title + " - " + p.Content
+		if !store.ContainsAnyTerm(text, terms) {
+			// The match is in a comment; show the oldest one that contains a term
+			firstID := 0
+			for _, c := range s.comments {
+				if c.PostID == p.ID && !c.IsDeleted() && store.ContainsAnyTerm(c.Content, terms) && (firstID == 0 || c.ID < firstID) {
+					firstID = c.ID
+					text = c.Content
+					hit.InComment = true
+				}
+			}
+		}
+		hit.Snippet = store.MakeSnippet(text, terms, 160)
+		hits = append(hits, hit)
+	}
+	return hits, nil
+}
@@ -0,0 +1,66 @@
+package memory
+
+import (
+	"time"
+
+	"forum/internal/models"
+	"forum/internal/store"
+)
+
+func (s *Store) CreateSession(token string, userID int, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions[token] = session{userID: userID, expiresAt: expiresAt}
+	return nil
+}
+
+func (s *Store) GetSessionUser(token string) (*models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sess, ok := s.sessions[token]
+	if !ok || !sess.expiresAt.After(time.Now()) {
+		return nil, store.ErrNotFound
+	}
+	u, ok := s.users[sess.userID]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	user := *u
+	user.PasswordHash = ""
+	return &user, nil
+}
+
+func (s *Store) DeleteSession(token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, token)
+	return nil
+}
+
+func (s *Store) DeleteUserSessions(userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for token, sess := range s.sessions {
+		if sess.userID == userID {
+			delete(s.sessions, token)
+		}
+	}
+	return nil
+}
+
+func (s *Store) DeleteExpiredSessions() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for token, sess := range s.sessions {
+		if !sess.expiresAt.After(now) {
+			delete(s.sessions, token)
+		}
+	}
+	return nil
+}
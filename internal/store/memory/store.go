@@ -0,0 +1,148 @@
+// Package memory implements the store interfaces in process memory.
+// It is meant for unit tests and local experiments; nothing is persisted.
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"forum/internal/models"
+	"forum/internal/store"
+)
+
+// reactionKey identifies a reaction by user, target and name
+type reactionKey struct {
+	userID     int
+	targetType string
+	targetID   int
+	name       string
+}
+
+type post struct {
+	models.Post
+	categoryIDs []int
+}
+
+// usernameChange is a row of username_history
+type usernameChange struct {
+	userID    int
+	username  string // The name given up
+	changedAt time.Time
+}
+
+// passwordReset is a row of password_resets, keyed by token hash
+type passwordReset struct {
+	userID    int
+	expiresAt time.Time
+	usedAt    time.Time // zero while unused
+	createdAt time.Time
+}
+
+// valid reports whether the token can still be used
+func (r *passwordReset) valid(now time.Time) bool {
+	return r.usedAt.IsZero() && r.expiresAt.After(now)
+}
+
+type session struct {
+	userID    int
+	expiresAt time.Time
+}
+
+// Store implements every store interface with maps guarded by a single mutex
+type Store struct {
+	mu sync.RWMutex
+
+	users          map[int]*models.User
+	categories     []models.Category
+	posts          map[int]*post
+	comments       map[int]*models.Comment
+	sessions       map[string]session
+	reactions      map[reactionKey]time.Time // when each reaction was given
+	revisions      []models.PostRevision     // oldest first
+	auditEvents    []models.AuditEvent       // oldest first
+	renames        []usernameChange          // oldest first
+	passwordResets map[string]*passwordReset // by token hash
+	verifySent     map[int]time.Time         // users.verification_sent_at
+
+	nextUserID    int
+	nextPostID    int
+	nextCommentID int
+}
+
+// Compile-time checks that Store satisfies every store interface
+var (
+	_ store.PostStore          = (*Store)(nil)
+	_ store.CommentStore       = (*Store)(nil)
+	_ store.CategoryStore      = (*Store)(nil)
+	_ store.UserStore          = (*Store)(nil)
+	_ store.SessionStore       = (*Store)(nil)
+	_ store.VoteStore          = (*Store)(nil)
+	_ store.ReactionStore      = (*Store)(nil)
+	_ store.SearchStore        = (*Store)(nil)
+	_ store.AuditStore         = (*Store)(nil)
+	_ store.PasswordResetStore = (*Store)(nil)
+)
+
+// New returns a store seeded with the same default categories and admin
+// account as migration 0001
+func New() *Store {
+	now := time.Now().UTC()
+	s := &Store{
+		users:          make(map[int]*models.User),
+		posts:          make(map[int]*post),
+		comments:       make(map[int]*models.Comment),
+		sessions:       make(map[string]session),
+		reactions:      make(map[reactionKey]time.Time),
+		passwordResets: make(map[string]*passwordReset),
+		verifySent:     make(map[int]time.Time),
+		categories: []models.Category{
+			{ID: 1, Name: "General Discussion", Description: "General topics and discussions", Slug: "general", CreatedAt: now},
+			{ID: 2, Name: "Tech Talk", Description: "Technology and programming discussions", Slug: "tech", CreatedAt: now},
+			{ID: 3, Name: "Announcements", Description: "Important announcements", Slug: "announcements", CreatedAt: now},
+			{ID: 4, Name: "Help & Support", Description: "Get help and support from the community", Slug: "help-support", CreatedAt: now},
+			{ID: 5, Name: "Off-Topic", Description: "Casual discussions and off-topic conversations", Slug: "off-topic", CreatedAt: now},
+		},
+		nextUserID:    1,
+		nextPostID:    1,
+		nextCommentID: 1,
+	}
+
+	s.users[1] = &models.User{
+		ID:           1,
+		UUID:         "550e8400-e29b-41d4-a716-446655440000",
+		Username:     "admin",
+		Email:        "admin@forum.local",
+		PasswordHash: "$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy",
+		IsAdmin:      true,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	s.nextUserID = 2
+
+	return s
+}
+
+// voteCounts tallies likes and dislikes for one target (caller holds mu)
+func (s *Store) voteCounts(targetType string, targetID int) (likes, dislikes int) {
+	for k := range s.reactions {
+		if k.targetType != targetType || k.targetID != targetID {
+			continue
+		}
+		switch k.name {
+		case models.ReactionLike:
+			likes++
+		case models.ReactionDislike:
+			dislikes++
+		}
+	}
+	return likes, dislikes
+}
+
+func containsInt(list []int, value int) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
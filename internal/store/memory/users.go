@@ -0,0 +1,235 @@
+package memory
+
+import (
+	"time"
+
+	"forum/internal/models"
+	"forum/internal/store"
+)
+
+func (s *Store) CreateUser(user *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, u := range s.users {
+		if u.Username == user.Username {
+			return store.ErrUsernameTaken
+		}
+		if u.Email == user.Email {
+			return store.ErrEmailTaken
+		}
+	}
+
+	now := time.Now().UTC()
+	user.ID = s.nextUserID
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	s.nextUserID++
+
+	stored := *user
+	s.users[user.ID] = &stored
+	return nil
+}
+
+func (s *Store) GetUserByID(id int) (*models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	u, ok := s.users[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	user := *u
+	user.PasswordHash = ""
+	return &user, nil
+}
+
+func (s *Store) GetUserByLogin(login string) (*models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, u := range s.users {
+		if u.Username == login || u.Email == login {
+			user := *u
+			return &user, nil
+		}
+	}
+	return nil, store.ErrNotFound
+}
+
+func (s *Store) UpdateAvatar(userID int, avatarURL string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[userID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	u.AvatarURL = avatarURL
+	return nil
+}
+
+func (s *Store) GetUserIDByUsername(username string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, u := range s.users {
+		if u.Username == username {
+			return u.ID, nil
+		}
+	}
+	return 0, store.ErrNotFound
+}
+
+func (s *Store) GetUserStats(userID int) (*models.UserStats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var st models.UserStats
+	for _, p := range s.posts {
+		if p.UserID == userID && !p.IsDeleted() {
+			likes, dislikes := s.voteCounts(models.ReactionTargetPost, p.ID)
+			st.PostCount++
+			st.LikesReceived += likes
+			st.DislikesReceived += dislikes
+		}
+	}
+	for _, c := range s.comments {
+		if c.UserID == userID && s.commentVisible(c) {
+			likes, dislikes := s.voteCounts(models.ReactionTargetComment, c.ID)
+			st.CommentCount++
+			st.LikesReceived += likes
+			st.DislikesReceived += dislikes
+		}
+	}
+	return &st, nil
+}
+
+func (s *Store) UpdatePassword(userID int, passwordHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[userID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	u.PasswordHash = passwordHash
+	u.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+func (s *Store) UpdateEmail(userID int, email string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[userID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	for _, other := range s.users {
+		if other.ID != userID && other.Email == email {
+			return store.ErrEmailTaken
+		}
+	}
+	u.Email = email
+	u.EmailVerifiedAt = nil
+	u.UpdatedAt = time.Now().UTC()
+	delete(s.verifySent, userID)
+	return nil
+}
+
+func (s *Store) VerifyEmail(userID int, email string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[userID]
+	if !ok || u.Email != email {
+		return store.ErrNotFound
+	}
+	at = at.UTC()
+	u.EmailVerifiedAt = &at
+	return nil
+}
+
+func (s *Store) SetVerificationSent(userID int, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[userID]; !ok {
+		return store.ErrNotFound
+	}
+	s.verifySent[userID] = at.UTC()
+	return nil
+}
+
+func (s *Store) VerificationSent(userID int) (time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.users[userID]; !ok {
+		return time.Time{}, store.ErrNotFound
+	}
+	return s.verifySent[userID], nil
+}
+
+func (s *Store) CountContentSince(userID int, since time.Time) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	n := 0
+	for _, p := range s.posts {
+		if p.UserID == userID && p.CreatedAt.After(since) {
+			n++
+		}
+	}
+	for _, c := range s.comments {
+		if c.UserID == userID && c.CreatedAt.After(since) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *Store) ChangeUsername(userID int, username string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[userID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	for _, other := range s.users {
+		if other.ID != userID && other.Username == username {
+			return store.ErrUsernameTaken
+		}
+	}
+	now := time.Now().UTC()
+	s.renames = append(s.renames, usernameChange{userID: userID, username: u.Username, changedAt: now})
+	u.Username = username
+	u.UpdatedAt = now
+	return nil
+}
+
+func (s *Store) LastUsernameChange(userID int) (time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for i := len(s.renames) - 1; i >= 0; i-- {
+		if s.renames[i].userID == userID {
+			return s.renames[i].changedAt, nil
+		}
+	}
+	return time.Time{}, nil
+}
+
+func (s *Store) UsernameReleasedBy(username string, since time.Time) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for i := len(s.renames) - 1; i >= 0; i-- {
+		if r := s.renames[i]; r.username == username && r.changedAt.After(since) {
+			return r.userID, nil
+		}
+	}
+	return 0, nil
+}
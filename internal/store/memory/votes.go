@@ -0,0 +1,151 @@
+package memory
+
+import (
+	"time"
+
+	"forum/internal/models"
+	"forum/internal/store"
+)
+
+func (s *Store) GetPostVote(userID, postID int) (*bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.getVote(models.ReactionTargetPost, userID, postID), nil
+}
+
+func (s *Store) TogglePostVote(userID, postID int, isLike bool, karmaWeight int) (store.VoteResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.posts[postID]
+	if !ok {
+		return store.VoteResult{}, store.ErrNotFound
+	}
+	res := s.changeVote(models.ReactionTargetPost, userID, postID, &isLike)
+	if !p.IsDeleted() {
+		s.addVoteKarma(p.UserID, userID, res.Change()*karmaWeight)
+	}
+	return res, nil
+}
+
+func (s *Store) ClearPostVote(userID, postID, karmaWeight int) (store.VoteResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.posts[postID]
+	if !ok {
+		return store.VoteResult{}, store.ErrNotFound
+	}
+	res := s.changeVote(models.ReactionTargetPost, userID, postID, nil)
+	if !p.IsDeleted() {
+		s.addVoteKarma(p.UserID, userID, res.Change()*karmaWeight)
+	}
+	return res, nil
+}
+
+func (s *Store) PostVoteCounts(postID int) (likes int, dislikes int, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	likes, dislikes = s.voteCounts(models.ReactionTargetPost, postID)
+	return likes, dislikes, nil
+}
+
+func (s *Store) GetCommentVote(userID, commentID int) (*bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.getVote(models.ReactionTargetComment, userID, commentID), nil
+}
+
+func (s *Store) ToggleCommentVote(userID, commentID int, isLike bool, karmaWeight int) (store.VoteResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.comments[commentID]
+	if !ok {
+		return store.VoteResult{}, store.ErrNotFound
+	}
+	res := s.changeVote(models.ReactionTargetComment, userID, commentID, &isLike)
+	if !c.IsDeleted() {
+		s.addVoteKarma(c.UserID, userID, res.Change()*karmaWeight)
+	}
+	return res, nil
+}
+
+func (s *Store) ClearCommentVote(userID, commentID, karmaWeight int) (store.VoteResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.comments[commentID]
+	if !ok {
+		return store.VoteResult{}, store.ErrNotFound
+	}
+	res := s.changeVote(models.ReactionTargetComment, userID, commentID, nil)
+	if !c.IsDeleted() {
+		s.addVoteKarma(c.UserID, userID, res.Change()*karmaWeight)
+	}
+	return res, nil
+}
+
+// addVoteKarma adds delta to the author's karma unless the voter is the
+// author (caller holds mu)
+func (s *Store) addVoteKarma(authorID, voterID, delta int) {
+	if u, ok := s.users[authorID]; ok && authorID != voterID {
+		u.Karma += delta
+	}
+}
+
+// addTargetKarma adds the score of the votes on a post or comment, times
+// weight, to its author's karma, leaving out the author's own vote (caller
+// holds mu)
+func (s *Store) addTargetKarma(targetType string, targetID, authorID, weight int) {
+	likes, dislikes := s.voteCounts(targetType, targetID)
+	score := likes - dislikes
+	if own := s.getVote(targetType, authorID, targetID); own != nil {
+		if *own {
+			score--
+		} else {
+			score++
+		}
+	}
+	if u, ok := s.users[authorID]; ok {
+		u.Karma += score * weight
+	}
+}
+
+// changeVote is the vote state machine of sqlstore's changeVote (caller holds mu)
+func (s *Store) changeVote(targetType string, userID, targetID int, v *bool) store.VoteResult {
+	res := store.VoteResult{Before: s.getVote(targetType, userID, targetID)}
+	like := reactionKey{userID, targetType, targetID, models.ReactionLike}
+	dislike := reactionKey{userID, targetType, targetID, models.ReactionDislike}
+
+	votedAt, ok := s.reactions[like]
+	if !ok {
+		votedAt, ok = s.reactions[dislike]
+	}
+	if !ok {
+		votedAt = time.Now().UTC()
+	}
+	delete(s.reactions, like)
+	delete(s.reactions, dislike)
+
+	if v != nil && (res.Before == nil || *res.Before != *v) {
+		// Like SQL, switching keeps the time of the first vote
+		if *v {
+			s.reactions[like] = votedAt
+		} else {
+			s.reactions[dislike] = votedAt
+		}
+		res.Vote = v
+	}
+	res.Likes, res.Dislikes = s.voteCounts(targetType, targetID)
+	return res
+}
+
+// getVote returns the stored vote or nil (caller holds mu)
+func (s *Store) getVote(targetType string, userID, targetID int) *bool {
+	if _, ok := s.reactions[reactionKey{userID, targetType, targetID, models.ReactionLike}]; ok {
+		isLike := true
+		return &isLike
+	}
+	if _, ok := s.reactions[reactionKey{userID, targetType, targetID, models.ReactionDislike}]; ok {
+		isLike := false
+		return &isLike
+	}
+	return nil
+}
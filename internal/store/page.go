@@ -0,0 +1,88 @@
+package store
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"forum/internal/models"
+)
+
+// ErrInvalidCursor is returned by ParseCursor for malformed ?before=/?after= values
+var ErrInvalidCursor = errors.New("invalid page cursor")
+
+// Cursor is the sort key of one post in a listing. Listings are ordered by
+// (Pinned, Time, ID) descending, where Time is the post's created_at, or the
+// time it was liked for PostFilter.LikedBy listings. Pinned follows the
+// listing's PostFilter.Pins order, and is false in listings without pins.
+type Cursor struct {
+	Pinned bool
+	Time   time.Time
+	ID     int
+}
+
+// Encode returns the cursor as an opaque URL-safe token
+func (c Cursor) Encode() string {
+	pinned := 0
+	if c.Pinned {
+		pinned = 1
+	}
+	raw := fmt.Sprintf("%d.%d.%d", pinned, c.Time.UnixMicro(), c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseCursor decodes a token produced by Cursor.Encode
+func ParseCursor(token string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	parts := strings.Split(string(raw), ".")
+	if len(parts) != 3 || (parts[0] != "0" && parts[0] != "1") {
+		return nil, ErrInvalidCursor
+	}
+	micros, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	id, err := strconv.Atoi(parts[2])
+	if err != nil || id <= 0 {
+		return nil, ErrInvalidCursor
+	}
+
+	return &Cursor{
+		Pinned: parts[0] == "1",
+		Time:   time.UnixMicro(micros).UTC(),
+		ID:     id,
+	}, nil
+}
+
+// Less reports whether c sorts before other in listing order (newest first)
+func (c Cursor) Less(other Cursor) bool {
+	if c.Pinned != other.Pinned {
+		return c.Pinned
+	}
+	if !c.Time.Equal(other.Time) {
+		return c.Time.After(other.Time)
+	}
+	return c.ID > other.ID
+}
+
+// PageRequest selects one page of a listing.
+// At most one of Before and After may be set; neither means the first page.
+type PageRequest struct {
+	Before *Cursor // Posts listed after this cursor (older: the next page)
+	After  *Cursor // Posts listed before this cursor (newer: the previous page)
+	Limit  int     // Page size
+}
+
+// PostPage is one page of a post listing
+type PostPage struct {
+	Posts []models.Post
+	Next  *Cursor // Pass as ?before= for the next page; nil on the last page
+	Prev  *Cursor // Pass as ?after= for the previous page; nil on the first page
+}
@@ -0,0 +1,155 @@
+package store
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Snippet highlight markers. They are control characters, which
+// validation.CleanText strips from user input, so they cannot clash with
+// post or comment text. Handlers escape the snippet and then turn the
+// markers into <mark> tags.
+const (
+	HighlightStart = "\x02"
+	HighlightEnd   = "\x03"
+)
+
+// SearchQuery describes a full-text search.
+// Zero values for CategoryID and Author mean "no restriction".
+type SearchQuery struct {
+	Text       string
+	CategoryID int    // Only posts in this category
+	Author     string // Only posts created by this username
+	Limit      int
+}
+
+// SearchHit is one matching post. The text match may be in the post
+// itself or in one of its comments.
+type SearchHit struct {
+	PostID    int
+	Snippet   string // Excerpt with matches wrapped in HighlightStart/HighlightEnd
+	InComment bool   // Snippet was taken from a comment
+}
+
+// SearchStore finds posts by text
+type SearchStore interface {
+	// SearchPosts returns matching posts, best match first
+	SearchPosts(q SearchQuery) ([]SearchHit, error)
+}
+
+// SearchTerms splits free text into lowercase search terms.
+// Everything except letters and digits separates terms, so the result is
+// safe to embed in FTS5 or tsquery syntax.
+func SearchTerms(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var terms []string
+	seen := make(map[string]bool)
+	for _, f := range fields {
+		if !seen[f] {
+			seen[f] = true
+			terms = append(terms, f)
+		}
+	}
+	return terms
+}
+
+// MatchesTerms reports whether text contains every term (case-insensitive)
+func MatchesTerms(text string, terms []string) bool {
+	lower := strings.ToLower(text)
+	for _, t := range terms {
+		if !strings.Contains(lower, t) {
+			return false
+		}
+	}
+	return len(terms) > 0
+}
+
+// ContainsAnyTerm reports whether text contains at least one term (case-insensitive)
+func ContainsAnyTerm(text string, terms []string) bool {
+	lower := strings.ToLower(text)
+	for _, t := range terms {
+		if strings.Contains(lower, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// MakeSnippet returns up to width runes of text around the first term match,
+// with every term occurrence wrapped in highlight markers. It is used where
+// the database cannot build snippets itself.
+func MakeSnippet(text string, terms []string, width int) string {
+	runes := []rune(text)
+	lower := []rune(strings.ToLower(text))
+	if len(lower) != len(runes) {
+		// Lowercasing changed the length (rare Unicode cases); match on the original
+		lower = runes
+	}
+
+	// Find the first match to centre the excerpt on
+	first := -1
+	for _, t := range terms {
+		if i := runeIndex(lower, []rune(t), 0); i >= 0 && (first < 0 || i < first) {
+			first = i
+		}
+	}
+
+	start := 0
+	if first > width/3 {
+		start = first - width/3
+	}
+	end := start + width
+	if end > len(runes) {
+		end = len(runes)
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	for i := start; i < end; {
+		matched := 0
+		for _, t := range terms {
+			tr := []rune(t)
+			if len(tr) > matched && i+len(tr) <= end && runeIndex(lower[i:i+len(tr)], tr, 0) == 0 {
+				matched = len(tr)
+			}
+		}
+		if matched > 0 {
+			b.WriteString(HighlightStart)
+			b.WriteString(string(runes[i : i+matched]))
+			b.WriteString(HighlightEnd)
+			i += matched
+			continue
+		}
+		b.WriteRune(runes[i])
+		i++
+	}
+	if end < len(runes) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
+
+// runeIndex returns the index of needle in haystack starting at from, or -1
+func runeIndex(haystack, needle []rune, from int) int {
+	if len(needle) == 0 {
+		return -1
+	}
+	for i := from; i+len(needle) <= len(haystack); i++ {
+		match := true
+		for j := range needle {
+			if haystack[i+j] != needle[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return i
+		}
+	}
+	return -1
+}
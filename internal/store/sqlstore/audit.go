@@ -0,0 +1,88 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"strings"
+
+	"forum/internal/models"
+	"forum/internal/store"
+)
+
+func (s *Store) RecordAuditEvent(event *models.AuditEvent) error {
+	var targetID interface{}
+	if event.TargetID > 0 {
+		targetID = event.TargetID
+	}
+
+	id, err := s.insert(s.db, `
+		INSERT INTO audit_events (actor_id, action, target_type, target_id, ip, user_agent, diff, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)`,
+		event.ActorID, event.Action, event.TargetType, targetID, event.IP, event.UserAgent, event.Diff)
+	if err != nil {
+		return err
+	}
+	event.ID = int(id)
+	return nil
+}
+
+// ListAuditEvents builds its WHERE clause from the filter, like listPosts
+func (s *Store) ListAuditEvents(filter store.AuditFilter) ([]models.AuditEvent, error) {
+	var where []string
+	var args []interface{}
+
+	if filter.ActorName != "" {
+		where = append(where, "u.username = ?")
+		args = append(args, filter.ActorName)
+	}
+	if filter.Action != "" {
+		where = append(where, "a.action = ?")
+		args = append(args, filter.Action)
+	}
+	if filter.TargetType != "" {
+		where = append(where, "a.target_type = ?")
+		args = append(args, filter.TargetType)
+	}
+	if filter.TargetID > 0 {
+		where = append(where, "a.target_id = ?")
+		args = append(args, filter.TargetID)
+	}
+	if filter.BeforeID > 0 {
+		where = append(where, "a.id < ?")
+		args = append(args, filter.BeforeID)
+	}
+
+	query := `
+		SELECT a.id, a.actor_id, u.username, a.action, a.target_type, a.target_id,
+		       a.ip, a.user_agent, a.diff, a.created_at
+		FROM audit_events a
+		LEFT JOIN users u ON a.actor_id = u.id`
+	if len(where) > 0 {
+		query += "\n\t\tWHERE " + strings.Join(where, " AND ")
+	}
+	query += "\n\t\tORDER BY a.id DESC"
+	if filter.Limit > 0 {
+		query += "\n\t\tLIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := s.query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []models.AuditEvent
+	for rows.Next() {
+		var e models.AuditEvent
+		var actorName sql.NullString
+		var targetID sql.NullInt64
+		if err := rows.Scan(&e.ID, &e.ActorID, &actorName, &e.Action, &e.TargetType, &targetID,
+			&e.IP, &e.UserAgent, &e.Diff, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.ActorName = actorName.String
+		e.TargetID = int(targetID.Int64)
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
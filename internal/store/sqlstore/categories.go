@@ -0,0 +1,97 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"strings"
+
+	"forum/internal/models"
+	"forum/internal/store"
+)
+
+func (s *Store) ListCategories() ([]models.Category, error) {
+	query := `SELECT id, name, description, slug, created_at FROM categories ORDER BY name`
+	rows, err := s.query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []models.Category
+	for rows.Next() {
+		var c models.Category
+		err := rows.Scan(&c.ID, &c.Name, &c.Description, &c.Slug, &c.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, c)
+	}
+	return categories, rows.Err()
+}
+
+func (s *Store) GetCategoryBySlug(slug string) (*models.Category, error) {
+	query := `SELECT id, name, description, slug, created_at FROM categories WHERE slug = ?`
+	var c models.Category
+	err := s.queryRow(query, slug).Scan(&c.ID, &c.Name, &c.Description, &c.Slug, &c.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// categoryBatchSize bounds the number of placeholders in one IN (...) list
+const categoryBatchSize = 500
+
+// attachCategories fills Categories, CategoryIDs and CategorySlugs for a whole
+// result set with one query per categoryBatchSize posts (instead of one per post)
+func (s *Store) attachCategories(posts []models.Post) error {
+	index := make(map[int]int, len(posts)) // post ID -> position in posts
+	ids := make([]interface{}, 0, len(posts))
+	for i, p := range posts {
+		index[p.ID] = i
+		ids = append(ids, p.ID)
+	}
+
+	for len(ids) > 0 {
+		batch := ids
+		if len(batch) > categoryBatchSize {
+			batch = batch[:categoryBatchSize]
+		}
+		ids = ids[len(batch):]
+
+		query := `
+			SELECT pc.post_id, c.id, c.name, c.slug
+			FROM categories c
+			JOIN post_categories pc ON c.id = pc.category_id
+			WHERE pc.post_id IN (?` + strings.Repeat(", ?", len(batch)-1) + `)
+			ORDER BY c.name`
+
+		if err := s.scanCategories(query, batch, posts, index); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) scanCategories(query string, args []interface{}, posts []models.Post, index map[int]int) error {
+	rows, err := s.query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var postID, id int
+		var name, slug string
+		if err := rows.Scan(&postID, &id, &name, &slug); err != nil {
+			return err
+		}
+		p := &posts[index[postID]]
+		p.CategoryIDs = append(p.CategoryIDs, id)
+		p.Categories = append(p.Categories, name)
+		p.CategorySlugs = append(p.CategorySlugs, slug)
+	}
+	return rows.Err()
+}
@@ -0,0 +1,128 @@
+package sqlstore
+
+import (
+	"database/sql"
+
+	"forum/internal/models"
+	"forum/internal/store"
+)
+
+// commentColumns is the select list read by scanComment; the query must join
+// users u (author), users du (deleter) and reactions ucr (viewer's vote)
+const commentColumns = `
+		SELECT c.id, c.content, c.user_id, c.post_id, c.parent_id,
+		       c.created_at, c.updated_at, u.username, u.karma,
+		       c.reply_count, c.like_count, c.dislike_count,
+		       c.deleted_at, c.deleted_by, du.username,
+		       ucr.reaction = 'like' as user_vote
+		FROM comments c
+		JOIN users u ON c.user_id = u.id
+		LEFT JOIN users du ON c.deleted_by = du.id
+		LEFT JOIN reactions ucr ON ucr.target_type = 'comment' AND ucr.target_id = c.id
+			AND ucr.user_id = ? AND ucr.reaction IN ('like', 'dislike')`
+
+// scanComment scans one row selected with commentColumns
+func scanComment(row interface{ Scan(...interface{}) error }) (models.Comment, error) {
+	var c models.Comment
+	var updatedAt sql.NullTime
+	var deletedByName sql.NullString
+	var userVote sql.NullBool
+	err := row.Scan(&c.ID, &c.Content, &c.UserID, &c.PostID, &c.ParentID,
+		&c.CreatedAt, &updatedAt, &c.Username, &c.AuthorKarma, &c.ReplyCount, &c.LikeCount, &c.DislikeCount,
+		&c.DeletedAt, &c.DeletedBy, &deletedByName, &userVote)
+	if err != nil {
+		return c, err
+	}
+	c.UpdatedAt = c.CreatedAt
+	if updatedAt.Valid {
+		c.UpdatedAt = updatedAt.Time
+	}
+	c.DeletedByName = deletedByName.String
+	c.HasVoted = userVote.Valid
+	if userVote.Valid {
+		c.IsLike = userVote.Bool
+	}
+	return c, nil
+}
+
+// listComments runs a query built on commentColumns
+func (s *Store) listComments(query string, args ...interface{}) ([]models.Comment, error) {
+	rows, err := s.query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []models.Comment
+	for rows.Next() {
+		c, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, c)
+	}
+	return comments, rows.Err()
+}
+
+// ListComments retrieves all comments for a given post
+func (s *Store) ListComments(postID, viewerID int) ([]models.Comment, error) {
+	return s.listComments(commentColumns+`
+		WHERE c.post_id = ?
+		ORDER BY c.created_at ASC`, viewerID, postID)
+}
+
+func (s *Store) ListDeletedComments() ([]models.Comment, error) {
+	return s.listComments(commentColumns+`
+		WHERE c.deleted_at IS NOT NULL
+		ORDER BY c.deleted_at DESC, c.id DESC`, 0)
+}
+
+func (s *Store) ListUserComments(userID, limit int) ([]models.Comment, error) {
+	return s.listComments(commentColumns+`
+		JOIN posts p ON c.post_id = p.id
+		WHERE c.user_id = ? AND c.deleted_at IS NULL AND p.deleted_at IS NULL
+		ORDER BY c.created_at DESC, c.id DESC
+		LIMIT ?`, 0, userID, limit)
+}
+
+func (s *Store) GetComment(id int) (*models.Comment, error) {
+	c, err := scanComment(s.queryRow(commentColumns+`
+		WHERE c.id = ?`, 0, id))
+	if err == sql.ErrNoRows {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (s *Store) CommentExists(id int) (bool, error) {
+	return s.exists(`
+		SELECT 1 FROM comments c
+		JOIN posts p ON c.post_id = p.id
+		WHERE c.id = ? AND c.deleted_at IS NULL AND p.deleted_at IS NULL
+		LIMIT 1`, id)
+}
+
+func (s *Store) CreateComment(content string, userID, postID int, parentID *int) (int64, error) {
+	query := `
+		INSERT INTO comments (content, user_id, post_id, parent_id, created_at, updated_at)
+		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`
+
+	return s.insert(s.db, query, content, userID, postID, parentID)
+}
+
+func (s *Store) UpdateComment(id int, content string) error {
+	return s.updateOne(`
+		UPDATE comments SET content = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND deleted_at IS NULL`, content, id)
+}
+
+func (s *Store) DeleteComment(id, deletedBy, karmaWeight int) error {
+	return s.softDelete(commentVotes, id, &deletedBy, karmaWeight)
+}
+
+func (s *Store) RestoreComment(id, karmaWeight int) error {
+	return s.softDelete(commentVotes, id, nil, karmaWeight)
+}
@@ -0,0 +1,85 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"time"
+
+	"forum/internal/store"
+)
+
+func (s *Store) CreatePasswordReset(userID int, tokenHash string, expiresAt time.Time) error {
+	_, err := s.exec(`
+		INSERT INTO password_resets (user_id, token_hash, expires_at, created_at)
+		VALUES (?, ?, ?, ?)`, userID, tokenHash, expiresAt.UTC(), time.Now().UTC())
+	return err
+}
+
+func (s *Store) PasswordResetUser(tokenHash string) (int, error) {
+	var userID int
+	err := s.queryRow(`
+		SELECT user_id FROM password_resets
+		WHERE token_hash = ? AND used_at IS NULL AND expires_at > ?`,
+		tokenHash, time.Now().UTC()).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return 0, store.ErrNotFound
+	}
+	return userID, err
+}
+
+// ResetPassword claims the token with a conditional UPDATE, so a second use
+// finds it already used whichever order the transactions run in
+func (s *Store) ResetPassword(tokenHash, passwordHash string) (int, error) {
+	now := time.Now().UTC()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(s.db.Rebind(`
+		UPDATE password_resets SET used_at = ?
+		WHERE token_hash = ? AND used_at IS NULL AND expires_at > ?`), now, tokenHash, now)
+	if err != nil {
+		return 0, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return 0, err
+	} else if n == 0 {
+		return 0, store.ErrNotFound
+	}
+
+	var userID int
+	err = tx.QueryRow(s.db.Rebind(`SELECT user_id FROM password_resets WHERE token_hash = ?`),
+		tokenHash).Scan(&userID)
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.Exec(s.db.Rebind(`
+		UPDATE password_resets SET used_at = ? WHERE user_id = ? AND used_at IS NULL`), now, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec(s.db.Rebind(`UPDATE users SET password_hash = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`),
+		passwordHash, userID)
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.Exec(s.db.Rebind(`DELETE FROM sessions WHERE user_id = ?`), userID)
+	if err != nil {
+		return 0, err
+	}
+	return userID, tx.Commit()
+}
+
+func (s *Store) LastPasswordReset(userID int) (time.Time, error) {
+	var createdAt time.Time
+	err := s.queryRow(`
+		SELECT created_at FROM password_resets WHERE user_id = ?
+		ORDER BY created_at DESC LIMIT 1`, userID).Scan(&createdAt)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	return createdAt, err
+}
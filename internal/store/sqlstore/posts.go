@@ -0,0 +1,411 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"forum/internal/models"
+	"forum/internal/store"
+)
+
+// ListPosts replaces the old getRecentPosts/getMyPosts/getLikedPosts/...ByCategory
+// family: one query, with joins and WHERE clauses added per filter
+func (s *Store) ListPosts(filter store.PostFilter) ([]models.Post, error) {
+	posts, _, err := s.listPosts(filter, store.PageRequest{})
+	return posts, err
+}
+
+// ListPostsPage fetches one row more than the page size to learn whether
+// another page follows in the direction being read
+func (s *Store) ListPostsPage(filter store.PostFilter, page store.PageRequest) (*store.PostPage, error) {
+	posts, keys, err := s.listPosts(filter, page)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &store.PostPage{}
+	more := page.Limit > 0 && len(posts) > page.Limit
+	if more {
+		// Drop the extra row, which is the one furthest from the cursor
+		if page.After != nil {
+			posts, keys = posts[1:], keys[1:]
+		} else {
+			posts, keys = posts[:page.Limit], keys[:page.Limit]
+		}
+	}
+	result.Posts = posts
+	if len(posts) == 0 {
+		return result, nil
+	}
+
+	first, last := keys[0], keys[len(keys)-1]
+	switch {
+	case page.After != nil:
+		// Read backwards from a later page: there is always a next page
+		result.Next = &last
+		if more {
+			result.Prev = &first
+		}
+	case page.Before != nil:
+		result.Prev = &first
+		if more {
+			result.Next = &last
+		}
+	default:
+		if more {
+			result.Next = &last
+		}
+	}
+	return result, nil
+}
+
+// listPosts runs the listing query and returns each post with its sort key.
+// Rows are ordered by (pinned, time, id) descending; a page read with After
+// is queried ascending from the cursor and reversed afterwards.
+func (s *Store) listPosts(filter store.PostFilter, page store.PageRequest) ([]models.Post, []store.Cursor, error) {
+	var joins, where []string
+	var joinArgs, whereArgs []interface{}
+
+	if filter.CategoryID > 0 {
+		joins = append(joins, "JOIN post_categories pc ON p.id = pc.post_id")
+		where = append(where, "pc.category_id = ?")
+		whereArgs = append(whereArgs, filter.CategoryID)
+	}
+	if filter.AuthorID > 0 {
+		where = append(where, "p.user_id = ?")
+		whereArgs = append(whereArgs, filter.AuthorID)
+	}
+	if filter.Deleted {
+		where = append(where, "p.deleted_at IS NOT NULL")
+	} else {
+		where = append(where, "p.deleted_at IS NULL")
+	}
+	if filter.IDs != nil {
+		if len(filter.IDs) == 0 {
+			return nil, nil, nil
+		}
+		where = append(where, "p.id IN (?"+strings.Repeat(", ?", len(filter.IDs)-1)+")")
+		for _, id := range filter.IDs {
+			whereArgs = append(whereArgs, id)
+		}
+	}
+
+	// Sort key: pinned posts first (see PostFilter.Pins), then newest first
+	pins := filter.Pins()
+	pinned := "FALSE"
+	switch pins {
+	case store.CategoryPins:
+		pinned = "p.is_pinned"
+	case store.GlobalPins:
+		pinned = "p.pinned_globally"
+	}
+	sortTime := "p.created_at"
+	if filter.LikedBy > 0 {
+		joins = append(joins, "JOIN reactions lr ON lr.target_type = 'post' AND lr.target_id = p.id AND lr.user_id = ? AND lr.reaction = 'like'")
+		joinArgs = append(joinArgs, filter.LikedBy)
+		sortTime = "lr.created_at" // most recently liked first
+	}
+
+	key := "(" + pinned + ", " + sortTime + ", p.id)"
+	direction := "DESC"
+	switch {
+	case page.Before != nil:
+		where = append(where, key+" < (?, ?, ?)")
+		whereArgs = append(whereArgs, page.Before.Pinned, s.timeArg(page.Before.Time), page.Before.ID)
+	case page.After != nil:
+		where = append(where, key+" > (?, ?, ?)")
+		whereArgs = append(whereArgs, page.After.Pinned, s.timeArg(page.After.Time), page.After.ID)
+		direction = "ASC"
+	}
+	orderBy := pinned + " " + direction + ", " + sortTime + " " + direction + ", p.id " + direction
+	if pinned == "FALSE" {
+		orderBy = sortTime + " " + direction + ", p.id " + direction
+	}
+
+	// Counts are denormalized columns kept up to date by triggers (migration 0002)
+	query := `
+		SELECT p.id, p.title, p.content, p.user_id, p.is_pinned, p.pinned_globally, p.is_locked,
+		       p.view_count, p.created_at, u.username, u.karma,
+		       p.reply_count, p.like_count, p.dislike_count,
+		       p.deleted_at, p.deleted_by, du.username,
+		       upr.reaction = 'like' as user_vote,
+		       ` + sortTime + ` as sort_time
+		FROM posts p
+		JOIN users u ON p.user_id = u.id
+		LEFT JOIN users du ON p.deleted_by = du.id
+		LEFT JOIN reactions upr ON upr.target_type = 'post' AND upr.target_id = p.id
+			AND upr.user_id = ? AND upr.reaction IN ('like', 'dislike')
+		` + strings.Join(joins, "\n\t\t")
+
+	query += "\n\t\tWHERE " + strings.Join(where, " AND ")
+	query += "\n\t\tORDER BY " + orderBy
+
+	args := append([]interface{}{filter.ViewerID}, joinArgs...)
+	args = append(args, whereArgs...)
+
+	if page.Limit > 0 {
+		query += "\n\t\tLIMIT ?"
+		args = append(args, page.Limit+1)
+	}
+
+	rows, err := s.query(query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var posts []models.Post
+	var keys []store.Cursor
+	for rows.Next() {
+		var p models.Post
+		var deletedByName sql.NullString
+		var userVote sql.NullBool
+		var sortAt time.Time
+		err := rows.Scan(&p.ID, &p.Title, &p.Content, &p.UserID, &p.IsPinned, &p.IsPinnedGlobally,
+			&p.IsLocked, &p.ViewCount, &p.CreatedAt, &p.Username, &p.AuthorKarma, &p.ReplyCount,
+			&p.LikeCount, &p.DislikeCount,
+			&p.DeletedAt, &p.DeletedBy, &deletedByName, &userVote, &sortAt)
+		if err != nil {
+			return nil, nil, err
+		}
+		p.DeletedByName = deletedByName.String
+		p.HasVoted = userVote.Valid
+		if userVote.Valid {
+			p.IsLike = userVote.Bool
+		}
+		p.PinnedInList = pins.Pinned(p)
+		posts = append(posts, p)
+		keys = append(keys, store.Cursor{Pinned: p.PinnedInList, Time: sortAt.UTC(), ID: p.ID})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	rows.Close()
+
+	if direction == "ASC" {
+		for i, j := 0, len(posts)-1; i < j; i, j = i+1, j-1 {
+			posts[i], posts[j] = posts[j], posts[i]
+			keys[i], keys[j] = keys[j], keys[i]
+		}
+	}
+
+	if err := s.attachCategories(posts); err != nil {
+		return nil, nil, err
+	}
+	return posts, keys, nil
+}
+
+func (s *Store) GetPost(id, viewerID int) (*models.Post, error) {
+	query := `
+		SELECT p.id, p.title, p.content, p.user_id, p.is_pinned, p.pinned_globally, p.view_count,
+			   p.created_at, u.username, u.karma,
+			   p.reply_count, p.like_count, p.dislike_count,
+			   p.deleted_at, p.deleted_by, du.username, p.edited_at,
+			   p.is_locked, p.locked_at, p.locked_by, lu.username, p.lock_reason,
+			   upr.reaction = 'like' as user_vote
+		FROM posts p
+		JOIN users u ON p.user_id = u.id
+		LEFT JOIN users du ON p.deleted_by = du.id
+		LEFT JOIN users lu ON p.locked_by = lu.id
+		LEFT JOIN reactions upr ON upr.target_type = 'post' AND upr.target_id = p.id
+			AND upr.user_id = ? AND upr.reaction IN ('like', 'dislike')
+		WHERE p.id = ?`
+
+	var p models.Post
+	var deletedByName, lockedByName sql.NullString
+	var userVote sql.NullBool
+	err := s.queryRow(query, viewerID, id).Scan(&p.ID, &p.Title, &p.Content, &p.UserID,
+		&p.IsPinned, &p.IsPinnedGlobally, &p.ViewCount, &p.CreatedAt, &p.Username, &p.AuthorKarma,
+		&p.ReplyCount, &p.LikeCount, &p.DislikeCount,
+		&p.DeletedAt, &p.DeletedBy, &deletedByName, &p.EditedAt,
+		&p.IsLocked, &p.LockedAt, &p.LockedBy, &lockedByName, &p.LockReason, &userVote)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.DeletedByName = deletedByName.String
+	p.LockedByName = lockedByName.String
+	p.HasVoted = userVote.Valid
+	if userVote.Valid {
+		p.IsLike = userVote.Bool
+	}
+
+	posts := []models.Post{p}
+	if err := s.attachCategories(posts); err != nil {
+		return nil, err
+	}
+	return &posts[0], nil
+}
+
+func (s *Store) PostExists(id int) (bool, error) {
+	return s.exists(`SELECT 1 FROM posts WHERE id = ? AND deleted_at IS NULL LIMIT 1`, id)
+}
+
+func (s *Store) CreatePost(title, content string, userID int, categoryIDs []int) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO posts (title, content, user_id, created_at, updated_at)
+		VALUES (?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`
+
+	postID, err := s.insert(tx, query, title, content, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(categoryIDs) > 0 {
+		stmt, err := tx.Prepare(s.db.Rebind("INSERT INTO post_categories (post_id, category_id) VALUES (?, ?)"))
+		if err != nil {
+			return 0, err
+		}
+		defer stmt.Close()
+
+		for _, categoryID := range categoryIDs {
+			if _, err := stmt.Exec(postID, categoryID); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return postID, nil
+}
+
+func (s *Store) IncrementViewCount(id int) error {
+	_, err := s.exec(`UPDATE posts SET view_count = view_count + 1 WHERE id = ?`, id)
+	return err
+}
+
+func (s *Store) DeletePost(id, deletedBy, karmaWeight int) error {
+	return s.softDelete(postVotes, id, &deletedBy, karmaWeight)
+}
+
+func (s *Store) RestorePost(id, karmaWeight int) error {
+	return s.softDelete(postVotes, id, nil, karmaWeight)
+}
+
+func (s *Store) PinPost(id int, global bool) error {
+	return s.updateOne(`
+		UPDATE posts SET is_pinned = TRUE, pinned_globally = ?
+		WHERE id = ? AND deleted_at IS NULL`, global, id)
+}
+
+func (s *Store) UnpinPost(id int) error {
+	return s.updateOne(`
+		UPDATE posts SET is_pinned = FALSE, pinned_globally = FALSE
+		WHERE id = ? AND deleted_at IS NULL`, id)
+}
+
+func (s *Store) LockPost(id, lockedBy int, reason string) error {
+	return s.updateOne(`
+		UPDATE posts SET is_locked = TRUE, locked_at = CURRENT_TIMESTAMP, locked_by = ?, lock_reason = ?
+		WHERE id = ? AND deleted_at IS NULL`, lockedBy, reason, id)
+}
+
+func (s *Store) UnlockPost(id int) error {
+	return s.updateOne(`
+		UPDATE posts SET is_locked = FALSE, locked_at = NULL, locked_by = NULL, lock_reason = ''
+		WHERE id = ? AND deleted_at IS NULL`, id)
+}
+
+func (s *Store) UpdatePost(id int, title, content string, categoryIDs []int, editedBy int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Category names as they were before the edit
+	rows, err := tx.Query(s.db.Rebind(`
+		SELECT c.name FROM post_categories pc
+		JOIN categories c ON pc.category_id = c.id
+		WHERE pc.post_id = ?
+		ORDER BY c.name`), id)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Copy the current version; no row copied means no such (visible) post
+	result, err := tx.Exec(s.db.Rebind(`
+		INSERT INTO post_revisions (post_id, title, content, categories, edited_by, created_at)
+		SELECT id, title, content, ?, ?, CURRENT_TIMESTAMP
+		FROM posts WHERE id = ? AND deleted_at IS NULL`),
+		strings.Join(names, ", "), editedBy, id)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return store.ErrNotFound
+	}
+
+	if _, err := tx.Exec(s.db.Rebind(`
+		UPDATE posts SET title = ?, content = ?,
+		       updated_at = CURRENT_TIMESTAMP, edited_at = CURRENT_TIMESTAMP
+		WHERE id = ?`), title, content, id); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(s.db.Rebind(`DELETE FROM post_categories WHERE post_id = ?`), id); err != nil {
+		return err
+	}
+	for _, categoryID := range categoryIDs {
+		if _, err := tx.Exec(s.db.Rebind(`
+			INSERT INTO post_categories (post_id, category_id) VALUES (?, ?)`), id, categoryID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *Store) ListPostRevisions(postID int) ([]models.PostRevision, error) {
+	rows, err := s.query(`
+		SELECT r.id, r.post_id, r.title, r.content, r.categories,
+		       r.edited_by, u.username, r.created_at
+		FROM post_revisions r
+		LEFT JOIN users u ON r.edited_by = u.id
+		WHERE r.post_id = ?
+		ORDER BY r.id ASC`, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []models.PostRevision
+	for rows.Next() {
+		var r models.PostRevision
+		var editedByName sql.NullString
+		if err := rows.Scan(&r.ID, &r.PostID, &r.Title, &r.Content, &r.Categories,
+			&r.EditedBy, &editedByName, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		r.EditedByName = editedByName.String
+		revisions = append(revisions, r)
+	}
+	return revisions, rows.Err()
+}
@@ -0,0 +1,157 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mattn/go-sqlite3"
+
+	"forum/internal/database"
+	"forum/internal/models"
+	"forum/internal/store"
+)
+
+// listPostsQueries is what one listing page should cost: the posts query
+// plus one batched categories query, whatever the page size
+const listPostsQueries = 2
+
+// queryCounter counts every statement sent to SQLite through the
+// "sqlite3_counting" driver
+var queryCounter atomic.Int64
+
+var registerCountingDriver sync.Once
+
+type countingDriver struct{ driver.Driver }
+
+func (d countingDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return countingConn{conn.(*sqlite3.SQLiteConn)}, nil
+}
+
+type countingConn struct{ *sqlite3.SQLiteConn }
+
+func (c countingConn) Prepare(query string) (driver.Stmt, error) {
+	queryCounter.Add(1)
+	return c.SQLiteConn.Prepare(query)
+}
+
+func (c countingConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	queryCounter.Add(1)
+	return c.SQLiteConn.PrepareContext(ctx, query)
+}
+
+func (c countingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryCounter.Add(1)
+	return c.SQLiteConn.QueryContext(ctx, query, args)
+}
+
+func (c countingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	queryCounter.Add(1)
+	return c.SQLiteConn.ExecContext(ctx, query, args)
+}
+
+// newCountingStore returns a migrated store in a temporary SQLite file,
+// seeded with numPosts posts in two categories each
+func newCountingStore(tb testing.TB, numPosts int) *Store {
+	tb.Helper()
+
+	registerCountingDriver.Do(func() {
+		sql.Register("sqlite3_counting", countingDriver{&sqlite3.SQLiteDriver{}})
+	})
+
+	path := filepath.Join(tb.TempDir(), "forum.db")
+	sqlDB, err := sql.Open("sqlite3_counting", path+"?_foreign_keys=on&_journal_mode=WAL")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { sqlDB.Close() })
+
+	db := &database.DB{DB: sqlDB, Dialect: database.SQLite}
+	if err := database.RunMigrations(db); err != nil {
+		tb.Fatal(err)
+	}
+
+	s := New(db)
+	user := &models.User{UUID: "bench", Username: "bench", Email: "bench@example.com", PasswordHash: "x"}
+	if err := s.CreateUser(user); err != nil {
+		tb.Fatal(err)
+	}
+	for i := 0; i < numPosts; i++ {
+		categories := []int{1 + i%5, 1 + (i+1)%5}
+		if _, err := s.CreatePost(fmt.Sprintf("Post %d", i), "Benchmark content", user.ID, categories); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	return s
+}
+
+// countQueries runs fn and returns how many statements it sent to the database
+func countQueries(fn func()) int64 {
+	before := queryCounter.Load()
+	fn()
+	return queryCounter.Load() - before
+}
+
+func TestListPostsPageQueryCount(t *testing.T) {
+	s := newCountingStore(t, 120)
+
+	for _, size := range []int{1, 10, 100} {
+		var page *store.PostPage
+		var err error
+		queries := countQueries(func() {
+			page, err = s.ListPostsPage(store.PostFilter{}, store.PageRequest{Limit: size})
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Posts) != size {
+			t.Fatalf("page size %d: got %d posts", size, len(page.Posts))
+		}
+		if queries != listPostsQueries {
+			t.Errorf("page size %d: %d queries, want %d", size, queries, listPostsQueries)
+		}
+		for _, p := range page.Posts {
+			if len(p.Categories) != 2 || len(p.CategoryIDs) != 2 || len(p.CategorySlugs) != 2 {
+				t.Fatalf("post %d: categories not attached: %v", p.ID, p.Categories)
+			}
+		}
+	}
+}
+
+// BenchmarkListPostsPage reports queries/op for several page sizes;
+// it must stay at listPostsQueries no matter how many posts are on the page.
+func BenchmarkListPostsPage(b *testing.B) {
+	s := newCountingStore(b, 200)
+
+	for _, size := range []int{10, 50, 100} {
+		b.Run(fmt.Sprintf("page=%d", size), func(b *testing.B) {
+			page := store.PageRequest{Limit: size}
+			var err error
+			queries := countQueries(func() {
+				for i := 0; i < b.N; i++ {
+					if _, err = s.ListPostsPage(store.PostFilter{}, page); err != nil {
+						break
+					}
+				}
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			perOp := float64(queries) / float64(b.N)
+			b.ReportMetric(perOp, "queries/op")
+			if perOp != listPostsQueries {
+				b.Fatalf("%.1f queries per page, want %d", perOp, listPostsQueries)
+			}
+		})
+	}
+}
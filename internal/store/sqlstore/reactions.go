@@ -0,0 +1,114 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"forum/internal/models"
+)
+
+// reactionTables maps reactions.target_type to the table it points into
+var reactionTables = map[string]string{
+	models.ReactionTargetPost:    "posts",
+	models.ReactionTargetComment: "comments",
+}
+
+func (s *Store) ToggleReaction(r models.Reaction) (bool, error) {
+	table, ok := reactionTables[r.TargetType]
+	if !ok {
+		return false, fmt.Errorf("unknown reaction target type %q", r.TargetType)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if err := s.lockTarget(tx, table, r.TargetID); err != nil {
+		return false, err
+	}
+
+	var found int
+	err = tx.QueryRow(s.db.Rebind(`
+		SELECT 1 FROM reactions WHERE user_id = ? AND target_type = ? AND target_id = ? AND reaction = ?`),
+		r.UserID, r.TargetType, r.TargetID, r.Name).Scan(&found)
+	switch {
+	case err == sql.ErrNoRows:
+		_, err = tx.Exec(s.db.Rebind(`
+			INSERT INTO reactions (user_id, target_type, target_id, reaction) VALUES (?, ?, ?, ?)`),
+			r.UserID, r.TargetType, r.TargetID, r.Name)
+	case err == nil:
+		_, err = tx.Exec(s.db.Rebind(`
+			DELETE FROM reactions WHERE user_id = ? AND target_type = ? AND target_id = ? AND reaction = ?`),
+			r.UserID, r.TargetType, r.TargetID, r.Name)
+	}
+	if err != nil {
+		return false, err
+	}
+	return found == 0, tx.Commit()
+}
+
+func (s *Store) ReactionCounts(targetType string, targetIDs []int) (map[int]map[string]int, error) {
+	counts := make(map[int]map[string]int)
+	if len(targetIDs) == 0 {
+		return counts, nil
+	}
+
+	args := []interface{}{targetType}
+	for _, id := range targetIDs {
+		args = append(args, id)
+	}
+	rows, err := s.query(`
+		SELECT target_id, reaction, COUNT(*) FROM reactions
+		WHERE target_type = ? AND target_id IN (?`+strings.Repeat(", ?", len(targetIDs)-1)+`)
+		GROUP BY target_id, reaction`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, n int
+		var name string
+		if err := rows.Scan(&id, &name, &n); err != nil {
+			return nil, err
+		}
+		if counts[id] == nil {
+			counts[id] = make(map[string]int)
+		}
+		counts[id][name] = n
+	}
+	return counts, rows.Err()
+}
+
+func (s *Store) UserReactions(userID int, targetType string, targetIDs []int) (map[int][]string, error) {
+	mine := make(map[int][]string)
+	if userID == 0 || len(targetIDs) == 0 {
+		return mine, nil
+	}
+
+	args := []interface{}{userID, targetType}
+	for _, id := range targetIDs {
+		args = append(args, id)
+	}
+	rows, err := s.query(`
+		SELECT target_id, reaction FROM reactions
+		WHERE user_id = ? AND target_type = ? AND target_id IN (?`+strings.Repeat(", ?", len(targetIDs)-1)+`)
+		ORDER BY id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		mine[id] = append(mine[id], name)
+	}
+	return mine, rows.Err()
+}
@@ -0,0 +1,233 @@
+package sqlstore
+
+import (
+	"strconv"
+	"strings"
+
+	"forum/internal/database"
+	"forum/internal/store"
+)
+
+// Snippet lengths: in words for FTS5/ts_headline, in runes for the LIKE fallback
+const (
+	snippetWords = 24
+	snippetRunes = 160
+)
+
+// SearchPosts uses the full-text index when database.EnsureSearchIndex set it up,
+// and falls back to LIKE matching otherwise
+func (s *Store) SearchPosts(q store.SearchQuery) ([]store.SearchHit, error) {
+	terms := store.SearchTerms(q.Text)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	switch {
+	case !s.db.FullTextSearch:
+		return s.searchLike(q, terms)
+	case s.db.Dialect == database.Postgres:
+		return s.searchPostgres(q, terms)
+	default:
+		return s.searchFTS5(q, terms)
+	}
+}
+
+// searchFilters builds the category/author restrictions on posts p joined with users u.
+// Deleted posts are always left out.
+func searchFilters(q store.SearchQuery) (string, []interface{}) {
+	where := []string{"p.deleted_at IS NULL"}
+	var args []interface{}
+
+	if q.CategoryID > 0 {
+		where = append(where, "EXISTS (SELECT 1 FROM post_categories pc WHERE pc.post_id = p.id AND pc.category_id = ?)")
+		args = append(args, q.CategoryID)
+	}
+	if q.Author != "" {
+		where = append(where, "u.username = ?")
+		args = append(args, q.Author)
+	}
+
+	return "WHERE " + strings.Join(where, " AND "), args
+}
+
+// searchFTS5 ranks matches with bm25 (titles weigh 10x content) and lets
+// FTS5 build the snippet. A post is listed once, with its best hit.
+func (s *Store) searchFTS5(q store.SearchQuery, terms []string) ([]store.SearchHit, error) {
+	// "term"* is a prefix query; terms are letters/digits only, so quoting is safe
+	quoted := make([]string, len(terms))
+	for i, t := range terms {
+		quoted[i] = `"` + t + `"*`
+	}
+	match := strings.Join(quoted, " ")
+
+	where, whereArgs := searchFilters(q)
+	query := `
+		WITH hits AS (
+			SELECT posts_fts.rowid AS post_id,
+			       bm25(posts_fts, 10.0, 1.0) AS rank,
+			       snippet(posts_fts, -1, char(2), char(3), '…', ` + strconv.Itoa(snippetWords) + `) AS snippet,
+			       0 AS in_comment
+			FROM posts_fts
+			WHERE posts_fts MATCH ?
+			UNION ALL
+			SELECT c.post_id,
+			       bm25(comments_fts),
+			       snippet(comments_fts, 0, char(2), char(3), '…', ` + strconv.Itoa(snippetWords) + `),
+			       1
+			FROM comments_fts
+			JOIN comments c ON c.id = comments_fts.rowid
+			WHERE comments_fts MATCH ? AND c.deleted_at IS NULL
+		)
+		SELECT h.post_id, h.snippet, h.in_comment, MIN(h.rank) AS best
+		FROM hits h
+		JOIN posts p ON p.id = h.post_id
+		JOIN users u ON u.id = p.user_id
+		` + where + `
+		GROUP BY h.post_id
+		ORDER BY best, h.post_id DESC
+		LIMIT ?`
+
+	args := append([]interface{}{match, match}, whereArgs...)
+	args = append(args, limitOrDefault(q.Limit))
+	return s.scanHits(query, args...)
+}
+
+// searchPostgres matches with to_tsquery against the expression indexes from
+// database.EnsureSearchIndex and builds snippets with ts_headline
+func (s *Store) searchPostgres(q store.SearchQuery, terms []string) ([]store.SearchHit, error) {
+	prefixed := make([]string, len(terms))
+	for i, t := range terms {
+		prefixed[i] = t + ":*"
+	}
+	tsquery := strings.Join(prefixed, " & ")
+
+	headline := `'StartSel=' || chr(2) || ', StopSel=' || chr(3) || ', MaxWords=` + strconv.Itoa(snippetWords) + `, MinWords=10'`
+
+	where, whereArgs := searchFilters(q)
+	query := `
+		WITH hits AS (
+			SELECT p.id AS post_id,
+			       ts_rank(setweight(to_tsvector('simple', p.title), 'A') ||
+			               setweight(to_tsvector('simple', p.content), 'D'), tq) AS rank,
+			       ts_headline('simple', p.title || ' ' || p.content, tq, ` + headline + `) AS snippet,
+			       FALSE AS in_comment
+			FROM posts p, to_tsquery('simple', ?) tq
+			WHERE to_tsvector('simple', p.title || ' ' || p.content) @@ tq
+			UNION ALL
+			SELECT c.post_id,
+			       ts_rank(to_tsvector('simple', c.content), tq),
+			       ts_headline('simple', c.content, tq, ` + headline + `),
+			       TRUE
+			FROM comments c, to_tsquery('simple', ?) tq
+			WHERE to_tsvector('simple', c.content) @@ tq AND c.deleted_at IS NULL
+		),
+		best AS (
+			SELECT DISTINCT ON (post_id) post_id, snippet, in_comment, rank
+			FROM hits
+			ORDER BY post_id, rank DESC
+		)
+		SELECT h.post_id, h.snippet, h.in_comment, h.rank
+		FROM best h
+		JOIN posts p ON p.id = h.post_id
+		JOIN users u ON u.id = p.user_id
+		` + where + `
+		ORDER BY h.rank DESC, h.post_id DESC
+		LIMIT ?`
+
+	args := append([]interface{}{tsquery, tsquery}, whereArgs...)
+	args = append(args, limitOrDefault(q.Limit))
+	return s.scanHits(query, args...)
+}
+
+func (s *Store) scanHits(query string, args ...interface{}) ([]store.SearchHit, error) {
+	rows, err := s.query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hits []store.SearchHit
+	for rows.Next() {
+		var h store.SearchHit
+		var rank float64
+		if err := rows.Scan(&h.PostID, &h.Snippet, &h.InComment, &rank); err != nil {
+			return nil, err
+		}
+		hits = append(hits, h)
+	}
+	return hits, rows.Err()
+}
+
+// searchLike is used when the SQLite driver has no FTS5. Every term must
+// appear in the post title, content or one of its comments; results are
+// newest first and snippets are built in Go.
+func (s *Store) searchLike(q store.SearchQuery, terms []string) ([]store.SearchHit, error) {
+	where, args := searchFilters(q)
+	for _, t := range terms {
+		where += " AND (p.title LIKE ? OR p.content LIKE ? OR EXISTS (" +
+			"SELECT 1 FROM comments c WHERE c.post_id = p.id AND c.deleted_at IS NULL AND c.content LIKE ?))"
+		pattern := "%" + t + "%"
+		args = append(args, pattern, pattern, pattern)
+	}
+
+	query := `
+		SELECT p.id, p.title, p.content
+		FROM posts p
+		JOIN users u ON u.id = p.user_id
+		` + where + `
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT ?`
+	args = append(args, limitOrDefault(q.Limit))
+
+	rows, err := s.query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type candidate struct {
+		id             int
+		title, content string
+	}
+	var candidates []candidate
+	for rows.Next() {
+		var c candidate
+		if err := rows.Scan(&c.id, &c.title, &c.content); err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	hits := make([]store.SearchHit, 0, len(candidates))
+	for _, c := range candidates {
+		hit := store.SearchHit{PostID: c.id}
+		text := c.title + " - " + c.content
+		if !store.ContainsAnyTerm(text, terms) {
+			// The match is in a comment; show the first one that contains a term
+			var comment string
+			err := s.queryRow(`
+				SELECT content FROM comments
+				WHERE post_id = ? AND deleted_at IS NULL AND content LIKE ?
+				ORDER BY created_at ASC LIMIT 1`, c.id, "%"+terms[0]+"%").Scan(&comment)
+			if err == nil {
+				text = comment
+				hit.InComment = true
+			}
+		}
+		hit.Snippet = store.MakeSnippet(text, terms, snippetRunes)
+		hits = append(hits, hit)
+	}
+	return hits, nil
+}
+
+// limitOrDefault caps the number of search results
+func limitOrDefault(limit int) int {
+	if limit <= 0 || limit > 100 {
+		return 50
+	}
+	return limit
+}
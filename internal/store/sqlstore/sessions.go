@@ -0,0 +1,43 @@
+package sqlstore
+
+import (
+	"time"
+
+	"forum/internal/models"
+)
+
+func (s *Store) CreateSession(token string, userID int, expiresAt time.Time) error {
+	query := `
+		INSERT INTO sessions (token, user_id, expires_at, created_at)
+		VALUES (?, ?, ?, CURRENT_TIMESTAMP)`
+
+	// Timestamps are compared in UTC so both dialects order them the same way
+	_, err := s.exec(query, token, userID, expiresAt.UTC())
+	return err
+}
+
+func (s *Store) GetSessionUser(token string) (*models.User, error) {
+	query := `
+		SELECT u.id, u.uuid, u.username, u.email, u.avatar_url, u.is_admin, u.karma, u.created_at,
+			u.email_verified_at
+		FROM users u
+		JOIN sessions s ON u.id = s.user_id
+		WHERE s.token = ? AND s.expires_at > ?`
+
+	return s.scanUser(s.queryRow(query, token, time.Now().UTC()))
+}
+
+func (s *Store) DeleteSession(token string) error {
+	_, err := s.exec(`DELETE FROM sessions WHERE token = ?`, token)
+	return err
+}
+
+func (s *Store) DeleteUserSessions(userID int) error {
+	_, err := s.exec(`DELETE FROM sessions WHERE user_id = ?`, userID)
+	return err
+}
+
+func (s *Store) DeleteExpiredSessions() error {
+	_, err := s.exec(`DELETE FROM sessions WHERE expires_at < ?`, time.Now().UTC())
+	return err
+}
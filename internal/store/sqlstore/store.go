@@ -0,0 +1,117 @@
+// Package sqlstore implements the store interfaces on top of database/sql.
+// Queries are written with ? placeholders and portable SQL, then rebound
+// for the active dialect (SQLite or PostgreSQL).
+package sqlstore
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"forum/internal/database"
+	"forum/internal/store"
+)
+
+// Store implements every store interface against a single database handle
+type Store struct {
+	db *database.DB
+}
+
+// Compile-time checks that Store satisfies every store interface
+var (
+	_ store.PostStore          = (*Store)(nil)
+	_ store.CommentStore       = (*Store)(nil)
+	_ store.CategoryStore      = (*Store)(nil)
+	_ store.UserStore          = (*Store)(nil)
+	_ store.SessionStore       = (*Store)(nil)
+	_ store.VoteStore          = (*Store)(nil)
+	_ store.ReactionStore      = (*Store)(nil)
+	_ store.SearchStore        = (*Store)(nil)
+	_ store.AuditStore         = (*Store)(nil)
+	_ store.PasswordResetStore = (*Store)(nil)
+)
+
+func New(db *database.DB) *Store {
+	return &Store{db: db}
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+func (s *Store) query(query string, args ...interface{}) (*sql.Rows, error) {
+	return s.db.Query(s.db.Rebind(query), args...)
+}
+
+func (s *Store) queryRow(query string, args ...interface{}) *sql.Row {
+	return s.db.QueryRow(s.db.Rebind(query), args...)
+}
+
+func (s *Store) exec(query string, args ...interface{}) (sql.Result, error) {
+	return s.db.Exec(s.db.Rebind(query), args...)
+}
+
+// insert runs an INSERT and returns the new row's id.
+// PostgreSQL has no LastInsertId, so it uses RETURNING id instead.
+func (s *Store) insert(ex execer, query string, args ...interface{}) (int64, error) {
+	if s.db.Dialect == database.Postgres {
+		var id int64
+		err := ex.QueryRow(s.db.Rebind(query)+" RETURNING id", args...).Scan(&id)
+		return id, err
+	}
+
+	result, err := ex.Exec(s.db.Rebind(query), args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// timeArg converts a time for comparison with a DATETIME/TIMESTAMPTZ column.
+// SQLite stores CURRENT_TIMESTAMP as "YYYY-MM-DD HH:MM:SS" text, so the
+// argument must use the same layout to compare correctly.
+func (s *Store) timeArg(t time.Time) interface{} {
+	if s.db.Dialect == database.Postgres {
+		return t
+	}
+	return t.UTC().Format("2006-01-02 15:04:05")
+}
+
+// isUniqueViolation reports whether err is a UNIQUE constraint failure in either dialect
+func isUniqueViolation(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "UNIQUE constraint failed") || // SQLite
+		strings.Contains(msg, "duplicate key value violates unique constraint") // PostgreSQL
+}
+
+// updateOne runs an UPDATE that should change exactly one row,
+// returning store.ErrNotFound when it matched none
+func (s *Store) updateOne(query string, args ...interface{}) error {
+	result, err := s.exec(query, args...)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+// exists runs a "SELECT 1 ... LIMIT 1" style query and reports whether it returned a row
+func (s *Store) exists(query string, args ...interface{}) (bool, error) {
+	var exists int
+	err := s.queryRow(query, args...).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
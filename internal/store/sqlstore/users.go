@@ -0,0 +1,242 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"forum/internal/models"
+	"forum/internal/store"
+)
+
+func (s *Store) CreateUser(user *models.User) error {
+	query := `
+		INSERT INTO users (uuid, username, email, password_hash, created_at, updated_at)
+		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`
+
+	id, err := s.insert(s.db, query, user.UUID, user.Username, user.Email, user.PasswordHash)
+	if err != nil {
+		return userConflict(err)
+	}
+
+	user.ID = int(id)
+	return nil
+}
+
+// userConflict turns a UNIQUE violation on users into ErrUsernameTaken or
+// ErrEmailTaken; other errors are returned as they are
+func userConflict(err error) error {
+	if isUniqueViolation(err) {
+		if strings.Contains(err.Error(), "username") {
+			return store.ErrUsernameTaken
+		}
+		if strings.Contains(err.Error(), "email") {
+			return store.ErrEmailTaken
+		}
+	}
+	return err
+}
+
+func (s *Store) GetUserByID(id int) (*models.User, error) {
+	query := `SELECT id, uuid, username, email, avatar_url, is_admin, karma, created_at, email_verified_at
+			  FROM users WHERE id = ?`
+	return s.scanUser(s.queryRow(query, id))
+}
+
+func (s *Store) GetUserByLogin(login string) (*models.User, error) {
+	var user models.User
+	query := `SELECT id, uuid, username, email, password_hash, is_admin, created_at 
+			  FROM users WHERE username = ? OR email = ?`
+
+	err := s.queryRow(query, login, login).Scan(
+		&user.ID, &user.UUID, &user.Username, &user.Email,
+		&user.PasswordHash, &user.IsAdmin, &user.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (s *Store) GetUserIDByUsername(username string) (int, error) {
+	var id int
+	err := s.queryRow(`SELECT id FROM users WHERE username = ?`, username).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, store.ErrNotFound
+	}
+	return id, err
+}
+
+// GetUserStats sums the denormalized counters of the user's visible posts and comments
+func (s *Store) GetUserStats(userID int) (*models.UserStats, error) {
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM posts WHERE user_id = ? AND deleted_at IS NULL),
+			(SELECT COUNT(*) FROM comments c JOIN posts p ON c.post_id = p.id
+			 WHERE c.user_id = ? AND c.deleted_at IS NULL AND p.deleted_at IS NULL),
+			(SELECT COALESCE(SUM(like_count), 0) FROM posts WHERE user_id = ? AND deleted_at IS NULL)
+			+ (SELECT COALESCE(SUM(c.like_count), 0) FROM comments c JOIN posts p ON c.post_id = p.id
+			   WHERE c.user_id = ? AND c.deleted_at IS NULL AND p.deleted_at IS NULL),
+			(SELECT COALESCE(SUM(dislike_count), 0) FROM posts WHERE user_id = ? AND deleted_at IS NULL)
+			+ (SELECT COALESCE(SUM(c.dislike_count), 0) FROM comments c JOIN posts p ON c.post_id = p.id
+			   WHERE c.user_id = ? AND c.deleted_at IS NULL AND p.deleted_at IS NULL)`
+
+	var st models.UserStats
+	err := s.queryRow(query, userID, userID, userID, userID, userID, userID).Scan(
+		&st.PostCount, &st.CommentCount, &st.LikesReceived, &st.DislikesReceived)
+	if err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// scanUser scans the public user columns (id, uuid, username, email,
+// avatar_url, is_admin, karma, created_at, email_verified_at)
+func (s *Store) scanUser(row *sql.Row) (*models.User, error) {
+	var user models.User
+	var avatarURL sql.NullString // Use sql.NullString for nullable fields
+	var verifiedAt sql.NullTime
+
+	err := row.Scan(
+		&user.ID, &user.UUID, &user.Username, &user.Email,
+		&avatarURL, &user.IsAdmin, &user.Karma, &user.CreatedAt, &verifiedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if avatarURL.Valid {
+		user.AvatarURL = avatarURL.String
+	}
+	if verifiedAt.Valid {
+		user.EmailVerifiedAt = &verifiedAt.Time
+	}
+	return &user, nil
+}
+
+// UpdateAvatar sets a user's avatar URL, storing NULL for ""
+func (s *Store) UpdateAvatar(userID int, avatarURL string) error {
+	avatar := sql.NullString{String: avatarURL, Valid: avatarURL != ""}
+	res, err := s.exec(`UPDATE users SET avatar_url = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, avatar, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) UpdatePassword(userID int, passwordHash string) error {
+	return s.updateUser(`UPDATE users SET password_hash = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
+		passwordHash, userID)
+}
+
+// UpdateEmail also clears the verification of the old address and its
+// resend throttle, so a link for the new one can be sent right away
+func (s *Store) UpdateEmail(userID int, email string) error {
+	return s.updateUser(`
+		UPDATE users SET email = ?, email_verified_at = NULL, verification_sent_at = NULL,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`, email, userID)
+}
+
+func (s *Store) VerifyEmail(userID int, email string, at time.Time) error {
+	return s.updateUser(`UPDATE users SET email_verified_at = ? WHERE id = ? AND email = ?`,
+		at.UTC(), userID, email)
+}
+
+func (s *Store) SetVerificationSent(userID int, at time.Time) error {
+	return s.updateUser(`UPDATE users SET verification_sent_at = ? WHERE id = ?`, at.UTC(), userID)
+}
+
+func (s *Store) VerificationSent(userID int) (time.Time, error) {
+	var sentAt sql.NullTime
+	err := s.queryRow(`SELECT verification_sent_at FROM users WHERE id = ?`, userID).Scan(&sentAt)
+	if err == sql.ErrNoRows {
+		return time.Time{}, store.ErrNotFound
+	}
+	return sentAt.Time, err
+}
+
+// CountContentSince counts the user's posts and comments created after
+// since; created_at is set by CURRENT_TIMESTAMP, hence timeArg
+func (s *Store) CountContentSince(userID int, since time.Time) (int, error) {
+	var n int
+	err := s.queryRow(`
+		SELECT (SELECT COUNT(*) FROM posts WHERE user_id = ? AND created_at > ?)
+			+ (SELECT COUNT(*) FROM comments WHERE user_id = ? AND created_at > ?)`,
+		userID, s.timeArg(since), userID, s.timeArg(since)).Scan(&n)
+	return n, err
+}
+
+// updateUser runs an UPDATE of one user, returning ErrNotFound if the user
+// is missing and ErrUsernameTaken/ErrEmailTaken on conflicts
+func (s *Store) updateUser(query string, args ...interface{}) error {
+	res, err := s.exec(query, args...)
+	if err != nil {
+		return userConflict(err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+// ChangeUsername updates the name and records the old one in one transaction
+func (s *Store) ChangeUsername(userID int, username string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var old string
+	err = tx.QueryRow(s.db.Rebind(`SELECT username FROM users WHERE id = ?`), userID).Scan(&old)
+	if err == sql.ErrNoRows {
+		return store.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(s.db.Rebind(`UPDATE users SET username = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`),
+		username, userID)
+	if err != nil {
+		return userConflict(err)
+	}
+	_, err = tx.Exec(s.db.Rebind(`INSERT INTO username_history (user_id, username, changed_at) VALUES (?, ?, ?)`),
+		userID, old, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *Store) LastUsernameChange(userID int) (time.Time, error) {
+	var changedAt time.Time
+	err := s.queryRow(`
+		SELECT changed_at FROM username_history WHERE user_id = ?
+		ORDER BY changed_at DESC LIMIT 1`, userID).Scan(&changedAt)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	return changedAt, err
+}
+
+func (s *Store) UsernameReleasedBy(username string, since time.Time) (int, error) {
+	var userID int
+	err := s.queryRow(`
+		SELECT user_id FROM username_history WHERE username = ? AND changed_at > ?
+		ORDER BY changed_at DESC LIMIT 1`, username, since.UTC()).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return userID, err
+}
@@ -0,0 +1,215 @@
+package sqlstore
+
+import (
+	"database/sql"
+
+	"forum/internal/database"
+	"forum/internal/models"
+	"forum/internal/store"
+)
+
+// voteTarget names what a vote points at
+type voteTarget struct {
+	targetType string // reactions.target_type
+	table      string // Table holding the like_count/dislike_count kept by triggers
+}
+
+var (
+	postVotes    = voteTarget{targetType: models.ReactionTargetPost, table: "posts"}
+	commentVotes = voteTarget{targetType: models.ReactionTargetComment, table: "comments"}
+)
+
+// voteQuery selects a user's vote on a target as a boolean (true = like)
+const voteQuery = `
+	SELECT reaction = 'like' FROM reactions
+	WHERE user_id = ? AND target_type = ? AND target_id = ? AND reaction IN ('like', 'dislike')`
+
+func (s *Store) GetPostVote(userID, postID int) (*bool, error) {
+	return s.getVote(voteQuery, userID, models.ReactionTargetPost, postID)
+}
+
+func (s *Store) TogglePostVote(userID, postID int, isLike bool, karmaWeight int) (store.VoteResult, error) {
+	return s.changeVote(postVotes, userID, postID, &isLike, karmaWeight)
+}
+
+func (s *Store) ClearPostVote(userID, postID, karmaWeight int) (store.VoteResult, error) {
+	return s.changeVote(postVotes, userID, postID, nil, karmaWeight)
+}
+
+func (s *Store) PostVoteCounts(postID int) (likes int, dislikes int, err error) {
+	err = s.queryRow(`SELECT like_count, dislike_count FROM posts WHERE id = ?`, postID).Scan(&likes, &dislikes)
+	if err == sql.ErrNoRows {
+		return 0, 0, nil
+	}
+	return likes, dislikes, err
+}
+
+func (s *Store) GetCommentVote(userID, commentID int) (*bool, error) {
+	return s.getVote(voteQuery, userID, models.ReactionTargetComment, commentID)
+}
+
+func (s *Store) ToggleCommentVote(userID, commentID int, isLike bool, karmaWeight int) (store.VoteResult, error) {
+	return s.changeVote(commentVotes, userID, commentID, &isLike, karmaWeight)
+}
+
+func (s *Store) ClearCommentVote(userID, commentID, karmaWeight int) (store.VoteResult, error) {
+	return s.changeVote(commentVotes, userID, commentID, nil, karmaWeight)
+}
+
+// changeVote reads the user's vote, applies the change, moves the author's
+// karma and reads the fresh counts in one transaction. A nil vote clears the
+// user's vote; otherwise the same vote toggles off and a missing or opposite
+// vote is replaced.
+func (s *Store) changeVote(t voteTarget, userID, targetID int, vote *bool, karmaWeight int) (store.VoteResult, error) {
+	var res store.VoteResult
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return res, err
+	}
+	defer tx.Rollback()
+
+	if err := s.lockTarget(tx, t.table, targetID); err != nil {
+		return res, err
+	}
+
+	var current bool
+	err = tx.QueryRow(s.db.Rebind(voteQuery), userID, t.targetType, targetID).Scan(&current)
+	switch {
+	case err == sql.ErrNoRows:
+		err = nil
+	case err != nil:
+		return res, err
+	default:
+		res.Before = &current
+	}
+
+	switch {
+	case vote == nil || (res.Before != nil && *res.Before == *vote):
+		if res.Before != nil {
+			_, err = tx.Exec(s.db.Rebind(`
+				DELETE FROM reactions
+				WHERE user_id = ? AND target_type = ? AND target_id = ? AND reaction IN ('like', 'dislike')`),
+				userID, t.targetType, targetID)
+		}
+	case res.Before != nil:
+		// Switching keeps the row, and so the time of the first vote
+		res.Vote = vote
+		_, err = tx.Exec(s.db.Rebind(`
+			UPDATE reactions SET reaction = ?
+			WHERE user_id = ? AND target_type = ? AND target_id = ? AND reaction IN ('like', 'dislike')`),
+			voteName(*vote), userID, t.targetType, targetID)
+	default:
+		res.Vote = vote
+		_, err = tx.Exec(s.db.Rebind(`
+			INSERT INTO reactions (user_id, target_type, target_id, reaction) VALUES (?, ?, ?, ?)`),
+			userID, t.targetType, targetID, voteName(*vote))
+	}
+	if err != nil {
+		return res, err
+	}
+
+	// Votes on one's own posts and comments, or on deleted ones, don't count
+	if delta := res.Change() * karmaWeight; delta != 0 {
+		_, err = tx.Exec(s.db.Rebind(`
+			UPDATE users SET karma = karma + ?
+			WHERE id = (SELECT user_id FROM `+t.table+` WHERE id = ? AND deleted_at IS NULL) AND id <> ?`),
+			delta, targetID, userID)
+		if err != nil {
+			return res, err
+		}
+	}
+
+	err = tx.QueryRow(s.db.Rebind(`SELECT like_count, dislike_count FROM `+t.table+` WHERE id = ?`),
+		targetID).Scan(&res.Likes, &res.Dislikes)
+	if err != nil {
+		return res, err
+	}
+	return res, tx.Commit()
+}
+
+// softDelete deletes (deletedBy set) or restores a post or comment. Votes on
+// deleted content don't count towards karma, so in the same transaction it
+// takes the target's score, times karmaWeight, from its author's karma or
+// gives it back.
+func (s *Store) softDelete(t voteTarget, id int, deletedBy *int, karmaWeight int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var result sql.Result
+	sign := 1
+	if deletedBy != nil {
+		sign = -1
+		result, err = tx.Exec(s.db.Rebind(`
+			UPDATE `+t.table+` SET deleted_at = CURRENT_TIMESTAMP, deleted_by = ?
+			WHERE id = ? AND deleted_at IS NULL`), *deletedBy, id)
+	} else {
+		result, err = tx.Exec(s.db.Rebind(`
+			UPDATE `+t.table+` SET deleted_at = NULL, deleted_by = NULL
+			WHERE id = ? AND deleted_at IS NOT NULL`), id)
+	}
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return store.ErrNotFound
+	}
+
+	if karmaWeight != 0 {
+		_, err = tx.Exec(s.db.Rebind(`
+			UPDATE users SET karma = karma + ? * (
+				SELECT COALESCE(SUM(CASE reaction WHEN 'like' THEN 1 ELSE -1 END), 0) FROM reactions
+				WHERE target_type = ? AND target_id = ? AND user_id <> users.id AND reaction IN ('like', 'dislike'))
+			WHERE id = (SELECT user_id FROM `+t.table+` WHERE id = ?)`),
+			sign*karmaWeight, t.targetType, id, id)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// lockTarget makes changes to the reactions on a post or comment run one at
+// a time: SQLite transactions hold the write lock from the start
+// (_txlock=immediate), PostgreSQL locks the row. It returns ErrNotFound if
+// the target is missing.
+func (s *Store) lockTarget(tx *sql.Tx, table string, id int) error {
+	lock := `SELECT 1 FROM ` + table + ` WHERE id = ?`
+	if s.db.Dialect == database.Postgres {
+		lock += ` FOR UPDATE`
+	}
+	var found int
+	err := tx.QueryRow(s.db.Rebind(lock), id).Scan(&found)
+	if err == sql.ErrNoRows {
+		return store.ErrNotFound
+	}
+	return err
+}
+
+// voteName is the reaction stored for a vote
+func voteName(isLike bool) string {
+	if isLike {
+		return models.ReactionLike
+	}
+	return models.ReactionDislike
+}
+
+// getVote scans a single boolean vote column (nil = no vote)
+func (s *Store) getVote(query string, args ...interface{}) (*bool, error) {
+	var isLike bool
+	err := s.queryRow(query, args...).Scan(&isLike)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &isLike, nil
+}
@@ -0,0 +1,360 @@
+package sqlstore
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"forum/internal/database"
+	"forum/internal/models"
+	"forum/internal/store"
+)
+
+// newVoteStore returns a migrated store in a temporary SQLite file, opened
+// the way the server opens it, with numUsers users and one post
+func newVoteStore(t *testing.T, numUsers int) (s *Store, userIDs []int, postID int) {
+	t.Helper()
+
+	db, err := database.InitDB(filepath.Join(t.TempDir(), "forum.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := database.RunMigrations(db); err != nil {
+		t.Fatal(err)
+	}
+
+	s = New(db)
+	for i := 0; i < numUsers; i++ {
+		name := fmt.Sprintf("voter%d", i)
+		user := &models.User{UUID: name, Username: name, Email: name + "@example.com", PasswordHash: "x"}
+		if err := s.CreateUser(user); err != nil {
+			t.Fatal(err)
+		}
+		userIDs = append(userIDs, user.ID)
+	}
+	id, err := s.CreatePost("Vote target", "Content to vote on", userIDs[0], []int{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, userIDs, int(id)
+}
+
+// checkCounts compares the post's counts with the votes actually stored
+func checkCounts(t *testing.T, s *Store, postID, wantLikes, wantDislikes int) {
+	t.Helper()
+
+	likes, dislikes, err := s.PostVoteCounts(postID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rowLikes, rowDislikes int
+	err = s.queryRow(`
+		SELECT COUNT(CASE WHEN reaction = 'like' THEN 1 END), COUNT(CASE WHEN reaction = 'dislike' THEN 1 END)
+		FROM reactions WHERE target_type = 'post' AND target_id = ?`, postID).Scan(&rowLikes, &rowDislikes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if likes != rowLikes || dislikes != rowDislikes {
+		t.Errorf("counts %d/%d disagree with stored votes %d/%d", likes, dislikes, rowLikes, rowDislikes)
+	}
+	if wantLikes >= 0 && (likes != wantLikes || dislikes != wantDislikes) {
+		t.Errorf("counts = %d/%d, want %d/%d", likes, dislikes, wantLikes, wantDislikes)
+	}
+}
+
+func TestTogglePostVoteStates(t *testing.T) {
+	s, users, postID := newVoteStore(t, 1)
+	like, dislike := true, false
+
+	steps := []struct {
+		name     string
+		change   func() (store.VoteResult, error)
+		before   *bool
+		vote     *bool
+		likes    int
+		dislikes int
+	}{
+		{"like", func() (store.VoteResult, error) { return s.TogglePostVote(users[0], postID, true, 0) }, nil, &like, 1, 0},
+		{"like again", func() (store.VoteResult, error) { return s.TogglePostVote(users[0], postID, true, 0) }, &like, nil, 0, 0},
+		{"dislike", func() (store.VoteResult, error) { return s.TogglePostVote(users[0], postID, false, 0) }, nil, &dislike, 0, 1},
+		{"switch to like", func() (store.VoteResult, error) { return s.TogglePostVote(users[0], postID, true, 0) }, &dislike, &like, 1, 0},
+		{"clear", func() (store.VoteResult, error) { return s.ClearPostVote(users[0], postID, 0) }, &like, nil, 0, 0},
+		{"clear again", func() (store.VoteResult, error) { return s.ClearPostVote(users[0], postID, 0) }, nil, nil, 0, 0},
+	}
+
+	name := func(v *bool) string {
+		switch {
+		case v == nil:
+			return "none"
+		case *v:
+			return "like"
+		default:
+			return "dislike"
+		}
+	}
+	for _, step := range steps {
+		res, err := step.change()
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if name(res.Before) != name(step.before) || name(res.Vote) != name(step.vote) {
+			t.Errorf("%s: vote %s -> %s, want %s -> %s", step.name,
+				name(res.Before), name(res.Vote), name(step.before), name(step.vote))
+		}
+		if res.Likes != step.likes || res.Dislikes != step.dislikes {
+			t.Errorf("%s: counts %d/%d, want %d/%d", step.name, res.Likes, res.Dislikes, step.likes, step.dislikes)
+		}
+	}
+
+	if _, err := s.TogglePostVote(users[0], 999999, true, 0); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("voting on a missing post: err = %v, want ErrNotFound", err)
+	}
+}
+
+// TestTogglePostVoteConcurrent has every user toggle a like from several
+// goroutines at once, as rapid repeated clicks do. Toggles must not fail or
+// be lost: a user who toggled an odd number of times ends up liking the post.
+func TestTogglePostVoteConcurrent(t *testing.T) {
+	const (
+		numUsers   = 10
+		goroutines = 4 // Per user
+		toggles    = 5 // Per goroutine
+	)
+	s, users, postID := newVoteStore(t, numUsers)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, numUsers*goroutines)
+	for i, userID := range users {
+		for g := 0; g < goroutines; g++ {
+			n := toggles
+			if i%2 == 1 && g == 0 {
+				n++ // Odd-numbered users toggle once more
+			}
+			wg.Add(1)
+			go func(userID, n int) {
+				defer wg.Done()
+				for k := 0; k < n; k++ {
+					res, err := s.TogglePostVote(userID, postID, true, 0)
+					if err != nil {
+						errs <- err
+						return
+					}
+					if res.Likes < 0 || res.Likes > numUsers || res.Dislikes != 0 {
+						errs <- fmt.Errorf("impossible counts %d/%d", res.Likes, res.Dislikes)
+						return
+					}
+				}
+			}(userID, n)
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	checkCounts(t, s, postID, numUsers/2, 0)
+	for i, userID := range users {
+		vote, err := s.GetPostVote(userID, postID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if liked := vote != nil && *vote; liked != (i%2 == 1) {
+			t.Errorf("user %d: liked = %v, want %v", i, liked, i%2 == 1)
+		}
+	}
+}
+
+// TestMixedPostVotesConcurrent mixes likes, dislikes and clears from many
+// goroutines; whatever the final votes are, the counts must match them
+func TestMixedPostVotesConcurrent(t *testing.T) {
+	const (
+		numUsers   = 8
+		goroutines = 3 // Per user
+		changes    = 12
+	)
+	s, users, postID := newVoteStore(t, numUsers)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, numUsers*goroutines)
+	for _, userID := range users {
+		for g := 0; g < goroutines; g++ {
+			wg.Add(1)
+			go func(userID, g int) {
+				defer wg.Done()
+				for k := 0; k < changes; k++ {
+					var err error
+					switch (g + k) % 3 {
+					case 0:
+						_, err = s.TogglePostVote(userID, postID, true, 0)
+					case 1:
+						_, err = s.TogglePostVote(userID, postID, false, 0)
+					default:
+						_, err = s.ClearPostVote(userID, postID, 0)
+					}
+					if err != nil {
+						errs <- err
+						return
+					}
+				}
+			}(userID, g)
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	checkCounts(t, s, postID, -1, -1)
+}
+
+// TestReactionsBesideVotes checks that other reactions neither count as votes
+// nor get in the way of one
+func TestReactionsBesideVotes(t *testing.T) {
+	s, users, postID := newVoteStore(t, 2)
+	love := models.Reaction{UserID: users[0], TargetType: models.ReactionTargetPost, TargetID: postID, Name: "love"}
+
+	if set, err := s.ToggleReaction(love); err != nil || !set {
+		t.Fatalf("adding a reaction: set = %v, err = %v", set, err)
+	}
+	if _, err := s.TogglePostVote(users[0], postID, true, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.TogglePostVote(users[1], postID, false, 0); err != nil {
+		t.Fatal(err)
+	}
+	checkCounts(t, s, postID, 1, 1)
+
+	counts, err := s.ReactionCounts(models.ReactionTargetPost, []int{postID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"like": 1, "dislike": 1, "love": 1}
+	for name, n := range want {
+		if counts[postID][name] != n {
+			t.Errorf("%s count = %d, want %d", name, counts[postID][name], n)
+		}
+	}
+	mine, err := s.UserReactions(users[0], models.ReactionTargetPost, []int{postID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := mine[postID]; len(got) != 2 || got[0] != "love" || got[1] != "like" {
+		t.Errorf("user reactions = %v, want [love like]", got)
+	}
+
+	if set, err := s.ToggleReaction(love); err != nil || set {
+		t.Fatalf("removing a reaction: set = %v, err = %v", set, err)
+	}
+	if _, err := s.ClearPostVote(users[0], postID, 0); err != nil {
+		t.Fatal(err)
+	}
+	checkCounts(t, s, postID, 0, 1)
+
+	love.TargetID = 999999
+	if _, err := s.ToggleReaction(love); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("reacting to a missing post: err = %v, want ErrNotFound", err)
+	}
+}
+
+// TestVoteKarma checks that vote changes move the author's karma by the
+// weight, that votes on one's own post don't, and that deleting the post
+// takes its votes back until it is restored
+func TestVoteKarma(t *testing.T) {
+	s, users, postID := newVoteStore(t, 2)
+
+	steps := []struct {
+		name  string
+		vote  func() (store.VoteResult, error)
+		karma int
+	}{
+		{"like", func() (store.VoteResult, error) { return s.TogglePostVote(users[1], postID, true, 2) }, 2},
+		{"switch to dislike", func() (store.VoteResult, error) { return s.TogglePostVote(users[1], postID, false, 2) }, -2},
+		{"clear", func() (store.VoteResult, error) { return s.ClearPostVote(users[1], postID, 2) }, 0},
+		{"own like", func() (store.VoteResult, error) { return s.TogglePostVote(users[0], postID, true, 2) }, 0},
+		{"like again", func() (store.VoteResult, error) { return s.TogglePostVote(users[1], postID, true, 2) }, 2},
+		{"delete", func() (store.VoteResult, error) { return store.VoteResult{}, s.DeletePost(postID, users[0], 2) }, 0},
+		{"clear while deleted", func() (store.VoteResult, error) { return s.ClearPostVote(users[1], postID, 2) }, 0},
+		{"like while deleted", func() (store.VoteResult, error) { return s.TogglePostVote(users[1], postID, true, 2) }, 0},
+		{"restore", func() (store.VoteResult, error) { return store.VoteResult{}, s.RestorePost(postID, 2) }, 2},
+	}
+	for _, step := range steps {
+		if _, err := step.vote(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		author, err := s.GetUserByID(users[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if author.Karma != step.karma {
+			t.Errorf("%s: author karma = %d, want %d", step.name, author.Karma, step.karma)
+		}
+	}
+}
+
+func TestRebuildKarma(t *testing.T) {
+	s, users, postID := newVoteStore(t, 4)
+	commentID, err := s.CreateComment("A comment to vote on", users[1], postID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// users[0] wrote the post: two likes and a dislike from others, and
+	// their own like, which doesn't count. A weight of 0 leaves karma alone,
+	// like votes cast before karma existed.
+	for i, like := range []bool{true, true, true, false} {
+		if _, err := s.TogglePostVote(users[i], postID, like, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// users[1] wrote the comment: one like
+	if _, err := s.ToggleCommentVote(users[0], int(commentID), true, 0); err != nil {
+		t.Fatal(err)
+	}
+	// Drift that the rebuild has to undo
+	if _, err := s.exec(`UPDATE users SET karma = 5 WHERE id = ?`, users[2]); err != nil {
+		t.Fatal(err)
+	}
+
+	changed, err := database.RebuildKarma(s.db, 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed != 3 {
+		t.Errorf("rebuild changed %d users, want 3", changed)
+	}
+
+	for i, want := range []int{2, 1, 0, 0} {
+		user, err := s.GetUserByID(users[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if user.Karma != want {
+			t.Errorf("user %d karma = %d, want %d", i, user.Karma, want)
+		}
+	}
+
+	if changed, err := database.RebuildKarma(s.db, 2, 1); err != nil || changed != 0 {
+		t.Errorf("second rebuild: changed = %d, err = %v, want 0, nil", changed, err)
+	}
+
+	// Deleting the comment takes its like back, and the rebuild agrees
+	if err := s.DeleteComment(int(commentID), users[1], 1); err != nil {
+		t.Fatal(err)
+	}
+	user, err := s.GetUserByID(users[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Karma != 0 {
+		t.Errorf("after deleting the comment: karma = %d, want 0", user.Karma)
+	}
+	if changed, err := database.RebuildKarma(s.db, 2, 1); err != nil || changed != 0 {
+		t.Errorf("rebuild after delete: changed = %d, err = %v, want 0, nil", changed, err)
+	}
+}
@@ -0,0 +1,271 @@
+// Package store defines the persistence interfaces used by handlers and services.
+// Implementations live in the sqlstore (database/sql) and memory subpackages.
+package store
+
+import (
+	"errors"
+	"time"
+
+	"forum/internal/models"
+)
+
+var (
+	// ErrNotFound is returned when the requested row does not exist
+	ErrNotFound = errors.New("not found")
+
+	// ErrUsernameTaken and ErrEmailTaken are returned by UserStore.CreateUser,
+	// UpdateEmail and ChangeUsername when a UNIQUE constraint is violated
+	ErrUsernameTaken = errors.New("username already exists")
+	ErrEmailTaken    = errors.New("email already exists")
+)
+
+// PostFilter selects which posts ListPosts returns.
+// Zero values mean "no restriction".
+type PostFilter struct {
+	CategoryID int   // Only posts in this category
+	AuthorID   int   // Only posts created by this user ("my posts")
+	LikedBy    int   // Only posts liked by this user, most recently liked first
+	IDs        []int // Only these posts (e.g. search hits); non-nil but empty matches nothing
+	Deleted    bool  // Only soft-deleted posts (moderation view); otherwise they are left out
+	ViewerID   int   // User whose vote state is attached to each post
+}
+
+// PinOrder says which pins sort posts to the top of a listing
+type PinOrder int
+
+const (
+	NoPins       PinOrder = iota // Personal, search and moderation listings
+	CategoryPins                 // A category: posts pinned in their categories
+	GlobalPins                   // The home page: globally pinned posts
+)
+
+// Pins returns the pin order of the listing the filter selects
+func (f PostFilter) Pins() PinOrder {
+	switch {
+	case f.AuthorID > 0 || f.LikedBy > 0 || f.IDs != nil || f.Deleted:
+		return NoPins
+	case f.CategoryID > 0:
+		return CategoryPins
+	default:
+		return GlobalPins
+	}
+}
+
+// Pinned reports whether the post sorts with the pinned posts under this order
+func (o PinOrder) Pinned(p models.Post) bool {
+	switch o {
+	case CategoryPins:
+		return p.IsPinned
+	case GlobalPins:
+		return p.IsPinnedGlobally
+	default:
+		return false
+	}
+}
+
+// PostStore reads and writes posts
+type PostStore interface {
+	ListPosts(filter PostFilter) ([]models.Post, error)
+	// ListPostsPage returns one page of the same listing, using keyset pagination
+	ListPostsPage(filter PostFilter, page PageRequest) (*PostPage, error)
+	// GetPost also returns soft-deleted posts; callers check IsDeleted
+	GetPost(id, viewerID int) (*models.Post, error)
+	// PostExists reports whether the post exists and is not deleted
+	PostExists(id int) (bool, error)
+	CreatePost(title, content string, userID int, categoryIDs []int) (int64, error)
+	IncrementViewCount(id int) error
+	// DeletePost soft-deletes a post and takes the votes on it, times
+	// karmaWeight, back from its author's karma (ErrNotFound if missing or
+	// already deleted)
+	DeletePost(id, deletedBy, karmaWeight int) error
+	// RestorePost undoes DeletePost, karma included (ErrNotFound if the post
+	// is not deleted)
+	RestorePost(id, karmaWeight int) error
+	// UpdatePost saves the current version of a post as a revision, then
+	// replaces its title, content and categories (ErrNotFound if missing or deleted)
+	UpdatePost(id int, title, content string, categoryIDs []int, editedBy int) error
+	// ListPostRevisions returns the earlier versions of a post, oldest first
+	ListPostRevisions(postID int) ([]models.PostRevision, error)
+	// PinPost pins a post to the top of its categories, and of the home page
+	// if global (ErrNotFound if missing or deleted)
+	PinPost(id int, global bool) error
+	// UnpinPost removes both pins (ErrNotFound if missing or deleted)
+	UnpinPost(id int) error
+	// LockPost stops new comments and votes on a post (ErrNotFound if missing or deleted)
+	LockPost(id, lockedBy int, reason string) error
+	// UnlockPost undoes LockPost (ErrNotFound if missing or deleted)
+	UnlockPost(id int) error
+}
+
+// CommentStore reads and writes comments
+type CommentStore interface {
+	// ListComments returns all comments on a post, soft-deleted ones included,
+	// so the thread keeps its shape; callers show a placeholder for those
+	ListComments(postID, viewerID int) ([]models.Comment, error)
+	// ListDeletedComments returns soft-deleted comments, most recently deleted first
+	ListDeletedComments() ([]models.Comment, error)
+	// ListUserComments returns a user's most recent comments, leaving out
+	// deleted comments and comments on deleted posts
+	ListUserComments(userID, limit int) ([]models.Comment, error)
+	// GetComment also returns soft-deleted comments
+	GetComment(id int) (*models.Comment, error)
+	// CommentExists reports whether the comment and its post exist and are not deleted
+	CommentExists(id int) (bool, error)
+	// CreateComment adds a comment to a post, as a reply to parentID if it is set
+	CreateComment(content string, userID, postID int, parentID *int) (int64, error)
+	// UpdateComment replaces a comment's content and sets updated_at
+	// (ErrNotFound if missing or deleted)
+	UpdateComment(id int, content string) error
+	// DeleteComment soft-deletes a comment and takes the votes on it, times
+	// karmaWeight, back from its author's karma (ErrNotFound if missing or
+	// already deleted)
+	DeleteComment(id, deletedBy, karmaWeight int) error
+	// RestoreComment undoes DeleteComment, karma included (ErrNotFound if the
+	// comment is not deleted)
+	RestoreComment(id, karmaWeight int) error
+}
+
+// CategoryStore reads categories
+type CategoryStore interface {
+	ListCategories() ([]models.Category, error)
+	GetCategoryBySlug(slug string) (*models.Category, error)
+}
+
+// UserStore reads and writes user accounts
+type UserStore interface {
+	// CreateUser inserts the user and sets its ID
+	CreateUser(user *models.User) error
+	GetUserByID(id int) (*models.User, error)
+	// GetUserByLogin looks a user up by username or email, including the password hash
+	GetUserByLogin(login string) (*models.User, error)
+	// GetUserIDByUsername looks up a user's ID by exact username (ErrNotFound if missing)
+	GetUserIDByUsername(username string) (int, error)
+	// GetUserStats counts the user's posts and comments that are not deleted
+	// and the likes and dislikes those received
+	GetUserStats(userID int) (*models.UserStats, error)
+	// UpdateAvatar sets a user's avatar URL; "" removes the avatar
+	UpdateAvatar(userID int, avatarURL string) error
+	UpdatePassword(userID int, passwordHash string) error
+	// UpdateEmail sets a new email address, which is unverified until confirmed
+	UpdateEmail(userID int, email string) error
+	// VerifyEmail marks the user's email verified at the given time, provided
+	// it is still email (ErrNotFound otherwise)
+	VerifyEmail(userID int, email string, at time.Time) error
+	// SetVerificationSent records when a verification email was sent
+	SetVerificationSent(userID int, at time.Time) error
+	// VerificationSent is when a verification email was last sent (zero if never)
+	VerificationSent(userID int) (time.Time, error)
+	// CountContentSince counts the posts and comments, deleted ones included,
+	// that the user created after since
+	CountContentSince(userID int, since time.Time) (int, error)
+	// ChangeUsername renames a user and records the old name in username_history
+	ChangeUsername(userID int, username string) error
+	// LastUsernameChange is when the user last renamed themselves (zero if never)
+	LastUsernameChange(userID int) (time.Time, error)
+	// UsernameReleasedBy returns the user who gave up username after since,
+	// most recently, or 0 if nobody did
+	UsernameReleasedBy(username string, since time.Time) (int, error)
+}
+
+// SessionStore reads and writes login sessions
+type SessionStore interface {
+	CreateSession(token string, userID int, expiresAt time.Time) error
+	// GetSessionUser returns the owner of a session that has not expired
+	GetSessionUser(token string) (*models.User, error)
+	DeleteSession(token string) error
+	DeleteUserSessions(userID int) error
+	DeleteExpiredSessions() error
+}
+
+// VoteStore reads and writes like/dislike votes (reactions "like" and "dislike").
+// A nil *bool means "no vote", true is a like and false a dislike.
+//
+// ToggleXVote applies the vote state machine in one transaction: no vote ->
+// set, same vote -> remove (toggle off), opposite vote -> switch. ClearXVote
+// removes the user's vote. In the same transaction both move the author's
+// karma by the change times karmaWeight (unless the voter is the author),
+// and both return ErrNotFound if the target is missing.
+type VoteStore interface {
+	GetPostVote(userID, postID int) (*bool, error)
+	TogglePostVote(userID, postID int, isLike bool, karmaWeight int) (VoteResult, error)
+	ClearPostVote(userID, postID, karmaWeight int) (VoteResult, error)
+	PostVoteCounts(postID int) (likes int, dislikes int, err error)
+
+	GetCommentVote(userID, commentID int) (*bool, error)
+	ToggleCommentVote(userID, commentID int, isLike bool, karmaWeight int) (VoteResult, error)
+	ClearCommentVote(userID, commentID, karmaWeight int) (VoteResult, error)
+}
+
+// ReactionStore reads and writes reactions on posts and comments. Likes and
+// dislikes are stored as the reactions "like" and "dislike" but are changed
+// through VoteStore, which keeps them to one per user and target.
+type ReactionStore interface {
+	// ToggleReaction adds the reaction, or removes it if the user already
+	// gave it, and reports whether it is now set (ErrNotFound if the target is missing)
+	ToggleReaction(r models.Reaction) (bool, error)
+	// ReactionCounts returns how many users gave each reaction to each of
+	// the targets: target ID -> reaction name -> count
+	ReactionCounts(targetType string, targetIDs []int) (map[int]map[string]int, error)
+	// UserReactions returns the reactions the user gave to each of the targets
+	UserReactions(userID int, targetType string, targetIDs []int) (map[int][]string, error)
+}
+
+// VoteResult is the outcome of a vote change
+type VoteResult struct {
+	Before   *bool // The user's vote before the change
+	Vote     *bool // The user's vote after it
+	Likes    int   // The target's counts after the change
+	Dislikes int
+}
+
+// Change is the difference the change made to the target's score: a like
+// counts 1 and a dislike -1, so switching from a dislike to a like is 2
+func (r VoteResult) Change() int {
+	return voteValue(r.Vote) - voteValue(r.Before)
+}
+
+func voteValue(v *bool) int {
+	switch {
+	case v == nil:
+		return 0
+	case *v:
+		return 1
+	default:
+		return -1
+	}
+}
+
+// AuditFilter selects which events ListAuditEvents returns.
+// Zero values mean "no restriction".
+type AuditFilter struct {
+	ActorName  string // Only events by this username
+	Action     string // Only this action, e.g. "post.delete"
+	TargetType string // Only events on this kind of row
+	TargetID   int    // Only events on this row (with TargetType)
+	BeforeID   int    // Only events older than this one (paging)
+	Limit      int    // Maximum number of events; 0 means no limit
+}
+
+// PasswordResetStore keeps password reset tokens, looked up by their hash.
+// A token is valid while it is unused and not expired.
+type PasswordResetStore interface {
+	CreatePasswordReset(userID int, tokenHash string, expiresAt time.Time) error
+	// PasswordResetUser returns the user of a valid token (ErrNotFound otherwise)
+	PasswordResetUser(tokenHash string) (int, error)
+	// ResetPassword sets the password hash of a valid token's user, ends
+	// all of their sessions and marks the token used, along with every other
+	// unused token of the user, all or nothing. It returns the user
+	// (ErrNotFound if the token is not valid). Only one of several
+	// concurrent calls succeeds.
+	ResetPassword(tokenHash, passwordHash string) (int, error)
+	// LastPasswordReset is when a token was last created for the user (zero if never)
+	LastPasswordReset(userID int) (time.Time, error)
+}
+
+// AuditStore appends to and reads the audit log
+type AuditStore interface {
+	// RecordAuditEvent inserts the event and sets its ID
+	RecordAuditEvent(event *models.AuditEvent) error
+	// ListAuditEvents returns matching events, newest first
+	ListAuditEvents(filter AuditFilter) ([]models.AuditEvent, error)
+}
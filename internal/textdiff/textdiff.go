@@ -0,0 +1,126 @@
+// Package textdiff computes line-based differences between two texts,
+// for showing what changed between post revisions.
+package textdiff
+
+import "strings"
+
+// Op says whether a line is unchanged, removed or added
+type Op int
+
+const (
+	Equal Op = iota
+	Delete
+	Insert
+)
+
+// Line is one line of a diff
+type Line struct {
+	Op   Op
+	Text string
+}
+
+// IsDelete and IsInsert are for templates, which cannot compare Op values
+func (l Line) IsDelete() bool { return l.Op == Delete }
+func (l Line) IsInsert() bool { return l.Op == Insert }
+
+// maxCells bounds the LCS table (lines of old * lines of new). Post content
+// is at most 10,000 characters, so real edits stay far below it; past it the
+// whole text is shown as replaced rather than spending the memory.
+const maxCells = 4 << 20
+
+// Lines returns the diff from old to new, using the longest common
+// subsequence of their lines. Removed lines come before added ones.
+func Lines(old, new string) []Line {
+	a, b := split(old), split(new)
+
+	// Trim the common prefix and suffix, which is most of a typical edit
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var diff []Line
+	for _, text := range a[:prefix] {
+		diff = append(diff, Line{Equal, text})
+	}
+	diff = append(diff, middle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, text := range a[len(a)-suffix:] {
+		diff = append(diff, Line{Equal, text})
+	}
+	return diff
+}
+
+// Changed reports whether a diff contains any removed or added lines
+func Changed(diff []Line) bool {
+	for _, l := range diff {
+		if l.Op != Equal {
+			return true
+		}
+	}
+	return false
+}
+
+// middle diffs the part of the texts between the common prefix and suffix
+func middle(a, b []string) []Line {
+	var diff []Line
+	if len(a)*len(b) > maxCells {
+		for _, text := range a {
+			diff = append(diff, Line{Delete, text})
+		}
+		for _, text := range b {
+			diff = append(diff, Line{Insert, text})
+		}
+		return diff
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			diff = append(diff, Line{Equal, a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, Line{Delete, a[i]})
+			i++
+		default:
+			diff = append(diff, Line{Insert, b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		diff = append(diff, Line{Delete, a[i]})
+	}
+	for ; j < len(b); j++ {
+		diff = append(diff, Line{Insert, b[j]})
+	}
+	return diff
+}
+
+// split breaks text into lines, treating \r\n (as sent by browsers) like \n
+func split(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+}
@@ -312,7 +312,9 @@ func ValidatePostTitle(title string) (bool, string) {
 	return true, ""
 }
 
-// ValidatePostContent checks if post content is valid and safe
+// ValidatePostContent checks if post content is valid and safe. Content is
+// Markdown, and the length limits apply to the source as typed, not to the
+// rendered HTML (tabs count as one character).
 func ValidatePostContent(content string) (bool, string) {
 	// Clean dangerous Unicode only (preserves spaces)
 	cleaned := CleanText(content)
@@ -321,9 +323,6 @@ func ValidatePostContent(content string) (bool, string) {
 	cleaned = strings.ReplaceAll(cleaned, "\r\n", "\n") // Windows → Unix
 	cleaned = strings.ReplaceAll(cleaned, "\r", "\n")   // Old Mac → Unix
 
-	// ✅ NEW: Convert tabs to spaces for consistent display
-	cleaned = strings.ReplaceAll(cleaned, "\t", "    ") // Tab → 4 spaces
-
 	// ✅ NEW: Check for leading/trailing spaces FIRST
 	if cleaned != strings.TrimSpace(cleaned) {
 		return false, "Content cannot have spaces at the beginning or end"
@@ -351,7 +350,8 @@ func ValidatePostContent(content string) (bool, string) {
 	return true, ""
 }
 
-// ValidateCommentContent checks if comment content is valid and safe
+// ValidateCommentContent checks if comment content is valid and safe.
+// Like posts, the length limits apply to the Markdown source.
 func ValidateCommentContent(content string) (bool, string) {
 	// Clean dangerous Unicode only (preserves spaces)
 	cleaned := CleanText(content)
@@ -360,9 +360,6 @@ func ValidateCommentContent(content string) (bool, string) {
 	cleaned = strings.ReplaceAll(cleaned, "\r\n", "\n") // Windows → Unix
 	cleaned = strings.ReplaceAll(cleaned, "\r", "\n")   // Old Mac → Unix
 
-	// ✅ NEW: Convert tabs to spaces for consistent display
-	cleaned = strings.ReplaceAll(cleaned, "\t", "    ") // Tab → 4 spaces
-
 	// ✅ NEW: Check for leading/trailing spaces FIRST
 	if cleaned != strings.TrimSpace(cleaned) {
 		return false, "Comment cannot have spaces at the beginning or end"
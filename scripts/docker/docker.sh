@@ -22,6 +22,12 @@ case "$1" in
         ;;
     
     run)
+        if [ -z "$EMAIL_VERIFY_SECRET" ]; then
+            echo -e "${RED}❌ EMAIL_VERIFY_SECRET is not set${NC}"
+            echo -e "${BLUE}💡 Generate it once (openssl rand -hex 32), keep it and export it before running${NC}"
+            exit 1
+        fi
+
         echo -e "${BLUE}🚀 Starting container with local database...${NC}"
         
         # Check for existing container and remove it (matches Makefile behavior)
@@ -49,6 +55,7 @@ case "$1" in
             -e PORT=$PORT \
             -e DATABASE_URL=/app/data/forum.db \
             -e JWT_SECRET=dev-secret \
+            -e EMAIL_VERIFY_SECRET="$EMAIL_VERIFY_SECRET" \
             -e TZ=Asia/Almaty \
             $IMAGE_NAME
         
@@ -34,7 +34,7 @@ if [ ! -f "$DB_FILE" ]; then
 fi
 
 # Build WHERE clause for all test username patterns
-# Patterns from all 12 test suites:
+# Patterns from all 13 test suites:
 # - testuser* (test_forum.sh, test_forum_endpoints.sh, test_backend_validation.sh, test_comment_routes.sh)
 # - validuser* (test_validation.sh)
 # - pwtest* (test_password_validation.sh)
@@ -42,6 +42,21 @@ fi
 # - methodtest* (test_http_methods.sh)
 # - reqtest* (test_post_required_fields.sh)
 # - cattest* (test_category.sh)
+# - searchtest* (test_search.sh)
+# - softdel* (test_soft_delete.sh)
+# - audit* (test_audit.sh)
+# - postedit* (test_post_edit.sh)
+# - pin* (test_pin.sh)
+# - lock* (test_lock.sh)
+# - mdtest* (test_markdown.sh)
+# - thr* (test_threads.sh)
+# - cedit* (test_comment_edit.sh)
+# - react* (test_reactions.sh)
+# - vjson* (test_vote_json.sh)
+# - karma* (test_karma.sh)
+# - prof* (test_profile.sh)
+# - avtr* (test_avatar.sh)
+# - acct* (test_account.sh)
 # - valid_user* (additional pattern from test_validation.sh)
 
 WHERE_CLAUSE="username LIKE 'testuser%' 
@@ -51,6 +66,23 @@ WHERE_CLAUSE="username LIKE 'testuser%'
     OR username LIKE 'methodtest%' 
     OR username LIKE 'reqtest%' 
     OR username LIKE 'cattest%'
+    OR username LIKE 'searchtest%'
+    OR username LIKE 'softdel%'
+    OR username LIKE 'audit%'
+    OR username LIKE 'postedit%'
+    OR username LIKE 'pin%'
+    OR username LIKE 'lock%'
+    OR username LIKE 'mdtest%'
+    OR username LIKE 'thr%'
+    OR username LIKE 'cedit%'
+    OR username LIKE 'react%'
+    OR username LIKE 'vjson%'
+    OR username LIKE 'karma%'
+    OR username LIKE 'prof%'
+    OR username LIKE 'avtr%'
+    OR username LIKE 'acct%'
+    OR username LIKE 'pwrs%'
+    OR username LIKE 'verif%'
     OR username LIKE 'valid_user%'"
 
 # Count test users before cleanup
@@ -84,6 +116,9 @@ if [[ $REPLY =~ ^[Yy]$ ]]; then
     # Delete sessions first (foreign key constraint)
     sqlite3 "$DB_FILE" "DELETE FROM sessions WHERE user_id IN (SELECT id FROM users WHERE $WHERE_CLAUSE);"
     
+    # Keep their audit events but detach them (same as ON DELETE SET NULL)
+    sqlite3 "$DB_FILE" "UPDATE audit_events SET actor_id = NULL WHERE actor_id IN (SELECT id FROM users WHERE $WHERE_CLAUSE);" 2>/dev/null
+
     # Delete users
     sqlite3 "$DB_FILE" "DELETE FROM users WHERE $WHERE_CLAUSE;"
     
@@ -107,4 +142,21 @@ echo "  • sessiontest* (test_sessions)"
 echo "  • methodtest*  (test_http_methods)"
 echo "  • reqtest*     (test_post_required_fields)"
 echo "  • cattest*     (test_category)"
+echo "  • searchtest*  (test_search)"
+echo "  • softdel*     (test_soft_delete)"
+echo "  • audit*       (test_audit)"
+echo "  • postedit*    (test_post_edit)"
+echo "  • pin*         (test_pin)"
+echo "  • lock*        (test_lock)"
+echo "  • mdtest*      (test_markdown)"
+echo "  • thr*         (test_threads)"
+echo "  • cedit*       (test_comment_edit)"
+echo "  • react*       (test_reactions)"
+echo "  • vjson*       (test_vote_json)"
+echo "  • karma*       (test_karma)"
+echo "  • prof*        (test_profile)"
+echo "  • avtr*        (test_avatar)"
+echo "  • acct*        (test_account)"
+echo "  • pwrs*        (test_password_reset)"
+echo "  • verif*       (test_email_verification)"
 echo "  • valid_user*  (test_validation alternate pattern)"
\ No newline at end of file
@@ -0,0 +1,144 @@
+#!/bin/bash
+
+# Helpers shared by the test suites. Source it first:
+#
+#   source "$(dirname "$0")/lib.sh"
+#
+# then call require_server, create users with register and login, run
+# checks with check (or result, for checks of your own) and end with finish.
+
+# Color codes
+RED='\033[0;31m'
+GREEN='\033[0;32m'
+YELLOW='\033[1;33m'
+BLUE='\033[0;34m'
+NC='\033[0m'
+
+BASE_URL="http://localhost:8080"
+PASSWORD="Test123!"   # Password of every account made by register
+
+PASS=0
+FAIL=0
+
+# require_server exits unless the forum answers at BASE_URL
+require_server() {
+    echo -e "${BLUE}[SETUP]${NC} Checking server..."
+    if ! curl -s -o /dev/null "$BASE_URL/"; then
+        echo -e "${RED}✗${NC} Server not running"
+        exit 1
+    fi
+    echo -e "${GREEN}✓${NC} Server is running"
+}
+
+# require_db exits unless DB_FILE names a throwaway copy of the server's
+# SQLite database, which suites read or change with sqlite3 (e.g. to make a
+# test user admin). The repository's own forum.db is refused.
+require_db() {
+    if [ -z "$DB_FILE" ]; then
+        echo -e "${RED}✗${NC} DB_FILE is not set (point it at a throwaway copy of the server's database)"
+        exit 1
+    fi
+    if [ ! -f "$DB_FILE" ]; then
+        echo -e "${RED}✗${NC} $DB_FILE not found (set DB_FILE to the server's database)"
+        exit 1
+    fi
+    local repo_db
+    repo_db="$(cd "$(dirname "${BASH_SOURCE[0]}")/../.." && pwd)/forum.db"
+    if [ "$(realpath "$DB_FILE")" = "$(realpath -m "$repo_db")" ]; then
+        echo -e "${RED}✗${NC} Refusing to change $repo_db; run the server on a throwaway copy and set DB_FILE to it"
+        exit 1
+    fi
+}
+
+# register USER...: creates accounts with PASSWORD and the email USER@test.com
+register() {
+    local user
+    for user in "$@"; do
+        curl -s -X POST "$BASE_URL/register" \
+            -d "username=${user}&email=${user}@test.com&password=${PASSWORD}&confirm_password=${PASSWORD}" \
+            > /dev/null 2>&1
+    done
+}
+
+# login USER COOKIES: logs USER in, saving the session to the cookie file
+login() {
+    curl -s -c "$2" -X POST "$BASE_URL/login" \
+        -d "username=${1}&password=${PASSWORD}" > /dev/null 2>&1
+}
+
+# make_admin USER: makes an account admin (call require_db first)
+make_admin() {
+    sqlite3 "$DB_FILE" "UPDATE users SET is_admin = TRUE WHERE username = '$1';"
+}
+
+# result NUM DESC OK: prints and counts the outcome of a check ("yes" passes)
+result() {
+    if [ "$3" = "yes" ]; then
+        echo -e "  ${GREEN}✓ PASS${NC}"
+        PASS=$((PASS + 1))
+    else
+        echo -e "  ${RED}✗ FAIL${NC}"
+        FAIL=$((FAIL + 1))
+    fi
+    echo ""
+}
+
+# check NUM DESC COOKIES METHOD URL EXPECTED_STATUS [PATTERN] [present|absent] [CURL_ARG...]
+# COOKIES is a cookie file (also updated from the response), or "" for an
+# anonymous request. PATTERN is looked for in the body, or in the redirect
+# target of a 3xx. Any further arguments go to curl, e.g. -d DATA.
+check() {
+    local num="$1"
+    local desc="$2"
+    local cookies="$3"
+    local method="$4"
+    local url="$5"
+    local expected="$6"
+    local pattern="$7"
+    local mode="${8:-present}"
+    shift 8 2>/dev/null || shift $#
+
+    echo "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"
+    echo -e "${YELLOW}Test $num: $desc${NC}"
+    echo "  URL: $method $url"
+
+    local args=()
+    [ -n "$cookies" ] && args=(-b "$cookies" -c "$cookies")
+    RESPONSE=$(curl -s "${args[@]}" "$@" -X "$method" \
+        -w "\nHTTP_STATUS:%{http_code}\nREDIRECT:%{redirect_url}" "$url")
+    STATUS=$(echo "$RESPONSE" | grep "HTTP_STATUS" | cut -d: -f2)
+
+    local ok="yes"
+    [ "$STATUS" = "$expected" ] || ok="no"
+    if [ -n "$pattern" ]; then
+        if echo "$RESPONSE" | grep -q -- "$pattern"; then
+            [ "$mode" = "present" ] || ok="no"
+        else
+            [ "$mode" = "absent" ] || ok="no"
+        fi
+        echo "  Pattern ($mode): $pattern"
+    fi
+    echo "  Status: $STATUS (expected $expected)"
+    result "$num" "$desc" "$ok"
+}
+
+# finish [FILE...]: removes the files (cookie jars and the like), prints the
+# summary and exits with 1 if any check failed
+finish() {
+    rm -rf "$@"
+
+    echo "========================================="
+    echo "SUMMARY"
+    echo "========================================="
+    echo "Total:  $((PASS + FAIL))"
+    echo -e "${GREEN}Passed: $PASS${NC}"
+    echo -e "${RED}Failed: $FAIL${NC}"
+    echo ""
+
+    if [ $FAIL -eq 0 ]; then
+        echo -e "${GREEN}✓✓✓ ALL TESTS PASSED! ✓✓✓${NC}"
+        exit 0
+    fi
+    echo -e "${YELLOW}Some tests failed${NC}"
+    exit 1
+}
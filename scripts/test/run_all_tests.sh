@@ -113,7 +113,7 @@ check_server() {
     else
         echo -e "${RED}✗ Server is not responding${NC}"
         echo -e "${YELLOW}Please start the server first:${NC}"
-        echo "  go run cmd/server/main.go"
+        echo "  go run -tags sqlite_fts5 ./cmd/server"
         echo "  or"
         echo "  make run"
         exit 1
@@ -232,6 +232,74 @@ main() {
     print_header "12. Template Error Handling Tests"
     run_test_suite "test_templates.sh" "Template Testing Suite"
     
+    # Search
+    print_header "13. Search Tests"
+    run_test_suite "test_search.sh" "Search Suite"
+    
+    # Soft delete
+    print_header "14. Soft Delete Tests"
+    run_test_suite "test_soft_delete.sh" "Soft Delete Suite"
+    
+    # Audit log
+    print_header "15. Audit Log Tests"
+    run_test_suite "test_audit.sh" "Audit Log Suite"
+    
+    # Post editing
+    print_header "16. Post Edit Tests"
+    run_test_suite "test_post_edit.sh" "Post Edit Suite"
+    
+    # Pinning
+    print_header "17. Pinning Tests"
+    run_test_suite "test_pin.sh" "Pinning Suite"
+    
+    # Thread locking
+    print_header "18. Thread Lock Tests"
+    run_test_suite "test_lock.sh" "Thread Lock Suite"
+    
+    # Markdown rendering
+    print_header "19. Markdown Tests"
+    run_test_suite "test_markdown.sh" "Markdown Suite"
+    
+    # Threaded replies
+    print_header "20. Threaded Reply Tests"
+    run_test_suite "test_threads.sh" "Threaded Reply Suite"
+    
+    # Comment editing
+    print_header "21. Comment Edit Tests"
+    run_test_suite "test_comment_edit.sh" "Comment Edit Suite"
+    
+    # Reactions
+    print_header "22. Reaction Tests"
+    run_test_suite "test_reactions.sh" "Reaction Suite"
+    
+    # JSON votes
+    print_header "23. JSON Vote Tests"
+    run_test_suite "test_vote_json.sh" "JSON Vote Suite"
+    
+    # Karma
+    print_header "24. Karma Tests"
+    run_test_suite "test_karma.sh" "Karma Suite"
+    
+    # User profiles
+    print_header "25. User Profile Tests"
+    run_test_suite "test_profile.sh" "User Profile Suite"
+    
+    # Avatar uploads
+    print_header "26. Avatar Upload Tests"
+    run_test_suite "test_avatar.sh" "Avatar Upload Suite"
+    
+    # Account settings
+    print_header "27. Account Settings Tests"
+    run_test_suite "test_account.sh" "Account Settings Suite"
+    
+    # Password reset
+    print_header "28. Password Reset Tests"
+    run_test_suite "test_password_reset.sh" "Password Reset Suite"
+    
+    # Email verification
+    print_header "29. Email Verification Tests"
+    run_test_suite "test_email_verification.sh" "Email Verification Suite"
+    
     # Calculate duration
     END_TIME=$(date +%s)
     DURATION=$((END_TIME - START_TIME))
@@ -288,6 +356,23 @@ while [[ $# -gt 0 ]]; do
             echo "  10. HTTP Status Codes"
             echo "  11. HTTP Methods"
             echo "  12. Template Error Handling"
+            echo "  13. Search"
+            echo "  14. Soft Delete"
+            echo "  15. Audit Log"
+            echo "  16. Post Editing"
+            echo "  17. Pinning"
+            echo "  18. Thread Locking"
+            echo "  19. Markdown Rendering"
+            echo "  20. Threaded Replies"
+            echo "  21. Comment Editing"
+            echo "  22. Reactions"
+            echo "  23. JSON Votes"
+            echo "  24. Karma"
+            echo "  25. User Profiles"
+            echo "  26. Avatar Uploads"
+            echo "  27. Account Settings"
+            echo "  28. Password Reset"
+            echo "  29. Email Verification"
             exit 0
             ;;
         *)
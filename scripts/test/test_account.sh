@@ -0,0 +1,243 @@
+#!/bin/bash
+
+source "$(dirname "$0")/lib.sh"
+
+echo "========================================="
+echo "Account Settings Tests"
+echo "(assumes the default USERNAME_CHANGE_INTERVAL and USERNAME_RESERVE_PERIOD)"
+echo "========================================="
+echo ""
+
+require_server
+require_db
+echo ""
+
+# A user who changes their settings, and another whose email and username are taken
+echo -e "${BLUE}[SETUP]${NC} Creating test users..."
+TIMESTAMP=$(date +%s)
+USER1="acct_${TIMESTAMP}"
+USER2="acct_o_${TIMESTAMP}"
+RENAMED="acct_n_${TIMESTAMP}"
+NEW_EMAIL="acct_new_${TIMESTAMP}@test.com"
+
+register "$USER1" "$USER2"
+
+login "$USER1" acct_user1.txt
+login "$USER2" acct_user2.txt
+
+USER1_ID=$(sqlite3 "$DB_FILE" "SELECT id FROM users WHERE username = '${USER1}';")
+
+# submit NUM DESC COOKIES PATH EXPECTED_STATUS PATTERN FIELD=VALUE...
+# Posts a settings form; values are URL-encoded
+submit() {
+    local num="$1"
+    local desc="$2"
+    local cookies="$3"
+    local path="$4"
+    local expected="$5"
+    local pattern="$6"
+    shift 6
+
+    echo "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"
+    echo -e "${YELLOW}Test $num: $desc${NC}"
+    echo "  URL: POST $path"
+
+    local args=()
+    for field in "$@"; do
+        args+=(--data-urlencode "$field")
+    done
+    RESPONSE=$(curl -s -b "$cookies" -c "$cookies" "${args[@]}" \
+        -w "\nHTTP_STATUS:%{http_code}\nREDIRECT:%{redirect_url}" "$BASE_URL$path")
+    STATUS=$(echo "$RESPONSE" | grep "HTTP_STATUS" | cut -d: -f2)
+
+    local ok="yes"
+    [ "$STATUS" = "$expected" ] || ok="no"
+    echo "$RESPONSE" | grep -q -- "$pattern" || ok="no"
+    echo "  Pattern: $pattern"
+    echo "  Status: $STATUS (expected $expected)"
+    result "$num" "$desc" "$ok"
+}
+
+# session_token COOKIES: the session token saved in a cookie file
+session_token() {
+    grep "session_token" "$1" | awk '{print $7}'
+}
+
+echo "========================================="
+echo "ACCOUNT PAGE"
+echo "========================================="
+echo ""
+
+check "1" "The account page requires login" \
+    "" GET "$BASE_URL/account" "303" "REDIRECT:.*/login"
+
+check "2" "It has the username, email and password forms" \
+    acct_user1.txt GET "$BASE_URL/account" "200" 'action="/account/password"'
+
+check "3" "The current email is filled in" \
+    acct_user1.txt GET "$BASE_URL/account" "200" "value=\"${USER1}@test.com\""
+
+echo "========================================="
+echo "PASSWORD"
+echo "========================================="
+echo ""
+
+submit "4" "The current password must be right" \
+    acct_user1.txt /account/password "200" "Current password is incorrect" \
+    "current_password=Wrong123!" "new_password=Changed123!" "confirm_password=Changed123!"
+
+submit "5" "The new password must follow the password policy" \
+    acct_user1.txt /account/password "200" "Password must contain at least one uppercase" \
+    "current_password=Test123!" "new_password=changed123!" "confirm_password=changed123!"
+
+submit "6" "The confirmation must match" \
+    acct_user1.txt /account/password "200" "Passwords do not match" \
+    "current_password=Test123!" "new_password=Changed123!" "confirm_password=Changed124!"
+
+submit "7" "The new password must differ from the current one" \
+    acct_user1.txt /account/password "200" "must be different" \
+    "current_password=Test123!" "new_password=Test123!" "confirm_password=Test123!"
+
+# A second session of the same user, as if logged in on another device
+OTHER_TOKEN="acct-other-${TIMESTAMP}"
+sqlite3 "$DB_FILE" "INSERT INTO sessions (token, user_id, expires_at)
+    VALUES ('${OTHER_TOKEN}', ${USER1_ID}, '2099-01-01 00:00:00+00:00');"
+OLD_TOKEN=$(session_token acct_user1.txt)
+
+submit "8" "The password is changed" \
+    acct_user1.txt /account/password "303" "REDIRECT:.*/account?saved=password$" \
+    "current_password=Test123!" "new_password=Changed123!" "confirm_password=Changed123!"
+
+echo "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"
+echo -e "${YELLOW}Test 9: Other sessions are revoked${NC}"
+LEFT=$(sqlite3 "$DB_FILE" "SELECT COUNT(*) FROM sessions WHERE token IN ('${OTHER_TOKEN}', '${OLD_TOKEN}');")
+echo "  Old sessions left: $LEFT (expected 0)"
+OK="no"
+[ "$LEFT" = "0" ] && OK="yes"
+result "9" "" "$OK"
+
+echo "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"
+echo -e "${YELLOW}Test 10: The old session cookie no longer works${NC}"
+STATUS=$(curl -s -o /dev/null -w "%{http_code}" -b "session_token=${OLD_TOKEN}" "$BASE_URL/account")
+echo "  Status: $STATUS (expected 303)"
+OK="no"
+[ "$STATUS" = "303" ] && OK="yes"
+result "10" "" "$OK"
+
+check "11" "This browser stays logged in with a new session" \
+    acct_user1.txt GET "$BASE_URL/account?saved=password" "200" "Your password has been changed."
+
+check "12" "The old password no longer logs in" \
+    "" POST "$BASE_URL/login" "200" "invalid username or password" \
+    present -d "username=${USER1}&password=Test123!"
+
+check "13" "The new password does" \
+    "" POST "$BASE_URL/login" "303" "" \
+    present -d "username=${USER1}&password=Changed123!"
+
+# That login replaced the session of acct_user1.txt
+curl -s -c acct_user1.txt -X POST "$BASE_URL/login" \
+    -d "username=${USER1}&password=Changed123!" > /dev/null 2>&1
+
+echo "========================================="
+echo "EMAIL"
+echo "========================================="
+echo ""
+
+submit "14" "The email address is validated" \
+    acct_user1.txt /account/email "200" "Invalid email format" \
+    "email=not-an-email" "password=Changed123!"
+
+submit "15" "The password is required" \
+    acct_user1.txt /account/email "200" "Current password is incorrect" \
+    "email=${NEW_EMAIL}" "password=Test123!"
+
+submit "16" "Another user's address is refused" \
+    acct_user1.txt /account/email "200" "already used by another account" \
+    "email=${USER2}@test.com" "password=Changed123!"
+
+submit "17" "The email is changed" \
+    acct_user1.txt /account/email "303" "REDIRECT:.*/account?saved=email$" \
+    "email=${NEW_EMAIL}" "password=Changed123!"
+
+check "18" "The new address is shown" \
+    acct_user1.txt GET "$BASE_URL/account" "200" "value=\"${NEW_EMAIL}\""
+
+echo "========================================="
+echo "USERNAME"
+echo "========================================="
+echo ""
+
+submit "19" "The username is validated" \
+    acct_user1.txt /account/username "200" "Username cannot contain spaces" \
+    "username=bad name"
+
+submit "20" "Another user's username is refused" \
+    acct_user1.txt /account/username "200" "That username is already taken" \
+    "username=${USER2}"
+
+submit "21" "The username is changed" \
+    acct_user1.txt /account/username "303" "REDIRECT:.*/account?saved=username$" \
+    "username=${RENAMED}"
+
+check "22" "The profile moves to the new name" \
+    "" GET "$BASE_URL/user/${RENAMED}" "200"
+
+check "23" "The old name has no profile" \
+    "" GET "$BASE_URL/user/${USER1}" "404"
+
+submit "24" "A second change is rate-limited" \
+    acct_user1.txt /account/username "200" "You can change your username again on" \
+    "username=acct_x_${TIMESTAMP}"
+
+check "25" "The page says when the next change is possible" \
+    acct_user1.txt GET "$BASE_URL/account" "200" "Your next change is possible on"
+
+check "26" "The old name can't be registered" \
+    "" POST "$BASE_URL/register" "200" "username is reserved" \
+    present -d "username=${USER1}&email=acct_r_${TIMESTAMP}@test.com&password=Test123!&confirm_password=Test123!"
+
+submit "27" "Nor taken by another user" \
+    acct_user2.txt /account/username "200" "still reserved" \
+    "username=${USER1}"
+
+# Move the rename past the 30-day interval; the name is still reserved (90 days)
+sqlite3 "$DB_FILE" "UPDATE username_history
+    SET changed_at = strftime('%Y-%m-%d %H:%M:%S+00:00', 'now', '-31 days')
+    WHERE user_id = ${USER1_ID};"
+
+submit "28" "Its owner can take it back" \
+    acct_user1.txt /account/username "303" "REDIRECT:.*/account?saved=username$" \
+    "username=${USER1}"
+
+echo "========================================="
+echo "AUDIT AND ERRORS"
+echo "========================================="
+echo ""
+
+echo "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"
+echo -e "${YELLOW}Test 29: Changes are audited${NC}"
+ACTIONS=$(sqlite3 "$DB_FILE" "SELECT action || ':' || COUNT(*) FROM audit_events
+    WHERE actor_id = ${USER1_ID} AND action IN ('user.password', 'user.email', 'user.rename')
+    GROUP BY action ORDER BY action;" | tr '\n' ' ')
+echo "  Events: $ACTIONS(expected user.email:1 user.password:1 user.rename:2)"
+OK="no"
+[ "$ACTIONS" = "user.email:1 user.password:1 user.rename:2 " ] && OK="yes"
+result "29" "" "$OK"
+
+echo "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"
+echo -e "${YELLOW}Test 30: Password hashes stay out of the audit log${NC}"
+DIFF=$(sqlite3 "$DB_FILE" "SELECT diff FROM audit_events
+    WHERE actor_id = ${USER1_ID} AND action = 'user.password';")
+echo "  Diff: $DIFF (expected {})"
+OK="no"
+[ "$DIFF" = "{}" ] && OK="yes"
+result "30" "" "$OK"
+
+check "31" "Settings forms require login" \
+    "" POST "$BASE_URL/account/email" "303" "REDIRECT:.*/login"
+
+check "32" "Settings forms only accept POST" \
+    acct_user1.txt GET "$BASE_URL/account/password" "405"
+
+finish acct_user1.txt acct_user2.txt
@@ -0,0 +1,126 @@
+#!/bin/bash
+
+source "$(dirname "$0")/lib.sh"
+
+echo "========================================="
+echo "Audit Log Tests"
+echo "========================================="
+echo ""
+
+require_server
+require_db
+echo ""
+
+# Create a user who does one of everything, and an admin to read the log
+echo -e "${BLUE}[SETUP]${NC} Creating test users and content..."
+TIMESTAMP=$(date +%s)
+ACTOR="audit_${TIMESTAMP}"
+ADMIN="audit_a_${TIMESTAMP}"
+AGENT="AuditTest/${TIMESTAMP}"   # User agent the actor's events must record
+
+curl -s -A "$AGENT" -X POST "$BASE_URL/register" \
+    -d "username=${ACTOR}&email=${ACTOR}@test.com&password=Test123!&confirm_password=Test123!" \
+    > /dev/null 2>&1
+register "$ADMIN"
+make_admin "$ADMIN"
+
+curl -s -A "$AGENT" -c audit_actor.txt -X POST "$BASE_URL/login" \
+    -d "username=${ACTOR}&password=Test123!" > /dev/null 2>&1
+login "$ADMIN" audit_admin.txt
+
+POST_URL=$(curl -s -A "$AGENT" -o /dev/null -w "%{redirect_url}" -b audit_actor.txt -X POST "$BASE_URL/post/create" \
+    -d "title=Audited post ${TIMESTAMP}" \
+    -d "content=This post is written to the audit log" \
+    -d "category_id[]=1")
+POST_ID=${POST_URL##*/}
+
+curl -s -A "$AGENT" -o /dev/null -b audit_actor.txt -X POST "$BASE_URL/comment/${POST_ID}" \
+    -d "content=An audited comment"
+COMMENT_ID=$(curl -s "$BASE_URL/post/${POST_ID}" | grep -o 'id="comment-[0-9]*"' | head -1 | grep -o '[0-9]*')
+
+curl -s -A "$AGENT" -o /dev/null -b audit_actor.txt -X POST "$BASE_URL/post/${POST_ID}/like"
+curl -s -A "$AGENT" -o /dev/null -b audit_actor.txt -X POST "$BASE_URL/post/${POST_ID}/dislike"
+curl -s -A "$AGENT" -o /dev/null -b audit_actor.txt -X POST "$BASE_URL/comment/${COMMENT_ID}/like?post_id=${POST_ID}"
+curl -s -A "$AGENT" -o /dev/null -b audit_actor.txt -X POST "$BASE_URL/comment/${COMMENT_ID}/delete"
+
+echo -e "${GREEN}✓${NC} Post ${POST_ID}, comment ${COMMENT_ID} by ${ACTOR}"
+echo ""
+
+ACTOR_LOG="$BASE_URL/admin/audit?actor=${ACTOR}"
+
+echo "========================================="
+echo "ACCESS"
+echo "========================================="
+echo ""
+
+check "1" "Anonymous users are sent to login" \
+    "" GET "$BASE_URL/admin/audit" "303"
+
+check "2" "Non-admins cannot read the audit log" \
+    audit_actor.txt GET "$BASE_URL/admin/audit" "403"
+
+check "3" "Admins can read the audit log" \
+    audit_admin.txt GET "$BASE_URL/admin/audit" "200" "Audit Log"
+
+check "4" "Audit log is read-only" \
+    audit_admin.txt POST "$BASE_URL/admin/audit" "405"
+
+echo "========================================="
+echo "RECORDED EVENTS"
+echo "========================================="
+echo ""
+
+check "5" "Registration is recorded" \
+    audit_admin.txt GET "$ACTOR_LOG" "200" ">user.register</a>"
+
+check "6" "Login is recorded" \
+    audit_admin.txt GET "$ACTOR_LOG" "200" ">session.create</a>"
+
+check "7" "Post creation is recorded" \
+    audit_admin.txt GET "$ACTOR_LOG" "200" ">post.create</a>"
+
+check "8" "Comment creation is recorded" \
+    audit_admin.txt GET "$ACTOR_LOG" "200" ">comment.create</a>"
+
+check "9" "Votes are recorded" \
+    audit_admin.txt GET "$ACTOR_LOG" "200" ">comment.vote</a>"
+
+check "10" "Deletion is recorded" \
+    audit_admin.txt GET "$ACTOR_LOG" "200" ">comment.delete</a>"
+
+check "11" "Events record the user agent" \
+    audit_admin.txt GET "$ACTOR_LOG" "200" "${AGENT}"
+
+check "12" "Events record the IP address" \
+    audit_admin.txt GET "$ACTOR_LOG" "200" "127.0.0.1\\|::1"
+
+check "13" "Vote changes are recorded as a diff" \
+    audit_admin.txt GET "$ACTOR_LOG&action=post.vote" "200" "&#34;like&#34;,&#34;dislike&#34;"
+
+check "14" "Post title is recorded in the diff" \
+    audit_admin.txt GET "$ACTOR_LOG&action=post.create" "200" "Audited post ${TIMESTAMP}"
+
+check "15" "Passwords are never recorded" \
+    audit_admin.txt GET "$ACTOR_LOG" "200" "Test123!" "absent"
+
+echo "========================================="
+echo "FILTERS"
+echo "========================================="
+echo ""
+
+check "16" "Action filter leaves other actions out" \
+    audit_admin.txt GET "$ACTOR_LOG&action=post.vote" "200" ">post.create</a>" "absent"
+
+check "17" "Target filter shows one row's history" \
+    audit_admin.txt GET "$BASE_URL/admin/audit?target=comment&id=${COMMENT_ID}" "200" ">comment.delete</a>"
+
+check "18" "Actor filter leaves other users out" \
+    audit_admin.txt GET "$BASE_URL/admin/audit?actor=${ADMIN}" "200" ">post.create</a>" "absent"
+
+check "19" "Unknown actions are rejected" \
+    audit_admin.txt GET "$BASE_URL/admin/audit?action=bogus" "400"
+
+check "20" "Non-numeric target IDs are rejected" \
+    audit_admin.txt GET "$BASE_URL/admin/audit?id=abc" "400"
+
+finish audit_actor.txt audit_admin.txt
@@ -0,0 +1,218 @@
+#!/bin/bash
+
+source "$(dirname "$0")/lib.sh"
+
+echo "========================================="
+echo "Avatar Upload Tests"
+echo "(assumes the default AVATAR_MAX_BYTES of 2 MB)"
+echo "========================================="
+echo ""
+
+require_server
+echo ""
+
+# Two users, so that uploading the same picture twice can be compared
+echo -e "${BLUE}[SETUP]${NC} Creating test users and images..."
+TIMESTAMP=$(date +%s)
+USER1="avtr_${TIMESTAMP}"
+USER2="avtr_o_${TIMESTAMP}"
+
+register "$USER1" "$USER2"
+
+login "$USER1" avtr_user1.txt
+login "$USER2" avtr_user2.txt
+
+# Tiny test images: a 4×2 PNG, a 2×2 grayscale JPEG and a 1×1 GIF
+IMG_DIR=$(mktemp -d)
+echo "iVBORw0KGgoAAAANSUhEUgAAAAQAAAACCAIAAADwyuo0AAAAJ0lEQVR4nAAaAOX/BABkyDwAADwAADwAAAIAAAAAAAAAAAAAAAADAClQAecY5rqFAAAAAElFTkSuQmCC" \
+    | base64 -d > "$IMG_DIR/wide.png"
+echo "/9j/2wCEABALDA4MChAODQ4SERATGCgaGBYWGDEjJR0oOjM9PDkzODdASFxOQERXRTc4UG1RV19iZ2hnPk1xeXBkeFxlZ2MBERISGBUYLxoaL2NCOEJjY2NjY2NjY2NjY2NjY2NjY2NjY2NjY2NjY2NjY2NjY2NjY2NjY2NjY2NjY2NjY2NjY//AAAsIAAIAAgEBEQD/xADSAAABBQEBAQEBAQAAAAAAAAAAAQIDBAUGBwgJCgsQAAIBAwMCBAMFBQQEAAABfQECAwAEEQUSITFBBhNRYQcicRQygZGhCCNCscEVUtHwJDNicoIJChYXGBkaJSYnKCkqNDU2Nzg5OkNERUZHSElKU1RVVldYWVpjZGVmZ2hpanN0dXZ3eHl6g4SFhoeIiYqSk5SVlpeYmZqio6Slpqeoqaqys7S1tre4ubrCw8TFxsfIycrS09TV1tfY2drh4uPk5ebn6Onq8fLz9PX29/j5+v/aAAgBAQAAPwDgXdpHZ3Ys7HLMxySfU1//2Q==" \
+    | base64 -d > "$IMG_DIR/gray.jpg"
+echo "R0lGODlhAQABAIAAAP///wAAACH5BAEAAAAALAAAAAABAAEAAAICRAEAOw==" \
+    | base64 -d > "$IMG_DIR/dot.gif"
+
+# Not images, whatever their names say
+echo "<script>alert('not an image')</script>" > "$IMG_DIR/fake.png"
+head -c 33 "$IMG_DIR/wide.png" > "$IMG_DIR/truncated.png"
+
+# Larger than the default 2 MB limit: just over it, and far over it
+{ cat "$IMG_DIR/wide.png"; head -c $((2 * 1024 * 1024)) /dev/zero; } > "$IMG_DIR/over.png"
+{ cat "$IMG_DIR/wide.png"; head -c $((3 * 1024 * 1024)) /dev/zero; } > "$IMG_DIR/huge.png"
+
+# upload NUM DESC COOKIES FILE TYPE EXPECTED_STATUS [PATTERN]
+# Posts FILE as the "avatar" field; PATTERN is looked for in the body, or in
+# the redirect target for a 303
+upload() {
+    local num="$1"
+    local desc="$2"
+    local cookies="$3"
+    local file="$4"
+    local type="$5"
+    local expected="$6"
+    local pattern="$7"
+
+    echo "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"
+    echo -e "${YELLOW}Test $num: $desc${NC}"
+    echo "  Upload: $(basename "$file") as $type"
+
+    RESPONSE=$(curl -s -b "$cookies" -F "avatar=@${file};type=${type}" \
+        -w "\nHTTP_STATUS:%{http_code}\nREDIRECT:%{redirect_url}" "$BASE_URL/account/avatar")
+    STATUS=$(echo "$RESPONSE" | grep "HTTP_STATUS" | cut -d: -f2)
+
+    local ok="yes"
+    [ "$STATUS" = "$expected" ] || ok="no"
+    if [ -n "$pattern" ]; then
+        echo "$RESPONSE" | grep -q -- "$pattern" || ok="no"
+        echo "  Pattern: $pattern"
+    fi
+    echo "  Status: $STATUS (expected $expected)"
+    result "$num" "$desc" "$ok"
+}
+
+# avatar_of COOKIES: the avatar URL shown on the account page
+avatar_of() {
+    curl -s -b "$1" "$BASE_URL/account" | grep -o 'src="/static/avatars/[0-9a-f]*\.png"' | cut -d'"' -f2
+}
+
+echo "========================================="
+echo "ACCOUNT PAGE"
+echo "========================================="
+echo ""
+
+check "1" "The account page requires login" \
+    "" GET "$BASE_URL/account" "303"
+
+check "2" "Logged-in users see the upload form" \
+    avtr_user1.txt GET "$BASE_URL/account" "200" 'enctype="multipart/form-data"'
+
+check "3" "The layout links to the account page" \
+    avtr_user1.txt GET "$BASE_URL/" "200" 'href="/account"'
+
+check "4" "Without an avatar the first letter is shown" \
+    avtr_user1.txt GET "$BASE_URL/account" "200" 'class="avatar avatar-placeholder">a<'
+
+echo "========================================="
+echo "UPLOADS"
+echo "========================================="
+echo ""
+
+upload "5" "A PNG is accepted" \
+    avtr_user1.txt "$IMG_DIR/wide.png" image/png "303" "REDIRECT:.*/account?saved=avatar$"
+
+check "6" "The page confirms the change" \
+    avtr_user1.txt GET "$BASE_URL/account?saved=avatar" "200" "Your avatar has been updated."
+
+URL1=$(avatar_of avtr_user1.txt)
+echo "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"
+echo -e "${YELLOW}Test 7: The avatar is stored under the hash of its content${NC}"
+echo "  Avatar: $URL1"
+OK="no"
+echo "$URL1" | grep -qE '^/static/avatars/[0-9a-f]{64}\.png$' && OK="yes"
+result "7" "" "$OK"
+
+echo "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"
+echo -e "${YELLOW}Test 8: The static file server serves it as a PNG${NC}"
+HEADERS=$(curl -s -D - -o "$IMG_DIR/served.png" "$BASE_URL${URL1}")
+echo "$HEADERS" | grep -i "^content-type\|^x-content-type-options" | sed 's/^/  /'
+OK="no"
+echo "$HEADERS" | grep -q "^HTTP/1.1 200" && echo "$HEADERS" | grep -qi "^content-type: image/png" \
+    && echo "$HEADERS" | grep -qi "^x-content-type-options: nosniff" && OK="yes"
+result "8" "" "$OK"
+
+echo "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"
+echo -e "${YELLOW}Test 9: The 4×2 upload became a 128×128 thumbnail${NC}"
+# Width and height are the big-endian words at bytes 16-23 of the PNG header
+SIZE=$(od -An -tx1 -j16 -N8 "$IMG_DIR/served.png" | tr -d ' \n')
+echo "  IHDR size: $SIZE (expected 0000008000000080)"
+OK="no"
+[ "$SIZE" = "0000008000000080" ] && OK="yes"
+result "9" "" "$OK"
+
+check "10" "The public profile shows the avatar" \
+    "" GET "$BASE_URL/user/${USER1}" "200" "src=\"${URL1}\""
+
+upload "11" "Another user uploads the same picture" \
+    avtr_user2.txt "$IMG_DIR/wide.png" image/png "303"
+
+URL2=$(avatar_of avtr_user2.txt)
+echo "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"
+echo -e "${YELLOW}Test 12: The same picture is stored once${NC}"
+echo "  Avatars: $URL1 $URL2"
+OK="no"
+[ -n "$URL1" ] && [ "$URL1" = "$URL2" ] && OK="yes"
+result "12" "" "$OK"
+
+upload "13" "A JPEG is accepted" \
+    avtr_user1.txt "$IMG_DIR/gray.jpg" image/jpeg "303"
+
+URL3=$(avatar_of avtr_user1.txt)
+echo "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"
+echo -e "${YELLOW}Test 14: A different picture gets a different file${NC}"
+echo "  Avatar: $URL3"
+OK="no"
+[ -n "$URL3" ] && [ "$URL3" != "$URL1" ] && OK="yes"
+result "14" "" "$OK"
+
+upload "15" "A GIF is accepted" \
+    avtr_user1.txt "$IMG_DIR/dot.gif" image/gif "303"
+
+echo "========================================="
+echo "REJECTED UPLOADS"
+echo "========================================="
+echo ""
+
+URL_BEFORE=$(avatar_of avtr_user1.txt)
+
+upload "16" "A text file named .png is rejected by its content" \
+    avtr_user1.txt "$IMG_DIR/fake.png" image/png "200" "must be a PNG, JPEG or GIF"
+
+upload "17" "A truncated PNG is rejected" \
+    avtr_user1.txt "$IMG_DIR/truncated.png" image/png "200" "could not be read"
+
+upload "18" "A file just over the size limit is rejected" \
+    avtr_user1.txt "$IMG_DIR/over.png" image/png "200" "Image file is too large"
+
+upload "19" "A request far over the limit is cut off" \
+    avtr_user1.txt "$IMG_DIR/huge.png" image/png "200" "Image file is too large"
+
+echo "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"
+echo -e "${YELLOW}Test 20: Rejected uploads keep the current avatar${NC}"
+URL_AFTER=$(avatar_of avtr_user1.txt)
+echo "  Avatar: $URL_AFTER (expected $URL_BEFORE)"
+OK="no"
+[ -n "$URL_AFTER" ] && [ "$URL_AFTER" = "$URL_BEFORE" ] && OK="yes"
+result "20" "" "$OK"
+
+check "21" "Submitting without a file asks for one" \
+    avtr_user1.txt POST "$BASE_URL/account/avatar" "200" "Please choose an image to upload."
+
+echo "========================================="
+echo "REMOVING AND ERRORS"
+echo "========================================="
+echo ""
+
+echo "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"
+echo -e "${YELLOW}Test 22: The avatar can be removed${NC}"
+REDIRECT=$(curl -s -o /dev/null -w "%{redirect_url}" -b avtr_user1.txt -X POST \
+    -d "remove=1" "$BASE_URL/account/avatar")
+echo "  Redirect: $REDIRECT"
+OK="no"
+echo "$REDIRECT" | grep -q "/account?saved=avatar-remove$" && OK="yes"
+result "22" "" "$OK"
+
+check "23" "The profile falls back to the first letter" \
+    "" GET "$BASE_URL/user/${USER1}" "200" 'class="avatar avatar-placeholder">a<'
+
+check "24" "Removing leaves other users' copy of the file" \
+    "" GET "$BASE_URL${URL2}" "200"
+
+check "25" "Uploading requires login" \
+    "" POST "$BASE_URL/account/avatar" "303"
+
+check "26" "The upload endpoint only accepts POST" \
+    avtr_user1.txt GET "$BASE_URL/account/avatar" "405"
+
+check "27" "Avatar directories are not listed" \
+    "" GET "$BASE_URL/static/avatars/" "403"
+
+finish avtr_user1.txt avtr_user2.txt "$IMG_DIR"
@@ -0,0 +1,122 @@
+#!/bin/bash
+
+source "$(dirname "$0")/lib.sh"
+
+echo "========================================="
+echo "Comment Edit Tests"
+echo "========================================="
+echo ""
+
+require_server
+require_db
+echo ""
+
+# Create an author, another user and a moderator (admin)
+echo -e "${BLUE}[SETUP]${NC} Creating test users and content..."
+TIMESTAMP=$(date +%s)
+AUTHOR="cedit_${TIMESTAMP}"
+OTHER="cedit_o_${TIMESTAMP}"
+ADMIN="cedit_a_${TIMESTAMP}"
+
+register "$AUTHOR" "$OTHER" "$ADMIN"
+make_admin "$ADMIN"
+
+login "$AUTHOR" cedit_author.txt
+login "$OTHER" cedit_other.txt
+login "$ADMIN" cedit_admin.txt
+
+POST_URL=$(curl -s -o /dev/null -w "%{redirect_url}" -b cedit_author.txt -X POST "$BASE_URL/post/create" \
+    -d "title=Comment edit thread ${TIMESTAMP}" \
+    -d "content=A post whose comments get edited" \
+    -d "category_id[]=1")
+POST_ID=${POST_URL##*/}
+
+curl -s -o /dev/null -b cedit_author.txt -X POST "$BASE_URL/comment/${POST_ID}" \
+    -d "content=Original comment ${TIMESTAMP}"
+COMMENT_ID=$(curl -s "$BASE_URL/post/${POST_ID}" | grep -o 'id="comment-[0-9]*"' | head -1 | grep -o '[0-9]*')
+
+echo -e "${GREEN}✓${NC} Post ${POST_ID}, comment ${COMMENT_ID} by ${AUTHOR}"
+echo ""
+
+echo "========================================="
+echo "PERMISSIONS"
+echo "========================================="
+echo ""
+
+check "1" "Author gets the edit form" \
+    cedit_author.txt GET "$BASE_URL/comment/${COMMENT_ID}/edit" "200" "Original comment ${TIMESTAMP}"
+
+check "2" "Post page links the author to the edit form" \
+    cedit_author.txt GET "$BASE_URL/post/${POST_ID}" "200" "/comment/${COMMENT_ID}/edit"
+
+check "3" "Other users get no edit link" \
+    cedit_other.txt GET "$BASE_URL/post/${POST_ID}" "200" "/comment/${COMMENT_ID}/edit" "absent"
+
+check "4" "Other users cannot open the edit form" \
+    cedit_other.txt GET "$BASE_URL/comment/${COMMENT_ID}/edit" "403"
+
+check "5" "Other users cannot edit" \
+    cedit_other.txt POST "$BASE_URL/comment/${COMMENT_ID}/edit?content=Hijacked+comment+text" "403"
+
+check "6" "Other users cannot delete" \
+    cedit_other.txt POST "$BASE_URL/comment/${COMMENT_ID}/delete" "403"
+
+check "7" "Anonymous users are sent to login" \
+    "" POST "$BASE_URL/comment/${COMMENT_ID}/edit?content=Anonymous+comment+text" "303"
+
+echo "========================================="
+echo "EDITING"
+echo "========================================="
+echo ""
+
+check "8" "Edit accepts only GET and POST" \
+    cedit_author.txt PUT "$BASE_URL/comment/${COMMENT_ID}/edit" "405"
+
+check "9" "Invalid content stays on the form" \
+    cedit_author.txt POST "$BASE_URL/comment/${COMMENT_ID}/edit?content=short" "200" "at least 10 characters"
+
+sleep 1 # updated_at has one-second resolution in SQLite
+check "10" "Author edits the comment" \
+    cedit_author.txt POST "$BASE_URL/comment/${COMMENT_ID}/edit?content=Corrected+comment+${TIMESTAMP}" "303"
+
+check "11" "Post page shows the new content" \
+    "" GET "$BASE_URL/post/${POST_ID}" "200" "Corrected comment ${TIMESTAMP}"
+
+check "12" "Post page marks the comment as edited" \
+    "" GET "$BASE_URL/post/${POST_ID}" "200" 'span class="edited-marker"'
+
+check "13" "Admins can edit any comment" \
+    cedit_admin.txt POST "$BASE_URL/comment/${COMMENT_ID}/edit?content=Moderated+comment+${TIMESTAMP}" "303"
+
+check "14" "Edits are recorded in the audit log" \
+    cedit_admin.txt GET "$BASE_URL/admin/audit?action=comment.edit&id=${COMMENT_ID}" "200" ">comment.edit</a>"
+
+check "15" "Missing comment is not found" \
+    cedit_author.txt GET "$BASE_URL/comment/999999/edit" "404"
+
+check "16" "Non-numeric comment ID is rejected" \
+    cedit_author.txt GET "$BASE_URL/comment/abc/edit" "400"
+
+check "17" "Comment ID is required" \
+    cedit_author.txt GET "$BASE_URL/comment/edit" "400"
+
+echo "========================================="
+echo "LOCKED AND DELETED"
+echo "========================================="
+echo ""
+
+curl -s -o /dev/null -b cedit_admin.txt -X POST "$BASE_URL/post/${POST_ID}/lock"
+check "18" "Comments in locked threads cannot be edited" \
+    cedit_author.txt POST "$BASE_URL/comment/${COMMENT_ID}/edit?content=Edit+after+the+lock" "403" "Thread Locked"
+
+check "19" "Locked threads show no edit link" \
+    cedit_author.txt GET "$BASE_URL/post/${POST_ID}" "200" "/comment/${COMMENT_ID}/edit" "absent"
+curl -s -o /dev/null -b cedit_admin.txt -X POST "$BASE_URL/post/${POST_ID}/unlock"
+
+check "20" "Author deletes the comment" \
+    cedit_author.txt POST "$BASE_URL/comment/${COMMENT_ID}/delete" "303"
+
+check "21" "Deleted comments cannot be edited" \
+    cedit_author.txt GET "$BASE_URL/comment/${COMMENT_ID}/edit" "404"
+
+finish cedit_author.txt cedit_other.txt cedit_admin.txt
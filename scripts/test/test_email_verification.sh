@@ -0,0 +1,201 @@
+#!/bin/bash
+
+source "$(dirname "$0")/lib.sh"
+MAIL_FILE="${MAIL_FILE:-mail.log}"   # The server's MAIL_FILE, where verification links are sent
+
+echo "========================================="
+echo "Email Verification Tests"
+echo "(start the server with MAIL_FILE set, e.g. MAIL_FILE=mail.log make run;"
+echo " assumes the default UNVERIFIED_POST_LIMIT)"
+echo "========================================="
+echo ""
+
+require_server
+require_db
+echo ""
+
+# mails_to ADDRESS: how many emails MAIL_FILE holds for an address
+mails_to() {
+    grep -c "^To: $1" "$MAIL_FILE"
+}
+
+# last_link: the last verification link in MAIL_FILE
+last_link() {
+    grep -o "http[^[:space:]]*/verify-email?token=[^[:space:]]*" "$MAIL_FILE" | tail -n 1 | tr -d '\r'
+}
+
+echo "========================================="
+echo "REGISTRATION"
+echo "========================================="
+echo ""
+
+TIMESTAMP=$(date +%s)
+USER1="verif_${TIMESTAMP}"
+EMAIL1="${USER1}@test.com"
+EMAIL2="verif_new_${TIMESTAMP}@test.com"
+UNVERIFIED_POST_LIMIT=20
+touch "$MAIL_FILE" 2>/dev/null
+
+check "1" "Registration asks to confirm the address" \
+    "" POST "$BASE_URL/register" "303" "REDIRECT:.*/login?registered=1" \
+    present -d "username=${USER1}&email=${EMAIL1}&password=Test123!&confirm_password=Test123!"
+
+echo "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"
+echo -e "${YELLOW}Test 2: A verification link is emailed${NC}"
+COUNT=$(mails_to "$EMAIL1")
+LINK1=$(last_link)
+echo "  Emails: $COUNT (expected 1)"
+echo "  Link: $LINK1"
+OK="no"
+[ "$COUNT" = "1" ] && [ -n "$LINK1" ] && OK="yes"
+result "2" "" "$OK"
+
+login "$USER1" verif_user1.txt
+USER1_ID=$(sqlite3 "$DB_FILE" "SELECT id FROM users WHERE username = '${USER1}';")
+
+check "3" "Pages remind the user to verify" \
+    verif_user1.txt GET "$BASE_URL/" "200" "Please confirm your email address"
+
+check "4" "The account page shows the address as unverified" \
+    verif_user1.txt GET "$BASE_URL/account" "200" "is not verified yet"
+
+echo "========================================="
+echo "POSTING LIMIT"
+echo "========================================="
+echo ""
+
+check "5" "An unverified account can post" \
+    verif_user1.txt POST "$BASE_URL/post/create" "303" "REDIRECT:.*/post/" \
+    present -d "title=Verification test ${TIMESTAMP}&content=Posted before verifying&category_id[]=1"
+
+POST_ID=$(sqlite3 "$DB_FILE" "SELECT id FROM posts WHERE user_id = ${USER1_ID} ORDER BY id DESC LIMIT 1;")
+
+# Use up the rest of the day's limit with comments
+sqlite3 "$DB_FILE" "WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < $((UNVERIFIED_POST_LIMIT - 1)))
+    INSERT INTO comments (content, user_id, post_id, created_at, updated_at)
+    SELECT 'Comment ' || i, ${USER1_ID}, ${POST_ID}, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP FROM n;"
+
+check "6" "Over the limit, new posts are refused" \
+    verif_user1.txt POST "$BASE_URL/post/create" "200" "can create ${UNVERIFIED_POST_LIMIT} posts and comments a day" \
+    present -d "title=One too many ${TIMESTAMP}&content=Over the limit&category_id[]=1"
+
+check "7" "And so are comments" \
+    verif_user1.txt POST "$BASE_URL/comment/${POST_ID}" "403" "verify your email address" \
+    present -d "content=Over the limit"
+
+echo "========================================="
+echo "RESENDING"
+echo "========================================="
+echo ""
+
+check "8" "Asking again right away is throttled" \
+    verif_user1.txt POST "$BASE_URL/account/verify-email" "200" "was sent recently"
+
+sqlite3 "$DB_FILE" "UPDATE users
+    SET verification_sent_at = strftime('%Y-%m-%d %H:%M:%S+00:00', 'now', '-10 minutes')
+    WHERE id = ${USER1_ID};"
+
+check "9" "Later a new link is sent" \
+    verif_user1.txt POST "$BASE_URL/account/verify-email" "303" "REDIRECT:.*/account?saved=verify-sent$"
+
+echo "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"
+echo -e "${YELLOW}Test 10: It arrives${NC}"
+COUNT=$(mails_to "$EMAIL1")
+LINK2=$(last_link)
+echo "  Emails: $COUNT (expected 2)"
+OK="no"
+[ "$COUNT" = "2" ] && [ -n "$LINK2" ] && OK="yes"
+result "10" "" "$OK"
+
+echo "========================================="
+echo "VERIFYING"
+echo "========================================="
+echo ""
+
+check "11" "A made-up token is refused" \
+    "" GET "$BASE_URL/verify-email?token=${USER1_ID}.9999999999.bogus" "200" "invalid or has expired"
+
+# Push the expiry of a real link forward; the signature no longer matches
+TOKEN="${LINK2#*token=}"
+EXPIRY=$(echo "$TOKEN" | cut -d. -f2)
+FORGED=$(echo "$TOKEN" | sed "s/\.${EXPIRY}\./.$((EXPIRY + 86400))./")
+
+check "12" "A token with a changed expiry is refused" \
+    "" GET "$BASE_URL/verify-email?token=${FORGED}" "200" "invalid or has expired"
+
+check "13" "The link verifies the address, without logging in" \
+    "" GET "$LINK1" "200" "is verified"
+
+echo "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"
+echo -e "${YELLOW}Test 14: The verification is stored${NC}"
+VERIFIED=$(sqlite3 "$DB_FILE" "SELECT email_verified_at IS NOT NULL FROM users WHERE id = ${USER1_ID};")
+echo "  Verified: $VERIFIED (expected 1)"
+OK="no"
+[ "$VERIFIED" = "1" ] && OK="yes"
+result "14" "" "$OK"
+
+check "15" "Opening a link again is harmless" \
+    "" GET "$LINK2" "200" "is verified"
+
+check "16" "The account page shows the address as verified" \
+    verif_user1.txt GET "$BASE_URL/account" "200" "is verified."
+
+check "17" "The limit no longer applies" \
+    verif_user1.txt POST "$BASE_URL/comment/${POST_ID}" "303" "" \
+    present -d "content=Posted after verifying"
+
+check "18" "Nothing is resent for a verified address" \
+    verif_user1.txt POST "$BASE_URL/account/verify-email" "200" "already verified"
+
+echo "========================================="
+echo "CHANGING THE ADDRESS"
+echo "========================================="
+echo ""
+
+check "19" "The email is changed" \
+    verif_user1.txt POST "$BASE_URL/account/email" "303" "REDIRECT:.*/account?saved=email$" \
+    present -d "email=${EMAIL2}&password=Test123!"
+
+check "20" "The new address is unverified" \
+    verif_user1.txt GET "$BASE_URL/account" "200" "is not verified yet"
+
+echo "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"
+echo -e "${YELLOW}Test 21: A link is sent to the new address${NC}"
+COUNT=$(mails_to "$EMAIL2")
+LINK3=$(last_link)
+echo "  Emails: $COUNT (expected 1)"
+OK="no"
+[ "$COUNT" = "1" ] && [ "$LINK3" != "$LINK2" ] && OK="yes"
+result "21" "" "$OK"
+
+check "22" "Links for the old address stop working" \
+    "" GET "$LINK2" "200" "invalid or has expired"
+
+check "23" "The new link works" \
+    "" GET "$LINK3" "200" "is verified"
+
+echo "========================================="
+echo "AUDIT AND ERRORS"
+echo "========================================="
+echo ""
+
+echo "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"
+echo -e "${YELLOW}Test 24: Emails and verifications are audited${NC}"
+ACTIONS=$(sqlite3 "$DB_FILE" "SELECT action || ':' || COUNT(*) FROM audit_events
+    WHERE actor_id = ${USER1_ID} AND action IN ('user.verify_request', 'user.verify_email')
+    GROUP BY action ORDER BY action;" | tr '\n' ' ')
+echo "  Events: $ACTIONS(expected user.verify_email:2 user.verify_request:3)"
+OK="no"
+[ "$ACTIONS" = "user.verify_email:2 user.verify_request:3 " ] && OK="yes"
+result "24" "" "$OK"
+
+check "25" "Resending requires login" \
+    "" POST "$BASE_URL/account/verify-email" "303" "REDIRECT:.*/login"
+
+check "26" "Resending only accepts POST" \
+    verif_user1.txt GET "$BASE_URL/account/verify-email" "405"
+
+check "27" "Verification links only accept GET" \
+    "" POST "$BASE_URL/verify-email" "405"
+
+finish verif_user1.txt
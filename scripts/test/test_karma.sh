@@ -0,0 +1,125 @@
+#!/bin/bash
+
+source "$(dirname "$0")/lib.sh"
+
+echo "========================================="
+echo "Karma Tests"
+echo "========================================="
+echo ""
+
+require_server
+echo ""
+
+# Create a post author, a comment author and a third voter
+echo -e "${BLUE}[SETUP]${NC} Creating test users and content..."
+TIMESTAMP=$(date +%s)
+AUTHOR="karma_${TIMESTAMP}"
+OTHER="karma_o_${TIMESTAMP}"
+THIRD="karma_t_${TIMESTAMP}"
+
+register "$AUTHOR" "$OTHER" "$THIRD"
+
+login "$AUTHOR" karma_author.txt
+login "$OTHER" karma_other.txt
+login "$THIRD" karma_third.txt
+
+POST_URL=$(curl -s -o /dev/null -w "%{redirect_url}" -b karma_author.txt -X POST "$BASE_URL/post/create" \
+    -d "title=Karma target ${TIMESTAMP}" \
+    -d "content=Votes on this post count towards its author" \
+    -d "category_id[]=1")
+POST_ID=${POST_URL##*/}
+
+curl -s -o /dev/null -b karma_other.txt -X POST "$BASE_URL/comment/${POST_ID}" \
+    -d "content=A comment by another user"
+COMMENT_ID=$(curl -s "$BASE_URL/post/${POST_ID}" | grep -o 'id="comment-[0-9]*"' | head -1 | grep -o '[0-9]*')
+
+POST_PAGE="$BASE_URL/post/${POST_ID}"
+
+# karma_of USER VALUE: what the page shows next to USER's name
+karma_of() {
+    echo "${1}</strong></a> <span class=\"karma\" title=\"Karma\">${2}<"
+}
+
+echo -e "${GREEN}✓${NC} Post ${POST_ID} by ${AUTHOR}, comment ${COMMENT_ID} by ${OTHER}"
+echo ""
+
+echo "========================================="
+echo "VOTES ON POSTS"
+echo "========================================="
+echo "(assumes the default KARMA_POST_WEIGHT=2 and KARMA_COMMENT_WEIGHT=1)"
+echo ""
+
+check "1" "New users start at 0" \
+    "" GET "${POST_PAGE}" "200" "$(karma_of "$AUTHOR" 0)"
+
+check "2" "Another user likes the post" \
+    karma_other.txt POST "${POST_PAGE}/like" "303"
+
+check "3" "The author gains the post weight" \
+    "" GET "${POST_PAGE}" "200" "$(karma_of "$AUTHOR" 2)"
+
+check "4" "Karma is shown in the home listing" \
+    "" GET "$BASE_URL/" "200" "$(karma_of "$AUTHOR" 2)"
+
+check "5" "Karma is shown in search results" \
+    "" GET "$BASE_URL/search?q=Karma&author=${AUTHOR}" "200" "$(karma_of "$AUTHOR" 2)"
+
+check "6" "A dislike takes karma away" \
+    karma_third.txt POST "${POST_PAGE}/dislike" "303"
+
+check "7" "Like and dislike cancel out" \
+    "" GET "${POST_PAGE}" "200" "$(karma_of "$AUTHOR" 0)"
+
+check "8" "Switching a like to a dislike" \
+    karma_other.txt POST "${POST_PAGE}/dislike" "303"
+
+check "9" "The switch counts twice" \
+    "" GET "${POST_PAGE}" "200" "$(karma_of "$AUTHOR" -4)"
+
+check "10" "The author likes their own post" \
+    karma_author.txt POST "${POST_PAGE}/like" "303"
+
+check "11" "Votes on one's own post don't count" \
+    "" GET "${POST_PAGE}" "200" "$(karma_of "$AUTHOR" -4)"
+
+check "12" "Toggling a dislike off gives the karma back" \
+    karma_other.txt POST "${POST_PAGE}/dislike" "303"
+
+check "13" "The author is back up" \
+    "" GET "${POST_PAGE}" "200" "$(karma_of "$AUTHOR" -2)"
+
+echo "========================================="
+echo "VOTES ON COMMENTS"
+echo "========================================="
+echo ""
+
+check "14" "Liking a comment" \
+    karma_author.txt POST "$BASE_URL/comment/${COMMENT_ID}/like?post_id=${POST_ID}" "303"
+
+check "15" "The comment author gains the comment weight" \
+    "" GET "${POST_PAGE}" "200" "$(karma_of "$OTHER" 1)"
+
+check "16" "A like reaction is a vote and counts too" \
+    karma_third.txt POST "$BASE_URL/comment/${COMMENT_ID}/react?reaction=like&post_id=${POST_ID}" "303"
+
+check "17" "The comment author has both likes" \
+    "" GET "${POST_PAGE}" "200" "$(karma_of "$OTHER" 2)"
+
+check "18" "Other reactions don't change karma" \
+    karma_third.txt POST "${POST_PAGE}/react?reaction=love" "303"
+
+check "19" "The post author is unchanged" \
+    "" GET "${POST_PAGE}" "200" "$(karma_of "$AUTHOR" -2)"
+
+echo "========================================="
+echo "DELETED CONTENT"
+echo "========================================="
+echo ""
+
+check "20" "The comment author deletes the comment" \
+    karma_other.txt POST "$BASE_URL/comment/${COMMENT_ID}/delete" "303"
+
+check "21" "Votes on deleted comments don't count" \
+    "" GET "$BASE_URL/user/${OTHER}" "200" '<strong class="karma">0</strong> karma'
+
+finish karma_author.txt karma_other.txt karma_third.txt
@@ -0,0 +1,119 @@
+#!/bin/bash
+
+source "$(dirname "$0")/lib.sh"
+
+echo "========================================="
+echo "Thread Lock Tests"
+echo "========================================="
+echo ""
+
+require_server
+require_db
+echo ""
+
+# Create an author, another user and a moderator (admin)
+echo -e "${BLUE}[SETUP]${NC} Creating test users and content..."
+TIMESTAMP=$(date +%s)
+AUTHOR="lock_${TIMESTAMP}"
+OTHER="lock_o_${TIMESTAMP}"
+ADMIN="lock_a_${TIMESTAMP}"
+REASON="Offtopic${TIMESTAMP}"   # Unique lock reason
+
+register "$AUTHOR" "$OTHER" "$ADMIN"
+make_admin "$ADMIN"
+
+login "$AUTHOR" lock_author.txt
+login "$OTHER" lock_other.txt
+login "$ADMIN" lock_admin.txt
+
+POST_URL=$(curl -s -o /dev/null -w "%{redirect_url}" -b lock_author.txt -X POST "$BASE_URL/post/create" \
+    -d "title=Lockable thread ${TIMESTAMP}" \
+    -d "content=This thread will be locked and unlocked" \
+    -d "category_id[]=1")
+POST_ID=${POST_URL##*/}
+
+curl -s -o /dev/null -b lock_author.txt -X POST "$BASE_URL/comment/${POST_ID}" \
+    -d "content=A comment before the lock"
+COMMENT_ID=$(curl -s "$BASE_URL/post/${POST_ID}" | grep -o 'id="comment-[0-9]*"' | head -1 | grep -o '[0-9]*')
+
+LONG_REASON=$(printf 'x%.0s' $(seq 1 201))
+
+echo -e "${GREEN}✓${NC} Post ${POST_ID}, comment ${COMMENT_ID} by ${AUTHOR}"
+echo ""
+
+echo "========================================="
+echo "LOCKING"
+echo "========================================="
+echo ""
+
+check "1" "Non-admins cannot lock" \
+    lock_author.txt POST "$BASE_URL/post/${POST_ID}/lock" "403"
+
+check "2" "Lock requires POST" \
+    lock_admin.txt GET "$BASE_URL/post/${POST_ID}/lock" "405"
+
+check "3" "Overlong lock reason is rejected" \
+    lock_admin.txt POST "$BASE_URL/post/${POST_ID}/lock?reason=${LONG_REASON}" "400"
+
+check "4" "Locking a missing post is not found" \
+    lock_admin.txt POST "$BASE_URL/post/999999/lock" "404"
+
+check "5" "Admin locks the thread" \
+    lock_admin.txt POST "$BASE_URL/post/${POST_ID}/lock?reason=${REASON}" "303"
+
+check "6" "Post page shows who locked it" \
+    "" GET "$BASE_URL/post/${POST_ID}" "200" "locked by <strong>${ADMIN}</strong>"
+
+check "7" "Post page shows why" \
+    "" GET "$BASE_URL/post/${POST_ID}" "200" "Reason: ${REASON}"
+
+check "8" "Reply form is hidden" \
+    lock_other.txt GET "$BASE_URL/post/${POST_ID}" "200" 'id="commentForm"' "absent"
+
+check "9" "Home listing shows the locked badge" \
+    "" GET "$BASE_URL/" "200" "🔒 Locked"
+
+check "10" "Locks are recorded in the audit log" \
+    lock_admin.txt GET "$BASE_URL/admin/audit?action=post.lock&id=${POST_ID}" "200" ">post.lock</a>"
+
+echo "========================================="
+echo "REFUSED ON LOCKED THREADS"
+echo "========================================="
+echo ""
+
+check "11" "New comments are refused" \
+    lock_other.txt POST "$BASE_URL/comment/${POST_ID}?content=Trying+to+reply+here" "403" "Thread Locked"
+
+check "12" "Post likes are refused" \
+    lock_other.txt POST "$BASE_URL/post/${POST_ID}/like" "403" "Thread Locked"
+
+check "13" "Post dislikes are refused" \
+    lock_other.txt POST "$BASE_URL/post/${POST_ID}/dislike" "403"
+
+check "14" "Comment likes are refused" \
+    lock_other.txt POST "$BASE_URL/comment/${COMMENT_ID}/like?post_id=${POST_ID}" "403"
+
+check "15" "Comment dislikes are refused" \
+    lock_other.txt POST "$BASE_URL/comment/${COMMENT_ID}/dislike?post_id=${POST_ID}" "403"
+
+echo "========================================="
+echo "UNLOCKING"
+echo "========================================="
+echo ""
+
+check "16" "Non-admins cannot unlock" \
+    lock_author.txt POST "$BASE_URL/post/${POST_ID}/unlock" "403"
+
+check "17" "Admin unlocks the thread" \
+    lock_admin.txt POST "$BASE_URL/post/${POST_ID}/unlock" "303"
+
+check "18" "Reply form is shown again" \
+    lock_other.txt GET "$BASE_URL/post/${POST_ID}" "200" 'id="commentForm"'
+
+check "19" "Comments are accepted again" \
+    lock_other.txt POST "$BASE_URL/comment/${POST_ID}?content=Replying+after+unlock" "303"
+
+check "20" "Votes are accepted again" \
+    lock_other.txt POST "$BASE_URL/post/${POST_ID}/like" "303"
+
+finish lock_author.txt lock_other.txt lock_admin.txt
@@ -0,0 +1,163 @@
+#!/bin/bash
+
+source "$(dirname "$0")/lib.sh"
+
+echo "========================================="
+echo "Markdown Tests"
+echo "========================================="
+echo ""
+
+require_server
+echo ""
+
+# Create a user and a post using every supported construct, plus some hostile HTML
+echo -e "${BLUE}[SETUP]${NC} Creating test user and content..."
+TIMESTAMP=$(date +%s)
+AUTHOR="mdtest_${TIMESTAMP}"
+
+register "$AUTHOR"
+login "$AUTHOR" mdtest_cookies.txt
+
+CONTENT='## Setup guide
+
+Some **bold** text, *emphasis* and `inline <code>`.
+
+```go
+fmt.Println("<script>alert(1)</script>")
+```
+
+- first item
+- second item
+
+1. step one
+2. step two
+
+> quoted text
+
+[docs](https://go.dev/doc) and [evil](javascript:alert(1))
+[away](//evil.example/x) and [slanted](\\evil.example/y)
+<img src=x onerror=alert(1)> <script>alert(2)</script>'
+
+POST_URL=$(curl -s -o /dev/null -w "%{redirect_url}" -b mdtest_cookies.txt -X POST "$BASE_URL/post/create" \
+    --data-urlencode "title=Markdown post ${TIMESTAMP}" \
+    --data-urlencode "content=${CONTENT}" \
+    -d "category_id[]=2")
+POST_ID=${POST_URL##*/}
+
+curl -s -o /dev/null -b mdtest_cookies.txt -X POST "$BASE_URL/comment/${POST_ID}" \
+    --data-urlencode "content=A comment with _emphasis_ and \`code\` in it"
+
+# Exactly 10,000 characters of source, which renders to far more HTML
+LONG_SOURCE=$(printf '**a**%.0s' $(seq 1 2000))
+
+echo -e "${GREEN}✓${NC} Post ${POST_ID} by ${AUTHOR}"
+echo ""
+
+# check_create NUM DESC CONTENT EXPECTED_STATUS [PATTERN]
+# Creates a post with CONTENT sent in the request body (too long for a URL)
+check_create() {
+    local num="$1"
+    local desc="$2"
+    local content="$3"
+    local expected="$4"
+    local pattern="$5"
+
+    echo "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"
+    echo -e "${YELLOW}Test $num: $desc${NC}"
+    echo "  URL: POST $BASE_URL/post/create (${#content} characters)"
+
+    RESPONSE=$(curl -s -b mdtest_cookies.txt -X POST -w "\nHTTP_STATUS:%{http_code}" "$BASE_URL/post/create" \
+        --data-urlencode "title=Long markdown ${TIMESTAMP} ${num}" \
+        --data-urlencode "content=${content}" \
+        -d "category_id[]=2")
+    STATUS=$(echo "$RESPONSE" | grep "HTTP_STATUS" | cut -d: -f2)
+
+    local ok="yes"
+    [ "$STATUS" = "$expected" ] || ok="no"
+    if [ -n "$pattern" ]; then
+        echo "$RESPONSE" | grep -q -- "$pattern" || ok="no"
+        echo "  Pattern (present): $pattern"
+    fi
+    echo "  Status: $STATUS (expected $expected)"
+
+    result "$num" "$desc" "$ok"
+}
+
+echo "========================================="
+echo "RENDERING"
+echo "========================================="
+echo ""
+
+check "1" "Headings are rendered" \
+    "" GET "$BASE_URL/post/${POST_ID}" "200" "<h2>Setup guide</h2>"
+
+check "2" "Bold and emphasis are rendered" \
+    "" GET "$BASE_URL/post/${POST_ID}" "200" "<strong>bold</strong> text, <em>emphasis</em>"
+
+check "3" "Inline code is escaped" \
+    "" GET "$BASE_URL/post/${POST_ID}" "200" "<code>inline &lt;code&gt;</code>"
+
+check "4" "Fenced code blocks keep their language" \
+    "" GET "$BASE_URL/post/${POST_ID}" "200" '<pre><code class="language-go">'
+
+check "5" "Lists are rendered" \
+    "" GET "$BASE_URL/post/${POST_ID}" "200" "<li>second item</li>"
+
+check "6" "Ordered lists are rendered" \
+    "" GET "$BASE_URL/post/${POST_ID}" "200" "<ol>"
+
+check "7" "Blockquotes are rendered" \
+    "" GET "$BASE_URL/post/${POST_ID}" "200" "<blockquote>"
+
+check "8" "Links are rendered with rel=nofollow" \
+    "" GET "$BASE_URL/post/${POST_ID}" "200" '<a href="https://go.dev/doc" rel="nofollow'
+
+check "9" "Comments are rendered" \
+    "" GET "$BASE_URL/post/${POST_ID}" "200" "<em>emphasis</em> and <code>code</code>"
+
+echo "========================================="
+echo "SANITIZING"
+echo "========================================="
+echo ""
+
+check "10" "javascript: links are not links" \
+    "" GET "$BASE_URL/post/${POST_ID}" "200" 'href="javascript' "absent"
+
+check "11" "Protocol-relative links are not links" \
+    "" GET "$BASE_URL/post/${POST_ID}" "200" 'href="//evil' "absent"
+
+check "12" "Nor are links starting with backslashes" \
+    "" GET "$BASE_URL/post/${POST_ID}" "200" 'href="\\' "absent"
+
+check "13" "Raw script tags are escaped" \
+    "" GET "$BASE_URL/post/${POST_ID}" "200" "<script>alert" "absent"
+
+check "14" "Raw img tags are escaped" \
+    "" GET "$BASE_URL/post/${POST_ID}" "200" "<img src=x" "absent"
+
+check "15" "Escaped HTML is shown as text" \
+    "" GET "$BASE_URL/post/${POST_ID}" "200" "&lt;img src=x onerror=alert(1)&gt;"
+
+echo "========================================="
+echo "EDITING AND LENGTH"
+echo "========================================="
+echo ""
+
+curl -s -o /dev/null -b mdtest_cookies.txt -X POST "$BASE_URL/post/${POST_ID}/edit" \
+    --data-urlencode "title=Markdown post ${TIMESTAMP}" \
+    --data-urlencode "content=Now with **heavy** text instead" \
+    -d "category_id[]=2"
+
+check "16" "Edited content is rendered again" \
+    "" GET "$BASE_URL/post/${POST_ID}" "200" "<strong>heavy</strong>"
+
+check "17" "Old rendering is not served from the cache" \
+    "" GET "$BASE_URL/post/${POST_ID}" "200" "<strong>bold</strong>" "absent"
+
+check_create "18" "10,000 characters of source are accepted" \
+    "${LONG_SOURCE}" "303"
+
+check_create "19" "10,001 characters of source are rejected" \
+    "${LONG_SOURCE}x" "200" "no more than 10,000"
+
+finish mdtest_cookies.txt
@@ -0,0 +1,213 @@
+#!/bin/bash
+
+source "$(dirname "$0")/lib.sh"
+MAIL_FILE="${MAIL_FILE:-mail.log}"   # The server's MAIL_FILE, where reset links are sent
+
+echo "========================================="
+echo "Password Reset Tests"
+echo "(start the server with MAIL_FILE set, e.g. MAIL_FILE=mail.log make run)"
+echo "========================================="
+echo ""
+
+require_server
+require_db
+echo ""
+
+# One user who forgets their password and one who never asks for a reset
+echo -e "${BLUE}[SETUP]${NC} Creating test users..."
+TIMESTAMP=$(date +%s)
+USER1="pwrs_${TIMESTAMP}"
+USER2="pwrs_o_${TIMESTAMP}"
+EMAIL1="${USER1}@test.com"
+
+register "$USER1" "$USER2"
+
+login "$USER1" pwrs_user1.txt
+
+USER1_ID=$(sqlite3 "$DB_FILE" "SELECT id FROM users WHERE username = '${USER1}';")
+touch "$MAIL_FILE" 2>/dev/null
+
+# reset NUM DESC TOKEN EXPECTED_STATUS PATTERN PASSWORD CONFIRM
+# Posts the reset form; values are URL-encoded
+reset() {
+    echo "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"
+    echo -e "${YELLOW}Test $1: $2${NC}"
+    echo "  URL: POST /reset-password"
+
+    RESPONSE=$(curl -s --data-urlencode "token=$3" --data-urlencode "password=$6" \
+        --data-urlencode "confirm_password=$7" \
+        -w "\nHTTP_STATUS:%{http_code}\nREDIRECT:%{redirect_url}" "$BASE_URL/reset-password")
+    STATUS=$(echo "$RESPONSE" | grep "HTTP_STATUS" | cut -d: -f2)
+
+    local ok="yes"
+    [ "$STATUS" = "$4" ] || ok="no"
+    echo "$RESPONSE" | grep -q -- "$5" || ok="no"
+    echo "  Pattern: $5"
+    echo "  Status: $STATUS (expected $4)"
+    result "$1" "$2" "$ok"
+}
+
+# mails_to ADDRESS: how many emails MAIL_FILE holds for an address
+mails_to() {
+    grep -c "^To: $1" "$MAIL_FILE"
+}
+
+# last_link: the last reset link in MAIL_FILE
+last_link() {
+    grep -o "http[^[:space:]]*/reset-password?token=[^[:space:]]*" "$MAIL_FILE" | tail -n 1 | tr -d '\r'
+}
+
+# token_of LINK: the raw token in a reset link
+token_of() {
+    python3 -c 'import sys, urllib.parse as u; print(u.parse_qs(u.urlparse(sys.argv[1]).query)["token"][0])' "$1"
+}
+
+echo "========================================="
+echo "REQUESTING A RESET"
+echo "========================================="
+echo ""
+
+check "1" "The login page links to the reset form" \
+    "" GET "$BASE_URL/login" "200" 'href="/forgot-password"'
+
+check "2" "The reset form is shown" \
+    "" GET "$BASE_URL/forgot-password" "200" 'name="login"'
+
+BEFORE=$(mails_to "$EMAIL1")
+check "3" "A reset is requested by email address" \
+    "" POST "$BASE_URL/forgot-password" "200" "If an account matches" \
+    present -d "login=${EMAIL1}"
+
+echo "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"
+echo -e "${YELLOW}Test 4: A link is emailed to the user${NC}"
+AFTER=$(mails_to "$EMAIL1")
+LINK=$(last_link)
+TOKEN=$(token_of "$LINK")
+echo "  Emails: $BEFORE -> $AFTER (expected one more)"
+echo "  Link: $LINK"
+OK="no"
+[ "$AFTER" = "$((BEFORE + 1))" ] && [ -n "$TOKEN" ] && OK="yes"
+result "4" "" "$OK"
+
+echo "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"
+echo -e "${YELLOW}Test 5: Only a hash of the token is stored${NC}"
+STORED=$(sqlite3 "$DB_FILE" "SELECT token_hash FROM password_resets WHERE user_id = ${USER1_ID};")
+echo "  Stored: $STORED"
+OK="no"
+[ ${#STORED} = 64 ] && [ "$STORED" != "$TOKEN" ] && OK="yes"
+result "5" "" "$OK"
+
+check "6" "An unknown account gets the same answer" \
+    "" POST "$BASE_URL/forgot-password" "200" "If an account matches" \
+    present -d "login=pwrs_nobody_${TIMESTAMP}@test.com"
+
+check "7" "A second request right away..." \
+    "" POST "$BASE_URL/forgot-password" "200" "If an account matches" \
+    present -d "login=${USER1}"
+
+echo "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"
+echo -e "${YELLOW}Test 8: ...sends no email${NC}"
+COUNT=$(mails_to "$EMAIL1")
+echo "  Emails: $COUNT (expected $AFTER)"
+OK="no"
+[ "$COUNT" = "$AFTER" ] && OK="yes"
+result "8" "" "$OK"
+
+echo "========================================="
+echo "RESETTING"
+echo "========================================="
+echo ""
+
+check "9" "The link opens the new password form" \
+    "" GET "$LINK" "200" 'name="confirm_password"'
+
+check "10" "A made-up token is refused" \
+    "" GET "$BASE_URL/reset-password?token=pwrs-bogus-${TIMESTAMP}" "200" "invalid or has expired"
+
+reset "11" "The new password must follow the password policy" \
+    "$TOKEN" "200" "Password must contain at least one uppercase" "reset123!" "reset123!"
+
+reset "12" "The confirmation must match" \
+    "$TOKEN" "200" "Passwords do not match" "Reset123!" "Reset124!"
+
+# A second session of the same user, as if logged in on another device
+OTHER_TOKEN="pwrs-other-${TIMESTAMP}"
+sqlite3 "$DB_FILE" "INSERT INTO sessions (token, user_id, expires_at)
+    VALUES ('${OTHER_TOKEN}', ${USER1_ID}, '2099-01-01 00:00:00+00:00');"
+
+reset "13" "The password is reset" \
+    "$TOKEN" "303" "REDIRECT:.*/login?reset=1$" "Reset123!" "Reset123!"
+
+echo "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"
+echo -e "${YELLOW}Test 14: Every session of the user is ended${NC}"
+LEFT=$(sqlite3 "$DB_FILE" "SELECT COUNT(*) FROM sessions WHERE user_id = ${USER1_ID};")
+echo "  Sessions left: $LEFT (expected 0)"
+OK="no"
+[ "$LEFT" = "0" ] && OK="yes"
+result "14" "" "$OK"
+
+check "15" "The old session cookie no longer works" \
+    pwrs_user1.txt GET "$BASE_URL/account" "303" "REDIRECT:.*/login"
+
+check "16" "The login page confirms the reset" \
+    "" GET "$BASE_URL/login?reset=1" "200" "Your password has been reset"
+
+check "17" "The old password no longer logs in" \
+    "" POST "$BASE_URL/login" "200" "invalid username or password" \
+    present -d "username=${USER1}&password=Test123!"
+
+check "18" "The new password does" \
+    "" POST "$BASE_URL/login" "303" "" \
+    present -d "username=${USER1}&password=Reset123!"
+
+check "19" "The link works only once" \
+    "" GET "$LINK" "200" "invalid or has expired"
+
+reset "20" "Nor can the form be posted again" \
+    "$TOKEN" "200" "invalid or has expired" "Again123!" "Again123!"
+
+echo "========================================="
+echo "EXPIRY"
+echo "========================================="
+echo ""
+
+# Let the throttle pass, ask again, then expire the new link
+sqlite3 "$DB_FILE" "UPDATE password_resets
+    SET created_at = strftime('%Y-%m-%d %H:%M:%S+00:00', 'now', '-1 hour')
+    WHERE user_id = ${USER1_ID};"
+curl -s -X POST "$BASE_URL/forgot-password" -d "login=${USER1}" > /dev/null 2>&1
+LINK2=$(last_link)
+
+check "21" "After the throttle a new link is sent" \
+    "" GET "$LINK2" "200" 'name="confirm_password"'
+
+sqlite3 "$DB_FILE" "UPDATE password_resets
+    SET expires_at = strftime('%Y-%m-%d %H:%M:%S+00:00', 'now', '-1 minute')
+    WHERE user_id = ${USER1_ID} AND used_at IS NULL;"
+
+check "22" "An expired link is refused" \
+    "" GET "$LINK2" "200" "invalid or has expired"
+
+echo "========================================="
+echo "AUDIT AND ERRORS"
+echo "========================================="
+echo ""
+
+echo "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"
+echo -e "${YELLOW}Test 23: Requests (anonymous) and resets are audited${NC}"
+ACTIONS=$(sqlite3 "$DB_FILE" "SELECT action || ':' || COALESCE(actor_id, 0) || ':' || COUNT(*) FROM audit_events
+    WHERE target_type = 'user' AND target_id = ${USER1_ID}
+      AND action IN ('user.reset_request', 'user.password_reset')
+    GROUP BY action, actor_id ORDER BY action;" | tr '\n' ' ')
+echo "  Events (action:actor:count): $ACTIONS(expected user.password_reset:${USER1_ID}:1 user.reset_request:0:2)"
+OK="no"
+[ "$ACTIONS" = "user.password_reset:${USER1_ID}:1 user.reset_request:0:2 " ] && OK="yes"
+result "23" "" "$OK"
+
+check "24" "The forms only accept GET and POST" \
+    "" PUT "$BASE_URL/forgot-password" "405"
+
+check "25" "Including the new password form" \
+    "" DELETE "$BASE_URL/reset-password" "405"
+
+finish pwrs_user1.txt
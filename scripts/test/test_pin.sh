@@ -0,0 +1,149 @@
+#!/bin/bash
+
+source "$(dirname "$0")/lib.sh"
+
+echo "========================================="
+echo "Pinning Tests"
+echo "========================================="
+echo ""
+
+require_server
+require_db
+echo ""
+
+# Create an author and a moderator (admin)
+echo -e "${BLUE}[SETUP]${NC} Creating test users and posts..."
+TIMESTAMP=$(date +%s)
+AUTHOR="pin_${TIMESTAMP}"
+ADMIN="pin_a_${TIMESTAMP}"
+
+register "$AUTHOR" "$ADMIN"
+make_admin "$ADMIN"
+
+login "$AUTHOR" pin_author.txt
+login "$ADMIN" pin_admin.txt
+
+# The post to pin is created first, so without a pin the newer one is listed above it
+POST_URL=$(curl -s -o /dev/null -w "%{redirect_url}" -b pin_author.txt -X POST "$BASE_URL/post/create" \
+    -d "title=Pinned candidate ${TIMESTAMP}" \
+    -d "content=This post will be pinned and unpinned" \
+    -d "category_id[]=1")
+POST_ID=${POST_URL##*/}
+
+NEWER_URL=$(curl -s -o /dev/null -w "%{redirect_url}" -b pin_author.txt -X POST "$BASE_URL/post/create" \
+    -d "title=Newer post ${TIMESTAMP}" \
+    -d "content=This post is newer than the pinned one" \
+    -d "category_id[]=1")
+NEWER_ID=${NEWER_URL##*/}
+
+echo -e "${GREEN}✓${NC} Posts ${POST_ID} (to pin) and ${NEWER_ID} (newer) by ${AUTHOR}"
+echo ""
+
+# check_first NUM DESC URL POST_ID [is|not]
+# Checks whether POST_ID is the first post listed on the page
+check_first() {
+    local num="$1"
+    local desc="$2"
+    local url="$3"
+    local id="$4"
+    local mode="${5:-is}"
+
+    echo "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"
+    echo -e "${YELLOW}Test $num: $desc${NC}"
+    echo "  URL: GET $url"
+
+    local first
+    first=$(curl -s "$url" | grep -o 'href="/post/[0-9]*"' | head -1 | grep -o '[0-9]*')
+    echo "  First post: $first ($mode $id)"
+
+    local ok="no"
+    if { [ "$mode" = "is" ] && [ "$first" = "$id" ]; } || { [ "$mode" = "not" ] && [ "$first" != "$id" ]; }; then
+        ok="yes"
+    fi
+    result "$num" "$desc" "$ok"
+}
+
+echo "========================================="
+echo "PERMISSIONS"
+echo "========================================="
+echo ""
+
+check "1" "Non-admins cannot pin" \
+    pin_author.txt POST "$BASE_URL/post/${POST_ID}/pin?scope=category" "403"
+
+check "2" "Pin requires POST" \
+    pin_admin.txt GET "$BASE_URL/post/${POST_ID}/pin?scope=category" "405"
+
+check "3" "Unknown pin scope is rejected" \
+    pin_admin.txt POST "$BASE_URL/post/${POST_ID}/pin?scope=everywhere" "400"
+
+check "4" "Pinning a missing post is not found" \
+    pin_admin.txt POST "$BASE_URL/post/999999/pin?scope=global" "404"
+
+check "5" "Non-admins do not see pin controls" \
+    pin_author.txt GET "$BASE_URL/post/${POST_ID}" "200" "/pin\"" "absent"
+
+echo "========================================="
+echo "CATEGORY PINS"
+echo "========================================="
+echo ""
+
+check_first "6" "Newest post is listed first before pinning" \
+    "$BASE_URL/category/general" "$NEWER_ID"
+
+check "7" "Admin pins the post in its category" \
+    pin_admin.txt POST "$BASE_URL/post/${POST_ID}/pin?scope=category" "303"
+
+check_first "8" "Pinned post is listed first in its category" \
+    "$BASE_URL/category/general" "$POST_ID"
+
+check "9" "Category listing shows the pinned badge" \
+    "" GET "$BASE_URL/category/general" "200" "pinned-badge"
+
+check_first "10" "Category pin does not lift the post on the home page" \
+    "$BASE_URL/" "$POST_ID" "not"
+
+check "11" "Post page shows the pinned badge" \
+    "" GET "$BASE_URL/post/${POST_ID}" "200" "📌 Pinned<"
+
+echo "========================================="
+echo "GLOBAL PINS"
+echo "========================================="
+echo ""
+
+check "12" "Admin pins the post globally" \
+    pin_admin.txt POST "$BASE_URL/post/${POST_ID}/pin?scope=global" "303"
+
+check_first "13" "Globally pinned post is listed first on the home page" \
+    "$BASE_URL/" "$POST_ID"
+
+check_first "14" "Globally pinned post stays first in its category" \
+    "$BASE_URL/category/general" "$POST_ID"
+
+check "15" "Post page shows the global badge" \
+    "" GET "$BASE_URL/post/${POST_ID}" "200" "Pinned globally"
+
+check "16" "Pins are recorded in the audit log" \
+    pin_admin.txt GET "$BASE_URL/admin/audit?action=post.pin&id=${POST_ID}" "200" ">post.pin</a>"
+
+echo "========================================="
+echo "UNPINNING"
+echo "========================================="
+echo ""
+
+check "17" "Non-admins cannot unpin" \
+    pin_author.txt POST "$BASE_URL/post/${POST_ID}/unpin" "403"
+
+check "18" "Admin unpins the post" \
+    pin_admin.txt POST "$BASE_URL/post/${POST_ID}/unpin" "303"
+
+check_first "19" "Unpinned post drops from the top of the home page" \
+    "$BASE_URL/" "$POST_ID" "not"
+
+check_first "20" "Unpinned post drops from the top of its category" \
+    "$BASE_URL/category/general" "$NEWER_ID"
+
+check "21" "Post page no longer shows a badge" \
+    "" GET "$BASE_URL/post/${POST_ID}" "200" "pinned-badge\"" "absent"
+
+finish pin_author.txt pin_admin.txt
@@ -0,0 +1,119 @@
+#!/bin/bash
+
+source "$(dirname "$0")/lib.sh"
+
+echo "========================================="
+echo "Post Edit Tests"
+echo "========================================="
+echo ""
+
+require_server
+require_db
+echo ""
+
+# Create an author, another user and an admin
+echo -e "${BLUE}[SETUP]${NC} Creating test users and content..."
+TIMESTAMP=$(date +%s)
+AUTHOR="postedit_${TIMESTAMP}"
+OTHER="postedit_o_${TIMESTAMP}"
+ADMIN="postedit_a_${TIMESTAMP}"
+WORD="pe${TIMESTAMP}"   # Unique word in the post title
+
+register "$AUTHOR" "$OTHER" "$ADMIN"
+make_admin "$ADMIN"
+
+login "$AUTHOR" postedit_author.txt
+login "$OTHER" postedit_other.txt
+login "$ADMIN" postedit_admin.txt
+
+POST_URL=$(curl -s -o /dev/null -w "%{redirect_url}" -b postedit_author.txt -X POST "$BASE_URL/post/create" \
+    -d "title=Original ${WORD}" \
+    --data-urlencode $'content=First line stays\nSecond line has a typo\nThird line stays' \
+    -d "category_id[]=1")
+POST_ID=${POST_URL##*/}
+
+# Query strings for edit submissions (the handlers read the form from the URL too)
+CATS="category_id%5B%5D=1"
+EDIT="title=Edited+${WORD}&content=First+line+stays%0ASecond+line+is+fixed%0AThird+line+stays&${CATS}"
+
+echo -e "${GREEN}✓${NC} Post ${POST_ID} by ${AUTHOR}"
+echo ""
+
+echo "========================================="
+echo "PERMISSIONS"
+echo "========================================="
+echo ""
+
+check "1" "Anonymous users are sent to login" \
+    "" GET "$BASE_URL/post/${POST_ID}/edit" "303"
+
+check "2" "Other users cannot open the edit form" \
+    postedit_other.txt GET "$BASE_URL/post/${POST_ID}/edit" "403"
+
+check "3" "Other users cannot submit an edit" \
+    postedit_other.txt POST "$BASE_URL/post/${POST_ID}/edit?title=Hijacked+title&content=Hijacked+content+here&${CATS}" "403"
+
+check "4" "Author gets the form filled with the post" \
+    postedit_author.txt GET "$BASE_URL/post/${POST_ID}/edit" "200" "Original ${WORD}"
+
+check "5" "Edit form validates the title" \
+    postedit_author.txt POST "$BASE_URL/post/${POST_ID}/edit?title=ab&content=Long+enough+content&${CATS}" "200" "Title must be at least 3 characters"
+
+check "6" "Edit form validates the categories" \
+    postedit_author.txt POST "$BASE_URL/post/${POST_ID}/edit?title=Valid+title&content=Long+enough+content" "200" "class=\"error\""
+
+check "7" "Non-numeric post IDs are rejected" \
+    postedit_author.txt GET "$BASE_URL/post/abc/edit" "400"
+
+check "8" "Missing posts cannot be edited" \
+    postedit_author.txt GET "$BASE_URL/post/999999999/edit" "404"
+
+echo "========================================="
+echo "EDITING"
+echo "========================================="
+echo ""
+
+check "9" "Author saves an edit" \
+    postedit_author.txt POST "$BASE_URL/post/${POST_ID}/edit?${EDIT}" "303"
+
+check "10" "Post shows the new title" \
+    "" GET "$BASE_URL/post/${POST_ID}" "200" "Edited ${WORD}"
+
+check "11" "Post shows the edited marker" \
+    "" GET "$BASE_URL/post/${POST_ID}" "200" "class=\"edited-marker\""
+
+check "12" "Search finds the new title" \
+    "" GET "$BASE_URL/search?q=Edited+${WORD}" "200" "/post/${POST_ID}\""
+
+check "13" "Admin can edit any post" \
+    postedit_admin.txt POST "$BASE_URL/post/${POST_ID}/edit?title=Moderated+${WORD}&content=First+line+stays%0ASecond+line+is+fixed%0AThird+line+stays&${CATS}" "303"
+
+echo "========================================="
+echo "HISTORY"
+echo "========================================="
+echo ""
+
+check "14" "History is public and lists every version" \
+    "" GET "$BASE_URL/post/${POST_ID}/history" "200" "Version 3"
+
+check "15" "History shows the removed line" \
+    "" GET "$BASE_URL/post/${POST_ID}/history" "200" "diff-delete\">− Second line has a typo"
+
+check "16" "History shows the added line" \
+    "" GET "$BASE_URL/post/${POST_ID}/history" "200" "diff-insert\">+ Second line is fixed"
+
+check "17" "History shows the title change" \
+    "" GET "$BASE_URL/post/${POST_ID}/history" "200" "+ Title: Moderated ${WORD}"
+
+check "18" "History names the editor" \
+    "" GET "$BASE_URL/post/${POST_ID}/history" "200" "${ADMIN}"
+
+check "19" "History of a missing post is not found" \
+    "" GET "$BASE_URL/post/999999999/history" "404"
+
+check "20" "Author deletes the post" \
+    postedit_author.txt POST "$BASE_URL/post/${POST_ID}/delete" "303"
+check "21" "Deleted posts cannot be edited" \
+    postedit_author.txt GET "$BASE_URL/post/${POST_ID}/edit" "404"
+
+finish postedit_author.txt postedit_other.txt postedit_admin.txt
@@ -0,0 +1,123 @@
+#!/bin/bash
+
+source "$(dirname "$0")/lib.sh"
+
+echo "========================================="
+echo "User Profile Tests"
+echo "========================================="
+echo ""
+
+require_server
+echo ""
+
+# Create a user with a profile to look at and a user who votes
+echo -e "${BLUE}[SETUP]${NC} Creating test users and content..."
+TIMESTAMP=$(date +%s)
+AUTHOR="prof_${TIMESTAMP}"
+OTHER="prof_o_${TIMESTAMP}"
+
+register "$AUTHOR" "$OTHER"
+
+login "$AUTHOR" prof_author.txt
+login "$OTHER" prof_other.txt
+
+# Two posts by the author (one is deleted later) and a comment by them on the first
+POST_URL=$(curl -s -o /dev/null -w "%{redirect_url}" -b prof_author.txt -X POST "$BASE_URL/post/create" \
+    -d "title=Profile post ${TIMESTAMP}" \
+    -d "content=A post listed on the author's profile" \
+    -d "category_id[]=1")
+POST_ID=${POST_URL##*/}
+
+POST_URL=$(curl -s -o /dev/null -w "%{redirect_url}" -b prof_author.txt -X POST "$BASE_URL/post/create" \
+    -d "title=Doomed post ${TIMESTAMP}" \
+    -d "content=This post will be deleted" \
+    -d "category_id[]=1")
+DOOMED_ID=${POST_URL##*/}
+
+curl -s -o /dev/null -b prof_author.txt -X POST "$BASE_URL/comment/${POST_ID}" \
+    -d "content=Profile comment ${TIMESTAMP}"
+COMMENT_ID=$(curl -s "$BASE_URL/post/${POST_ID}" | grep -o 'id="comment-[0-9]*"' | head -1 | grep -o '[0-9]*')
+
+curl -s -o /dev/null -b prof_other.txt -X POST "$BASE_URL/post/${POST_ID}/like"
+curl -s -o /dev/null -b prof_other.txt -X POST "$BASE_URL/comment/${COMMENT_ID}/dislike?post_id=${POST_ID}"
+
+PROFILE="$BASE_URL/user/${AUTHOR}"
+
+echo "========================================="
+echo "PROFILE PAGE"
+echo "========================================="
+echo ""
+
+check "1" "Anyone can view a profile" \
+    "" GET "${PROFILE}" "200" "<h2>${AUTHOR}</h2>"
+
+check "2" "The join date is shown" \
+    "" GET "${PROFILE}" "200" "Joined "
+
+check "3" "The email address is never shown" \
+    "" GET "${PROFILE}" "200" "${AUTHOR}@test.com" "absent"
+
+check "4" "Not even to the user themselves" \
+    prof_author.txt GET "${PROFILE}" "200" "@test.com" "absent"
+
+check "5" "Without an avatar the first letter is shown" \
+    "" GET "${PROFILE}" "200" 'class="avatar avatar-placeholder">p<'
+
+check "6" "Post count" \
+    "" GET "${PROFILE}" "200" "<strong>2</strong> posts"
+
+check "7" "Comment count" \
+    "" GET "${PROFILE}" "200" "<strong>1</strong> comment<"
+
+check "8" "Likes received" \
+    "" GET "${PROFILE}" "200" "<strong>👍 1</strong> received"
+
+check "9" "Dislikes received" \
+    "" GET "${PROFILE}" "200" "<strong>👎 1</strong> received"
+
+check "10" "Recent posts are listed" \
+    "" GET "${PROFILE}" "200" "Profile post ${TIMESTAMP}</a>"
+
+check "11" "Recent comments link to their post" \
+    "" GET "${PROFILE}" "200" "href=\"/post/${POST_ID}#comment-${COMMENT_ID}\">Profile post ${TIMESTAMP}</a>"
+
+check "12" "The comment text is shown" \
+    "" GET "${PROFILE}" "200" "Profile comment ${TIMESTAMP}"
+
+echo "========================================="
+echo "DELETED CONTENT"
+echo "========================================="
+echo ""
+
+curl -s -o /dev/null -b prof_author.txt -X POST "$BASE_URL/post/${DOOMED_ID}/delete"
+
+check "13" "Deleted posts are not listed" \
+    "" GET "${PROFILE}" "200" "Doomed post ${TIMESTAMP}" "absent"
+
+check "14" "Nor counted" \
+    "" GET "${PROFILE}" "200" "<strong>1</strong> post<"
+
+echo "========================================="
+echo "LINKS AND ERRORS"
+echo "========================================="
+echo ""
+
+check "15" "Author names in the home listing link to profiles" \
+    "" GET "$BASE_URL/" "200" "href=\"/user/${AUTHOR}\""
+
+check "16" "Author names on a post page link to profiles" \
+    "" GET "$BASE_URL/post/${POST_ID}" "200" "href=\"/user/${AUTHOR}\""
+
+check "17" "Unknown users are not found" \
+    "" GET "$BASE_URL/user/no_such_user_${TIMESTAMP}" "404"
+
+check "18" "A username is required" \
+    "" GET "$BASE_URL/user/" "404"
+
+check "19" "Extra path segments are not found" \
+    "" GET "${PROFILE}/posts" "404"
+
+check "20" "Profiles only accept GET" \
+    prof_author.txt POST "${PROFILE}" "405"
+
+finish prof_author.txt prof_other.txt
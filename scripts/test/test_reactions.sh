@@ -0,0 +1,149 @@
+#!/bin/bash
+
+source "$(dirname "$0")/lib.sh"
+
+echo "========================================="
+echo "Reaction Tests"
+echo "========================================="
+echo ""
+
+require_server
+require_db
+echo ""
+
+# Create an author, a user who reacts and a moderator (admin)
+echo -e "${BLUE}[SETUP]${NC} Creating test users and content..."
+TIMESTAMP=$(date +%s)
+AUTHOR="react_${TIMESTAMP}"
+OTHER="react_o_${TIMESTAMP}"
+ADMIN="react_a_${TIMESTAMP}"
+
+register "$AUTHOR" "$OTHER" "$ADMIN"
+make_admin "$ADMIN"
+
+login "$AUTHOR" react_author.txt
+login "$OTHER" react_other.txt
+login "$ADMIN" react_admin.txt
+
+POST_URL=$(curl -s -o /dev/null -w "%{redirect_url}" -b react_author.txt -X POST "$BASE_URL/post/create" \
+    -d "title=Reaction target ${TIMESTAMP}" \
+    -d "content=This post collects reactions" \
+    -d "category_id[]=1")
+POST_ID=${POST_URL##*/}
+
+curl -s -o /dev/null -b react_author.txt -X POST "$BASE_URL/comment/${POST_ID}" \
+    -d "content=A comment to react to"
+COMMENT_ID=$(curl -s "$BASE_URL/post/${POST_ID}" | grep -o 'id="comment-[0-9]*"' | head -1 | grep -o '[0-9]*')
+
+POST_REACT="$BASE_URL/post/${POST_ID}/react"
+COMMENT_REACT="$BASE_URL/comment/${COMMENT_ID}/react"
+
+echo -e "${GREEN}✓${NC} Post ${POST_ID}, comment ${COMMENT_ID} by ${AUTHOR}"
+echo ""
+
+echo "========================================="
+echo "POST REACTIONS"
+echo "========================================="
+echo ""
+
+check "1" "Logged-in users get reaction buttons" \
+    react_other.txt GET "$BASE_URL/post/${POST_ID}" "200" "action=\"/post/${POST_ID}/react\""
+
+check "2" "Visitors get no buttons" \
+    "" GET "$BASE_URL/post/${POST_ID}" "200" "action=\"/post/${POST_ID}/react\"" "absent"
+
+check "3" "Reacting with love" \
+    react_other.txt POST "${POST_REACT}?reaction=love" "303"
+
+check "4" "The reaction is counted and marked as the user's own" \
+    react_other.txt GET "$BASE_URL/post/${POST_ID}" "200" 'reaction-mine" title="love">❤️ 1<'
+
+check "5" "Other users see the count, not marked as theirs" \
+    react_author.txt GET "$BASE_URL/post/${POST_ID}" "200" 'class="reaction" title="love">❤️ 1<'
+
+check "6" "Visitors see reactions that have a count" \
+    "" GET "$BASE_URL/post/${POST_ID}" "200" 'title="love">❤️ 1<'
+
+check "7" "A user can give several reactions" \
+    react_other.txt POST "${POST_REACT}?reaction=laugh" "303"
+
+check "8" "Both reactions are shown" \
+    react_author.txt GET "$BASE_URL/post/${POST_ID}" "200" 'title="laugh">😂 1<'
+
+check "9" "Reacting again removes the reaction" \
+    react_other.txt POST "${POST_REACT}?reaction=love" "303"
+
+check "10" "The count is gone" \
+    react_other.txt GET "$BASE_URL/post/${POST_ID}" "200" 'title="love">❤️<'
+
+check "11" "Reactions outside the configured set are rejected" \
+    react_other.txt POST "${POST_REACT}?reaction=angry" "400" "Unknown reaction"
+
+check "12" "A missing reaction is rejected" \
+    react_other.txt POST "${POST_REACT}" "400"
+
+check "13" "Reacting to a missing post is not found" \
+    react_other.txt POST "$BASE_URL/post/999999/react?reaction=love" "404"
+
+check "14" "Reactions only accept POST" \
+    react_other.txt GET "${POST_REACT}" "405"
+
+echo "========================================="
+echo "LIKES AND DISLIKES ARE REACTIONS"
+echo "========================================="
+echo ""
+
+check "15" "The like reaction is a like" \
+    react_other.txt POST "${POST_REACT}?reaction=like" "303"
+
+check "16" "The like button shows it" \
+    react_other.txt GET "$BASE_URL/post/${POST_ID}" "200" "👍 Like (1)"
+
+check "17" "A dislike reaction replaces the like" \
+    react_other.txt POST "${POST_REACT}?reaction=dislike" "303"
+
+check "18" "Only the dislike is counted" \
+    react_other.txt GET "$BASE_URL/post/${POST_ID}" "200" "👍 Like (0)"
+
+echo "========================================="
+echo "COMMENT REACTIONS"
+echo "========================================="
+echo ""
+
+check "19" "Reacting to a comment" \
+    react_other.txt POST "${COMMENT_REACT}?reaction=party&post_id=${POST_ID}" "303"
+
+check "20" "The comment shows the reaction" \
+    react_author.txt GET "$BASE_URL/post/${POST_ID}" "200" 'title="party">🎉 1<'
+
+check "21" "Reacting to a missing comment is not found" \
+    react_other.txt POST "$BASE_URL/comment/999999/react?reaction=party&post_id=${POST_ID}" "404"
+
+check "22" "A comment ID is required" \
+    react_other.txt POST "$BASE_URL/comment/react?reaction=party&post_id=${POST_ID}" "400"
+
+check "23" "post_id is required" \
+    react_other.txt POST "${COMMENT_REACT}?reaction=party" "400"
+
+echo "========================================="
+echo "AUDIT AND LOCKS"
+echo "========================================="
+echo ""
+
+check "24" "Reactions are recorded in the audit log" \
+    react_admin.txt GET "$BASE_URL/admin/audit?action=post.react&id=${POST_ID}" "200" ">post.react</a>"
+
+curl -s -o /dev/null -b react_admin.txt -X POST "$BASE_URL/post/${POST_ID}/lock"
+
+check "25" "Locked threads refuse post reactions" \
+    react_other.txt POST "${POST_REACT}?reaction=love" "403" "Thread Locked"
+
+check "26" "Locked threads refuse comment reactions" \
+    react_other.txt POST "${COMMENT_REACT}?reaction=love&post_id=${POST_ID}" "403"
+
+check "27" "Locked threads show no reaction buttons" \
+    react_other.txt GET "$BASE_URL/post/${POST_ID}" "200" "action=\"/post/${POST_ID}/react\"" "absent"
+
+curl -s -o /dev/null -b react_admin.txt -X POST "$BASE_URL/post/${POST_ID}/unlock"
+
+finish react_author.txt react_other.txt react_admin.txt
@@ -0,0 +1,79 @@
+#!/bin/bash
+
+source "$(dirname "$0")/lib.sh"
+
+echo "========================================="
+echo "Search Tests"
+echo "========================================="
+echo ""
+
+require_server
+echo ""
+
+# Create and login test user
+echo -e "${BLUE}[SETUP]${NC} Creating test user and searchable content..."
+TIMESTAMP=$(date +%s)
+TEST_USER="searchtest_${TIMESTAMP}"
+WORD="zq${TIMESTAMP}"          # Unique word for the post
+COMMENT_WORD="cq${TIMESTAMP}"  # Unique word that only appears in a comment
+
+register "$TEST_USER"
+
+login "$TEST_USER" search_cookies.txt
+
+POST_URL=$(curl -s -o /dev/null -w "%{redirect_url}" -b search_cookies.txt -X POST "$BASE_URL/post/create" \
+    -d "title=Searchable ${WORD}" \
+    -d "content=This post mentions ${WORD} <script>alert(1)</script> for search testing" \
+    -d "category_id[]=2")
+POST_ID=${POST_URL##*/}
+
+curl -s -o /dev/null -b search_cookies.txt -X POST "$BASE_URL/comment/${POST_ID}" \
+    -d "content=A comment containing ${COMMENT_WORD} only"
+
+echo -e "${GREEN}✓${NC} Logged in as ${TEST_USER}, created post ${POST_ID}"
+echo ""
+
+echo "========================================="
+echo "SEARCH TESTS"
+echo "========================================="
+echo ""
+
+check "1" "Empty query shows the form" \
+    search_cookies.txt GET "$BASE_URL/search" "200" 'name="q"'
+
+check "2" "Word in post title/content is found" \
+    search_cookies.txt GET "$BASE_URL/search?q=${WORD}" "200" "/post/${POST_ID}\""
+
+check "3" "Match is highlighted" \
+    search_cookies.txt GET "$BASE_URL/search?q=${WORD}" "200" "<mark>${WORD}</mark>"
+
+check "4" "Snippet HTML is escaped" \
+    search_cookies.txt GET "$BASE_URL/search?q=${WORD}" "200" "<script>alert(1)</script>" "absent"
+
+check "5" "Word in a comment finds the post" \
+    search_cookies.txt GET "$BASE_URL/search?q=${COMMENT_WORD}" "200" "In a comment:"
+
+check "6" "Category filter keeps matching posts" \
+    search_cookies.txt GET "$BASE_URL/search?q=${WORD}&category=tech" "200" "/post/${POST_ID}\""
+
+check "7" "Category filter excludes other categories" \
+    search_cookies.txt GET "$BASE_URL/search?q=${WORD}&category=off-topic" "200" "/post/${POST_ID}\"" "absent"
+
+check "8" "Author filter keeps the author's posts" \
+    search_cookies.txt GET "$BASE_URL/search?q=${WORD}&author=${TEST_USER}" "200" "/post/${POST_ID}\""
+
+check "9" "Author filter excludes other authors" \
+    search_cookies.txt GET "$BASE_URL/search?q=${WORD}&author=admin" "200" "/post/${POST_ID}\"" "absent"
+
+check "10" "Unknown category is rejected" \
+    search_cookies.txt GET "$BASE_URL/search?q=${WORD}&category=no-such-category" "400"
+
+check "11" "Search syntax characters are harmless" \
+    search_cookies.txt GET "$BASE_URL/search?q=%22%2A%28OR%20NEAR" "200"
+
+curl -s -o /dev/null -b search_cookies.txt -X POST "$BASE_URL/post/${POST_ID}/like"
+
+check "12" "Viewer vote state is shown" \
+    search_cookies.txt GET "$BASE_URL/search?q=${WORD}" "200" "You liked this"
+
+finish search_cookies.txt
@@ -0,0 +1,119 @@
+#!/bin/bash
+
+source "$(dirname "$0")/lib.sh"
+
+echo "========================================="
+echo "Soft Delete Tests"
+echo "========================================="
+echo ""
+
+require_server
+require_db
+echo ""
+
+# Create an author, another user and a moderator (admin)
+echo -e "${BLUE}[SETUP]${NC} Creating test users and content..."
+TIMESTAMP=$(date +%s)
+AUTHOR="softdel_${TIMESTAMP}"
+OTHER="softdel_o_${TIMESTAMP}"
+ADMIN="softdel_a_${TIMESTAMP}"
+WORD="sd${TIMESTAMP}"           # Unique word in the post title
+COMMENT_WORD="sc${TIMESTAMP}"   # Unique word in the comment
+
+register "$AUTHOR" "$OTHER" "$ADMIN"
+make_admin "$ADMIN"
+
+login "$AUTHOR" softdel_author.txt
+login "$OTHER" softdel_other.txt
+login "$ADMIN" softdel_admin.txt
+
+POST_URL=$(curl -s -o /dev/null -w "%{redirect_url}" -b softdel_author.txt -X POST "$BASE_URL/post/create" \
+    -d "title=Deletable ${WORD}" \
+    -d "content=This post will be deleted and restored" \
+    -d "category_id[]=1")
+POST_ID=${POST_URL##*/}
+
+curl -s -o /dev/null -b softdel_author.txt -X POST "$BASE_URL/comment/${POST_ID}" \
+    -d "content=A comment containing ${COMMENT_WORD} only"
+COMMENT_ID=$(curl -s "$BASE_URL/post/${POST_ID}" | grep -o 'id="comment-[0-9]*"' | head -1 | grep -o '[0-9]*')
+
+echo -e "${GREEN}✓${NC} Post ${POST_ID}, comment ${COMMENT_ID} by ${AUTHOR}"
+echo ""
+
+echo "========================================="
+echo "DELETING"
+echo "========================================="
+echo ""
+
+check "1" "Other users cannot delete the post" \
+    softdel_other.txt POST "$BASE_URL/post/${POST_ID}/delete" "403"
+
+check "2" "Other users cannot delete the comment" \
+    softdel_other.txt POST "$BASE_URL/comment/${COMMENT_ID}/delete" "403"
+
+check "3" "Delete requires POST" \
+    softdel_author.txt GET "$BASE_URL/post/${POST_ID}/delete" "405"
+
+check "4" "Author deletes own comment" \
+    softdel_author.txt POST "$BASE_URL/comment/${COMMENT_ID}/delete" "303"
+
+check "5" "Deleted comment shows a placeholder" \
+    "" GET "$BASE_URL/post/${POST_ID}" "200" "\[deleted\]"
+
+check "6" "Deleted comment text is hidden" \
+    "" GET "$BASE_URL/post/${POST_ID}" "200" "${COMMENT_WORD}" "absent"
+
+check "7" "Deleted comment cannot be liked" \
+    softdel_other.txt POST "$BASE_URL/comment/${COMMENT_ID}/like?post_id=${POST_ID}" "404"
+
+check "8" "Author deletes own post" \
+    softdel_author.txt POST "$BASE_URL/post/${POST_ID}/delete" "303"
+
+check "9" "Deleting twice is not found" \
+    softdel_author.txt POST "$BASE_URL/post/${POST_ID}/delete" "404"
+
+check "10" "Deleted post is gone from the home listing" \
+    "" GET "$BASE_URL/" "200" "Deletable ${WORD}" "absent"
+
+check "11" "Deleted post is gone from its category" \
+    "" GET "$BASE_URL/category/general" "200" "Deletable ${WORD}" "absent"
+
+check "12" "Deleted post is gone from search" \
+    "" GET "$BASE_URL/search?q=${WORD}" "200" "/post/${POST_ID}\"" "absent"
+
+check "13" "Deleted post page shows a placeholder" \
+    "" GET "$BASE_URL/post/${POST_ID}" "200" "This post has been deleted"
+
+check "14" "Deleted post cannot be commented on" \
+    softdel_other.txt POST "$BASE_URL/comment/${POST_ID}?content=Trying+to+comment+here" "404"
+
+echo "========================================="
+echo "RESTORING"
+echo "========================================="
+echo ""
+
+check "15" "Only admins see deleted content" \
+    softdel_author.txt GET "$BASE_URL/admin/deleted" "403"
+
+check "16" "Admin lists the deleted post" \
+    softdel_admin.txt GET "$BASE_URL/admin/deleted" "200" "Deletable ${WORD}"
+
+check "17" "Admin sees the deleted post text" \
+    softdel_admin.txt GET "$BASE_URL/post/${POST_ID}" "200" "Deletable ${WORD}"
+
+check "18" "Authors cannot restore" \
+    softdel_author.txt POST "$BASE_URL/post/${POST_ID}/restore" "403"
+
+check "19" "Admin restores the post" \
+    softdel_admin.txt POST "$BASE_URL/post/${POST_ID}/restore" "303"
+
+check "20" "Restored post is listed again" \
+    "" GET "$BASE_URL/" "200" "Deletable ${WORD}"
+
+check "21" "Admin restores the comment" \
+    softdel_admin.txt POST "$BASE_URL/comment/${COMMENT_ID}/restore" "303"
+
+check "22" "Restored comment text is shown" \
+    "" GET "$BASE_URL/post/${POST_ID}" "200" "${COMMENT_WORD}"
+
+finish softdel_author.txt softdel_other.txt softdel_admin.txt
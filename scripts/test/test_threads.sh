@@ -0,0 +1,143 @@
+#!/bin/bash
+
+source "$(dirname "$0")/lib.sh"
+
+echo "========================================="
+echo "Threaded Reply Tests"
+echo "========================================="
+echo "(assumes the default MAX_REPLY_DEPTH=5)"
+echo ""
+
+require_server
+echo ""
+
+# Create a commenter and two posts
+echo -e "${BLUE}[SETUP]${NC} Creating test users and content..."
+TIMESTAMP=$(date +%s)
+AUTHOR="thr_${TIMESTAMP}"
+
+register "$AUTHOR"
+login "$AUTHOR" thr_author.txt
+
+create_post() {
+    local url
+    url=$(curl -s -o /dev/null -w "%{redirect_url}" -b thr_author.txt -X POST "$BASE_URL/post/create" \
+        -d "title=$1 ${TIMESTAMP}" \
+        -d "content=A post for threaded reply tests" \
+        -d "category_id[]=1")
+    echo "${url##*/}"
+}
+POST_ID=$(create_post "Threaded post")
+OTHER_POST_ID=$(create_post "Other post")
+
+# comment_ids POST_ID prints the post's comment IDs in page order
+comment_ids() {
+    curl -s -b thr_author.txt "$BASE_URL/post/$1" | grep -o 'id="comment-[0-9]*"' | grep -o '[0-9]*' | tr '\n' ' '
+}
+
+# newest_comment POST_ID prints the highest comment ID on the post
+newest_comment() {
+    comment_ids "$1" | tr ' ' '\n' | sort -n | tail -1
+}
+
+curl -s -o /dev/null -b thr_author.txt -X POST "$BASE_URL/comment/${OTHER_POST_ID}" \
+    -d "content=A comment on the other post"
+OTHER_COMMENT_ID=$(newest_comment "$OTHER_POST_ID")
+
+echo -e "${GREEN}✓${NC} Posts ${POST_ID} and ${OTHER_POST_ID} by ${AUTHOR}"
+echo ""
+
+# check_order NUM DESC EXPECTED compares the post's comment IDs in page order
+check_order() {
+    local num="$1"
+    local desc="$2"
+    local expected="$3"
+
+    echo "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"
+    echo -e "${YELLOW}Test $num: $desc${NC}"
+    local order
+    order=$(comment_ids "$POST_ID")
+    echo "  Order: $order(expected $expected)"
+
+    local ok="no"
+    [ "$order" = "$expected" ] && ok="yes"
+    result "$num" "$desc" "$ok"
+}
+
+echo "========================================="
+echo "REPLYING"
+echo "========================================="
+echo ""
+
+check "1" "Top-level comments use the same URL as before" \
+    thr_author.txt POST "$BASE_URL/comment/${POST_ID}?content=First+top-level+comment" "303"
+FIRST_ID=$(newest_comment "$POST_ID")
+
+check "2" "A second top-level comment" \
+    thr_author.txt POST "$BASE_URL/comment/${POST_ID}?content=Second+top-level+comment" "303"
+SECOND_ID=$(newest_comment "$POST_ID")
+
+check "3" "Reply to the first comment" \
+    thr_author.txt POST "$BASE_URL/comment/${POST_ID}?parent_id=${FIRST_ID}&content=A+reply+to+the+first" "303"
+REPLY_ID=$(newest_comment "$POST_ID")
+
+check_order "4" "Replies follow their parent" \
+    "${FIRST_ID} ${REPLY_ID} ${SECOND_ID} "
+
+check "5" "Replies are indented" \
+    "" GET "$BASE_URL/post/${POST_ID}" "200" 'comment-reply" id="comment-'"${REPLY_ID}"'"'
+
+check "6" "Logged-in users get a reply form" \
+    thr_author.txt GET "$BASE_URL/post/${POST_ID}" "200" 'name="parent_id" value="'"${FIRST_ID}"'"'
+
+check "7" "Anonymous users get no reply form" \
+    "" GET "$BASE_URL/post/${POST_ID}" "200" 'name="parent_id"' "absent"
+
+check "8" "Invalid reply content stays on the reply form" \
+    thr_author.txt POST "$BASE_URL/comment/${POST_ID}?parent_id=${FIRST_ID}&content=short" "200" 'class="reply-box" open'
+
+echo "========================================="
+echo "INVALID PARENTS"
+echo "========================================="
+echo ""
+
+check "9" "Parent on another post is rejected" \
+    thr_author.txt POST "$BASE_URL/comment/${POST_ID}?parent_id=${OTHER_COMMENT_ID}&content=Reply+across+posts" "400"
+
+check "10" "Missing parent is not found" \
+    thr_author.txt POST "$BASE_URL/comment/${POST_ID}?parent_id=999999&content=Reply+to+nothing" "404"
+
+check "11" "Non-numeric parent ID is rejected" \
+    thr_author.txt POST "$BASE_URL/comment/${POST_ID}?parent_id=abc&content=Reply+to+abc" "400"
+
+check "12" "Negative parent ID is rejected" \
+    thr_author.txt POST "$BASE_URL/comment/${POST_ID}?parent_id=-1&content=Reply+to+minus+one" "400"
+
+curl -s -o /dev/null -b thr_author.txt -X POST "$BASE_URL/comment/${SECOND_ID}/delete"
+check "13" "Deleted comments cannot be replied to" \
+    thr_author.txt POST "$BASE_URL/comment/${POST_ID}?parent_id=${SECOND_ID}&content=Reply+to+deleted" "404"
+
+echo "========================================="
+echo "MAXIMUM DEPTH"
+echo "========================================="
+echo ""
+
+# REPLY_ID is at depth 1; build the chain down to depth 5
+PARENT_ID=$REPLY_ID
+for DEPTH in 2 3 4 5; do
+    curl -s -o /dev/null -b thr_author.txt -X POST "$BASE_URL/comment/${POST_ID}" \
+        -d "parent_id=${PARENT_ID}" -d "content=A reply at depth ${DEPTH}"
+    PREV_ID=$PARENT_ID
+    PARENT_ID=$(newest_comment "$POST_ID")
+done
+
+check "14" "Comments above the maximum depth can be replied to" \
+    thr_author.txt GET "$BASE_URL/post/${POST_ID}" "200" 'name="parent_id" value="'"${PREV_ID}"'"'
+
+check "15" "Comments at the maximum depth get no reply form" \
+    thr_author.txt GET "$BASE_URL/post/${POST_ID}" "200" 'name="parent_id" value="'"${PARENT_ID}"'"' "absent"
+
+check "16" "Replies below the maximum depth are rejected" \
+    thr_author.txt POST "$BASE_URL/comment/${POST_ID}?parent_id=${PARENT_ID}&content=One+level+too+deep" "400" "nested as deeply"
+
+finish thr_author.txt
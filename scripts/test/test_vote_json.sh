@@ -0,0 +1,149 @@
+#!/bin/bash
+
+source "$(dirname "$0")/lib.sh"
+JSON="application/json"
+
+# vote NUM DESC COOKIES ACCEPT URL EXPECTED_STATUS [PATTERN] [present|absent]
+# POSTs to URL with the given Accept header ("" for a plain form post) and
+# checks the response headers and body
+vote() {
+    local accept=()
+    [ -n "$4" ] && accept=(-H "Accept: $4")
+    check "$1" "$2" "$3" POST "$5" "$6" "$7" "${8:-present}" -i "${accept[@]}"
+}
+
+echo "========================================="
+echo "JSON Vote Tests"
+echo "========================================="
+echo ""
+
+require_server
+require_db
+echo ""
+
+# Create an author, a voter and a moderator (admin)
+echo -e "${BLUE}[SETUP]${NC} Creating test users and content..."
+TIMESTAMP=$(date +%s)
+AUTHOR="vjson_${TIMESTAMP}"
+OTHER="vjson_o_${TIMESTAMP}"
+ADMIN="vjson_a_${TIMESTAMP}"
+
+register "$AUTHOR" "$OTHER" "$ADMIN"
+make_admin "$ADMIN"
+
+login "$AUTHOR" vjson_author.txt
+login "$OTHER" vjson_other.txt
+login "$ADMIN" vjson_admin.txt
+
+POST_URL=$(curl -s -o /dev/null -w "%{redirect_url}" -b vjson_author.txt -X POST "$BASE_URL/post/create" \
+    -d "title=JSON vote target ${TIMESTAMP}" \
+    -d "content=This post is voted on without reloading" \
+    -d "category_id[]=1")
+POST_ID=${POST_URL##*/}
+
+curl -s -o /dev/null -b vjson_author.txt -X POST "$BASE_URL/comment/${POST_ID}" \
+    -d "content=A comment to vote on"
+COMMENT_ID=$(curl -s "$BASE_URL/post/${POST_ID}" | grep -o 'id="comment-[0-9]*"' | head -1 | grep -o '[0-9]*')
+
+echo -e "${GREEN}✓${NC} Post ${POST_ID}, comment ${COMMENT_ID} by ${AUTHOR}"
+echo ""
+
+echo "========================================="
+echo "POST VOTES"
+echo "========================================="
+echo ""
+
+vote "1" "Liking returns the fresh counts" \
+    vjson_other.txt "$JSON" "$BASE_URL/post/${POST_ID}/like" "200" \
+    '{"like_count":1,"dislike_count":0,"user_vote":"like"}'
+
+vote "2" "The response is JSON (and liking again removes the vote)" \
+    vjson_other.txt "$JSON" "$BASE_URL/post/${POST_ID}/like" "200" "Content-Type: application/json"
+
+vote "3" "A dislike from another user is counted" \
+    vjson_author.txt "$JSON" "$BASE_URL/post/${POST_ID}/dislike" "200" \
+    '{"like_count":0,"dislike_count":1,"user_vote":"dislike"}'
+
+vote "4" "Switching a vote moves the count" \
+    vjson_author.txt "$JSON" "$BASE_URL/post/${POST_ID}/like" "200" \
+    '{"like_count":1,"dislike_count":0,"user_vote":"like"}'
+
+vote "5" "Toggling off reports no vote" \
+    vjson_author.txt "$JSON" "$BASE_URL/post/${POST_ID}/like" "200" \
+    '{"like_count":0,"dislike_count":0,"user_vote":null}'
+
+vote "6" "Accept lists with JSON are understood" \
+    vjson_other.txt "text/html;q=0.9, application/json" "$BASE_URL/post/${POST_ID}/like" "200" '"user_vote":"like"'
+
+vote "7" "Form posts are still redirected to the thread" \
+    vjson_other.txt "" "$BASE_URL/post/${POST_ID}/like" "303" "Location: /post/${POST_ID}"
+
+echo "========================================="
+echo "COMMENT VOTES"
+echo "========================================="
+echo ""
+
+vote "8" "Liking a comment returns its counts" \
+    vjson_other.txt "$JSON" "$BASE_URL/comment/${COMMENT_ID}/like?post_id=${POST_ID}" "200" \
+    '{"like_count":1,"dislike_count":0,"user_vote":"like"}'
+
+vote "9" "Disliking a comment switches the vote" \
+    vjson_other.txt "$JSON" "$BASE_URL/comment/${COMMENT_ID}/dislike?post_id=${POST_ID}" "200" \
+    '{"like_count":0,"dislike_count":1,"user_vote":"dislike"}'
+
+vote "10" "Form posts on comments are still redirected" \
+    vjson_other.txt "" "$BASE_URL/comment/${COMMENT_ID}/dislike?post_id=${POST_ID}" "303" "Location: /post/${POST_ID}"
+
+echo "========================================="
+echo "ERRORS"
+echo "========================================="
+echo ""
+
+vote "11" "Anonymous JSON votes get 401 instead of a redirect" \
+    "" "$JSON" "$BASE_URL/post/${POST_ID}/like" "401" '"error":'
+
+vote "12" "Anonymous form votes are still sent to the login page" \
+    "" "" "$BASE_URL/post/${POST_ID}/like" "303" "Location: /login"
+
+vote "13" "A missing post is a JSON 404" \
+    vjson_other.txt "$JSON" "$BASE_URL/post/999999/like" "404" '"error":'
+
+vote "14" "An invalid comment ID is a JSON 400" \
+    vjson_other.txt "$JSON" "$BASE_URL/comment/abc/like?post_id=${POST_ID}" "400" '"error":'
+
+vote "15" "JSON comment votes need no post_id" \
+    vjson_other.txt "$JSON" "$BASE_URL/comment/${COMMENT_ID}/like" "200" '"user_vote":"like"'
+
+vote "16" "Form errors are still HTML pages" \
+    vjson_other.txt "" "$BASE_URL/post/999999/like" "404" "<html"
+
+curl -s -o /dev/null -b vjson_admin.txt -X POST "$BASE_URL/post/${POST_ID}/lock"
+
+vote "17" "Locked threads answer JSON votes with 403" \
+    vjson_other.txt "$JSON" "$BASE_URL/post/${POST_ID}/dislike" "403" '"error":"This thread is locked'
+
+curl -s -o /dev/null -b vjson_admin.txt -X POST "$BASE_URL/post/${POST_ID}/unlock"
+
+echo "========================================="
+echo "REACTIONS"
+echo "========================================="
+echo ""
+
+vote "18" "Reacting returns whether the reaction is now set" \
+    vjson_other.txt "$JSON" "$BASE_URL/post/${POST_ID}/react?reaction=party" "200" \
+    '{"reaction":"party","reacted":true}'
+
+vote "19" "Comment reactions need no post_id from JSON clients" \
+    vjson_other.txt "$JSON" "$BASE_URL/comment/${COMMENT_ID}/react?reaction=party" "200" \
+    '{"reaction":"party","reacted":true}'
+
+vote "20" "Anonymous JSON reactions get 401" \
+    "" "$JSON" "$BASE_URL/post/${POST_ID}/react?reaction=party" "401" '"error":'
+
+vote "21" "An unknown reaction is a JSON 400" \
+    vjson_other.txt "$JSON" "$BASE_URL/post/${POST_ID}/react?reaction=nope" "400" '{"error":"Unknown reaction."}'
+
+vote "22" "Reacting to a missing comment is a JSON 404" \
+    vjson_other.txt "$JSON" "$BASE_URL/comment/999999/react?reaction=party" "404" '"error":'
+
+finish vjson_author.txt vjson_other.txt vjson_admin.txt